@@ -0,0 +1,282 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	spokeManagerName    = "spoke manager"
+	hubManagerName      = "hub manager"
+	healthCheckInterval = 30 * time.Second
+	uxBackendProxyPort  = "8888"
+
+	// Reasons of the Degraded condition of the ManagedClusterAddOn
+	ReasonAgentHealthy              = "AsExpected"
+	ReasonManagerNotRunning         = "ManagerNotRunning"
+	ReasonStorageClusterNotFound    = "StorageClusterNotFound"
+	ReasonUXBackendProxyUnreachable = "UXBackendProxyUnreachable"
+	ReasonHealthCheckFailed         = "HealthCheckFailed"
+)
+
+var errManagerNotStarted = errors.New("manager has not started yet")
+
+// electionState is the progress of the replica in the leader election of the spoke manager
+type electionState int
+
+const (
+	// electionStopped is the state of a replica whose spoke manager is not running, it is starting or restarting
+	electionStopped electionState = iota
+	// electionWaiting is the state of a replica whose spoke manager runs and waits to win the leader election
+	electionWaiting
+	// electionLeading is the state of the replica running the managers
+	electionLeading
+)
+
+// AgentHealth tracks whether the hub and spoke managers of the agent are running with synced caches
+type AgentHealth struct {
+	mu       sync.RWMutex
+	managers map[string]error
+	election electionState
+}
+
+func NewAgentHealth(managerNames ...string) *AgentHealth {
+	h := &AgentHealth{managers: make(map[string]error)}
+	for _, name := range managerNames {
+		h.managers[name] = errManagerNotStarted
+	}
+	return h
+}
+
+func (h *AgentHealth) setManagerState(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.managers[name] = err
+}
+
+func (h *AgentHealth) setElectionState(state electionState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.election = state
+}
+
+// Check returns an error naming the managers which are not running. Replicas waiting to win the leader election
+// are ready to take over, while a replica whose spoke manager stopped is not ready until it runs again.
+func (h *AgentHealth) Check() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var failures []string
+	switch h.election {
+	case electionWaiting:
+		return nil
+	case electionStopped:
+		failures = append(failures, "leader election: spoke manager is not running")
+	}
+	for name, err := range h.managers {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	slices.Sort(failures)
+	return errors.New(strings.Join(failures, "; "))
+}
+
+// IsHealthy is a health check of the lease updater. The lease of the addon is not renewed while a manager is not
+// running, which turns the ManagedClusterAddOn unavailable.
+func (h *AgentHealth) IsHealthy() bool {
+	return h.Check() == nil
+}
+
+// ManagerRunnable reports the manager as running once its caches are synced and as stopped once it shuts down
+func (h *AgentHealth) ManagerRunnable(name string, mgr ctrl.Manager) manager.Runnable {
+	return manager.RunnableFunc(func(ctx context.Context) error {
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			h.setManagerState(name, errors.New("caches did not sync"))
+			return nil
+		}
+		h.setManagerState(name, nil)
+		<-ctx.Done()
+		h.setManagerState(name, errors.New("manager stopped"))
+		return nil
+	})
+}
+
+// ElectionRunnable reports the replica as waiting for the leader election while its spoke manager runs. It runs on
+// every replica, before the spoke manager is elected.
+func (h *AgentHealth) ElectionRunnable() manager.Runnable {
+	return &electionRunnable{health: h}
+}
+
+type electionRunnable struct {
+	health *AgentHealth
+}
+
+func (r *electionRunnable) Start(ctx context.Context) error {
+	r.health.mu.Lock()
+	// The replica may already be elected if the leader runnables started first
+	if r.health.election != electionLeading {
+		r.health.election = electionWaiting
+	}
+	r.health.mu.Unlock()
+	<-ctx.Done()
+	r.health.setElectionState(electionStopped)
+	return nil
+}
+
+func (r *electionRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+// LeaderRunnable reports the replica as leading once it is elected. A replica stops leading only when its spoke
+// manager stops, it is reported as stopped until the manager is restarted and waits for the leader election again.
+func (h *AgentHealth) LeaderRunnable() manager.Runnable {
+	return manager.RunnableFunc(func(ctx context.Context) error {
+		h.setElectionState(electionLeading)
+		<-ctx.Done()
+		h.setElectionState(electionStopped)
+		return nil
+	})
+}
+
+// AgentHealthReporter surfaces the health of the agent as the Degraded condition of its ManagedClusterAddOn, the
+// progress of its upgrade as the AgentUpgraded condition and the publishing of the cluster CIDRs as the
+// ClusterCIDRsPublished condition
+type AgentHealthReporter struct {
+	Health           *AgentHealth
+	Migrator         *AgentMigrator
+	ClusterCIDRs     *ClusterCIDRReconciler
+	HubClient        client.Client
+	SpokeClient      client.Client
+	SpokeClusterName string
+	// UXBackendProxyAddress is the host:port of the ux-backend-proxy serving the onboarding tokens
+	UXBackendProxyAddress string
+	Logger                *slog.Logger
+
+	// dial checks the reachability of an address, it is replaced in tests
+	dial func(ctx context.Context, address string) error
+}
+
+// Start reports the health of the agent until the context is cancelled
+func (r *AgentHealthReporter) Start(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.report(ctx); err != nil {
+			r.Logger.Error("Failed to report agent health", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *AgentHealthReporter) report(ctx context.Context) error {
+	condition := r.getDegradedCondition(ctx)
+
+	var addon addonapiv1alpha1.ManagedClusterAddOn
+	namespacedName := types.NamespacedName{Name: setup.TokenExchangeName, Namespace: r.SpokeClusterName}
+	if err := r.HubClient.Get(ctx, namespacedName, &addon); err != nil {
+		return fmt.Errorf("failed to get ManagedClusterAddOn %q: %w", namespacedName, err)
+	}
+	original := addon.DeepCopy()
+	condition.ObservedGeneration = addon.Generation
+	changed := meta.SetStatusCondition(&addon.Status.Conditions, condition)
+	if r.Migrator != nil {
+		upgraded := r.Migrator.Condition()
+		upgraded.ObservedGeneration = addon.Generation
+		changed = meta.SetStatusCondition(&addon.Status.Conditions, upgraded) || changed
+	}
+	if r.ClusterCIDRs != nil {
+		if published, ok := r.ClusterCIDRs.Condition(); ok {
+			published.ObservedGeneration = addon.Generation
+			changed = meta.SetStatusCondition(&addon.Status.Conditions, published) || changed
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.HubClient.Status().Patch(ctx, &addon, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to update the conditions of ManagedClusterAddOn %q: %w", namespacedName, err)
+	}
+	r.Logger.Info("Reported agent health", "Degraded", condition.Status, "Reason", condition.Reason, "Message", condition.Message)
+	return nil
+}
+
+// getDegradedCondition checks the managers of the agent, the StorageClusters of the spoke cluster and the
+// reachability of the ux-backend-proxy serving the onboarding tokens
+func (r *AgentHealthReporter) getDegradedCondition(ctx context.Context) metav1.Condition {
+	degraded := func(reason string, err error) metav1.Condition {
+		return metav1.Condition{
+			Type:    addonapiv1alpha1.ManagedClusterAddOnConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		}
+	}
+
+	if err := r.Health.Check(); err != nil {
+		return degraded(ReasonManagerNotRunning, err)
+	}
+
+	var storageClusterList ocsv1.StorageClusterList
+	if err := r.SpokeClient.List(ctx, &storageClusterList); err != nil {
+		return degraded(ReasonHealthCheckFailed, fmt.Errorf("failed to list StorageClusters: %w", err))
+	}
+	if len(storageClusterList.Items) == 0 {
+		return degraded(ReasonStorageClusterNotFound, errors.New("no StorageCluster found on the managed cluster"))
+	}
+
+	// Only StorageClusters in internal mode serve onboarding tokens to their peers
+	if slices.ContainsFunc(storageClusterList.Items, func(sc ocsv1.StorageCluster) bool { return !sc.Spec.ExternalStorage.Enable }) {
+		dial := r.dial
+		if dial == nil {
+			dial = dialAddress
+		}
+		if err := dial(ctx, r.UXBackendProxyAddress); err != nil {
+			return degraded(ReasonUXBackendProxyUnreachable, fmt.Errorf("ux-backend-proxy at %q is unreachable: %w", r.UXBackendProxyAddress, err))
+		}
+	}
+
+	return metav1.Condition{
+		Type:    addonapiv1alpha1.ManagedClusterAddOnConditionDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonAgentHealthy,
+		Message: "The token exchange agent is healthy",
+	}
+}
+
+// getUXBackendProxyAddress returns the address of the ux-backend-proxy of the ODF operator namespace
+func getUXBackendProxyAddress(namespace string) string {
+	return net.JoinHostPort(fmt.Sprintf("ux-backend-proxy.%s.svc.cluster.local", namespace), uxBackendProxyPort)
+}
+
+func dialAddress(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,89 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAgentHealthReport(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := ocsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := addonapiv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: setup.TokenExchangeName, Namespace: "cluster1"},
+	}
+	storageCluster := &ocsv1.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs-storagecluster", Namespace: odfNamespace},
+	}
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(addon).WithStatusSubresource(addon).Build()
+	fakeSpokeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	health := NewAgentHealth(spokeManagerName, hubManagerName)
+	var dialed string
+	dialErr := errors.New("connection refused")
+	r := AgentHealthReporter{
+		Health:                health,
+		HubClient:             fakeHubClient,
+		SpokeClient:           fakeSpokeClient,
+		SpokeClusterName:      "cluster1",
+		UXBackendProxyAddress: getUXBackendProxyAddress(odfNamespace),
+		Logger:                utils.GetLogger(utils.GetZapLogger(true)),
+		dial: func(_ context.Context, address string) error {
+			dialed = address
+			return dialErr
+		},
+	}
+
+	ctx := context.TODO()
+	assertReason := func(status metav1.ConditionStatus, reason string) {
+		t.Helper()
+		if err := r.report(ctx); err != nil {
+			t.Fatalf("AgentHealthReporter report() failed. Error: %s", err)
+		}
+		var got addonapiv1alpha1.ManagedClusterAddOn
+		if err := fakeHubClient.Get(ctx, types.NamespacedName{Name: addon.Name, Namespace: addon.Namespace}, &got); err != nil {
+			t.Fatal(err)
+		}
+		condition := meta.FindStatusCondition(got.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionDegraded)
+		if condition == nil || condition.Status != status || condition.Reason != reason {
+			t.Errorf("expected Degraded=%s with reason %s, got %+v", status, reason, condition)
+		}
+	}
+
+	assertReason(metav1.ConditionTrue, ReasonManagerNotRunning)
+	if health.IsHealthy() {
+		t.Error("expected the agent to be unhealthy before its managers started")
+	}
+
+	health.setElectionState(electionLeading)
+	health.setManagerState(spokeManagerName, nil)
+	health.setManagerState(hubManagerName, nil)
+	assertReason(metav1.ConditionTrue, ReasonStorageClusterNotFound)
+
+	if err := fakeSpokeClient.Create(ctx, storageCluster); err != nil {
+		t.Fatal(err)
+	}
+	assertReason(metav1.ConditionTrue, ReasonUXBackendProxyUnreachable)
+	if dialed != getUXBackendProxyAddress(odfNamespace) {
+		t.Errorf("expected the ux-backend-proxy to be dialed, got %q", dialed)
+	}
+
+	dialErr = nil
+	assertReason(metav1.ConditionFalse, ReasonAgentHealthy)
+}
@@ -0,0 +1,184 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/version"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// AgentMigrationsConfigMapName records the migrations completed by the agent on the managed cluster, keyed by
+	// migration name with the agent version which completed them
+	AgentMigrationsConfigMapName = "odf-multicluster-agent-migrations"
+
+	migrationRetryInterval = 30 * time.Second
+)
+
+// AgentMigration is an upgrade step of the agent. Migrations must be idempotent, a migration interrupted by a
+// restart of the agent runs again.
+type AgentMigration struct {
+	Name string
+	Run  func(ctx context.Context, m *AgentMigrator) error
+}
+
+// getAgentMigrations returns the migrations of the agent in the order they run. Migrations are only ever appended,
+// their names are recorded on the managed clusters.
+func getAgentMigrations() []AgentMigration {
+	return []AgentMigration{}
+}
+
+// AgentMigrator runs the pending migrations of the agent in order and tracks the AgentUpgraded condition which the
+// AgentHealthReporter reports on the ManagedClusterAddOn. Once all migrations completed, it publishes the version of
+// the agent as the AgentVersionClusterClaimName ClusterClaim. The hub waits for the agents of both peers to complete
+// their migrations before creating artifacts which the previous agents do not understand.
+type AgentMigrator struct {
+	HubClient        client.Client
+	SpokeClient      client.Client
+	SpokeClusterName string
+	CurrentNamespace string
+	// Migrations defaults to getAgentMigrations
+	Migrations []AgentMigration
+	Logger     *slog.Logger
+
+	mu        sync.RWMutex
+	condition *metav1.Condition
+}
+
+// Condition returns the AgentUpgraded condition of the agent
+func (m *AgentMigrator) Condition() metav1.Condition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.condition == nil {
+		return metav1.Condition{
+			Type:    setup.AgentUpgradedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  setup.ReasonAgentUpgradeInProgress,
+			Message: fmt.Sprintf("The token exchange agent has not started the migrations of version %s yet", version.Version),
+		}
+	}
+	return *m.condition
+}
+
+func (m *AgentMigrator) setCondition(status metav1.ConditionStatus, reason, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.condition = &metav1.Condition{
+		Type:    setup.AgentUpgradedCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// Start runs the pending migrations, retrying the failed migration until all migrations completed or the context is
+// cancelled
+func (m *AgentMigrator) Start(ctx context.Context) {
+	ticker := time.NewTicker(migrationRetryInterval)
+	defer ticker.Stop()
+	for {
+		err := m.migrate(ctx)
+		if err == nil {
+			return
+		}
+		m.Logger.Error("Failed to migrate the agent", "Version", version.Version, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// migrate runs the migrations which have not been recorded as completed yet and records each of them as soon as it
+// completed, so that an interrupted upgrade resumes with the first pending migration
+func (m *AgentMigrator) migrate(ctx context.Context) error {
+	migrations := m.Migrations
+	if migrations == nil {
+		migrations = getAgentMigrations()
+	}
+
+	var completed corev1.ConfigMap
+	namespacedName := types.NamespacedName{Name: AgentMigrationsConfigMapName, Namespace: m.CurrentNamespace}
+	err := m.SpokeClient.Get(ctx, namespacedName, &completed)
+	if k8serrors.IsNotFound(err) {
+		completed = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      AgentMigrationsConfigMapName,
+				Namespace: m.CurrentNamespace,
+				Labels:    map[string]string{utils.CreatedByLabelKey: setup.TokenExchangeName},
+			},
+		}
+		err = m.SpokeClient.Create(ctx, &completed)
+	}
+	if err != nil {
+		m.setCondition(metav1.ConditionFalse, setup.ReasonAgentUpgradeFailed, fmt.Sprintf("Failed to get the completed migrations: %v", err))
+		return fmt.Errorf("failed to get ConfigMap %q: %w", namespacedName, err)
+	}
+
+	for _, migration := range migrations {
+		if _, ok := completed.Data[migration.Name]; ok {
+			continue
+		}
+		m.setCondition(metav1.ConditionFalse, setup.ReasonAgentUpgradeInProgress,
+			fmt.Sprintf("The token exchange agent is running migration %q of version %s", migration.Name, version.Version))
+		m.Logger.Info("Running agent migration", "Migration", migration.Name, "Version", version.Version)
+		if err := migration.Run(ctx, m); err != nil {
+			m.setCondition(metav1.ConditionFalse, setup.ReasonAgentUpgradeFailed, fmt.Sprintf("Migration %q failed: %v", migration.Name, err))
+			return fmt.Errorf("migration %q failed: %w", migration.Name, err)
+		}
+
+		if completed.Data == nil {
+			completed.Data = make(map[string]string)
+		}
+		completed.Data[migration.Name] = version.Version
+		if err := m.SpokeClient.Update(ctx, &completed); err != nil {
+			m.setCondition(metav1.ConditionFalse, setup.ReasonAgentUpgradeFailed, fmt.Sprintf("Failed to record migration %q: %v", migration.Name, err))
+			return fmt.Errorf("failed to record migration %q in ConfigMap %q: %w", migration.Name, namespacedName, err)
+		}
+		m.Logger.Info("Completed agent migration", "Migration", migration.Name)
+	}
+
+	if err := m.publishVersion(ctx); err != nil {
+		m.setCondition(metav1.ConditionFalse, setup.ReasonAgentUpgradeFailed, fmt.Sprintf("Failed to publish the agent version: %v", err))
+		return err
+	}
+
+	m.setCondition(metav1.ConditionTrue, setup.ReasonAgentUpgradeCompleted, setup.AgentVersionMessage(version.Version))
+	m.Logger.Info("Agent migrations completed", "Version", version.Version)
+	return nil
+}
+
+// publishVersion publishes the version of the agent as a ClusterClaim, which the hub reads from the ManagedCluster
+// status to find out whether the agent completed its upgrade
+func (m *AgentMigrator) publishVersion(ctx context.Context) error {
+	claim := clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: setup.AgentVersionClusterClaimName,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, m.SpokeClient, &claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+		claim.Labels[utils.CreatedByLabelKey] = utils.CreatorMulticlusterOrchestrator
+		claim.Spec.Value = version.Version
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update ClusterClaim %q: %w", claim.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/version"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAgentMigratorMigrate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := addonapiv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: setup.TokenExchangeName, Namespace: "cluster1"},
+	}
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(addon).WithStatusSubresource(addon).Build()
+	fakeSpokeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	var ran []string
+	failSecond := errors.New("hub unreachable")
+	m := &AgentMigrator{
+		HubClient:        fakeHubClient,
+		SpokeClient:      fakeSpokeClient,
+		SpokeClusterName: "cluster1",
+		CurrentNamespace: "openshift-operators",
+		Migrations: []AgentMigration{
+			{Name: "first", Run: func(_ context.Context, _ *AgentMigrator) error {
+				ran = append(ran, "first")
+				return nil
+			}},
+			{Name: "second", Run: func(_ context.Context, _ *AgentMigrator) error {
+				ran = append(ran, "second")
+				return failSecond
+			}},
+			{Name: "third", Run: func(_ context.Context, _ *AgentMigrator) error {
+				ran = append(ran, "third")
+				return nil
+			}},
+		},
+		Logger: utils.GetLogger(utils.GetZapLogger(true)),
+	}
+	r := AgentHealthReporter{
+		Health:           NewAgentHealth(),
+		Migrator:         m,
+		HubClient:        fakeHubClient,
+		SpokeClient:      fakeSpokeClient,
+		SpokeClusterName: "cluster1",
+		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+	}
+
+	ctx := context.TODO()
+	reportedVersion := func() (string, bool) {
+		t.Helper()
+		if err := r.report(ctx); err != nil {
+			t.Fatalf("AgentHealthReporter report() failed. Error: %s", err)
+		}
+		// The ClusterClaims of the spoke cluster are synced to the status of its ManagedCluster
+		var managedCluster clusterv1.ManagedCluster
+		var claimList clusterv1alpha1.ClusterClaimList
+		if err := fakeSpokeClient.List(ctx, &claimList); err != nil {
+			t.Fatal(err)
+		}
+		for _, claim := range claimList.Items {
+			managedCluster.Status.ClusterClaims = append(managedCluster.Status.ClusterClaims,
+				clusterv1.ManagedClusterClaim{Name: claim.Name, Value: claim.Spec.Value})
+		}
+		return setup.GetAgentVersion(&managedCluster)
+	}
+
+	if _, ok := reportedVersion(); ok {
+		t.Error("expected no agent version to be reported before the migrations ran")
+	}
+
+	if err := m.migrate(ctx); !errors.Is(err, failSecond) {
+		t.Fatalf("expected migration second to fail, got %v", err)
+	}
+	if condition := m.Condition(); condition.Reason != setup.ReasonAgentUpgradeFailed {
+		t.Errorf("expected reason %s, got %+v", setup.ReasonAgentUpgradeFailed, condition)
+	}
+	if _, ok := reportedVersion(); ok {
+		t.Error("expected no agent version to be reported while a migration fails")
+	}
+
+	var completed corev1.ConfigMap
+	if err := fakeSpokeClient.Get(ctx, types.NamespacedName{Name: AgentMigrationsConfigMapName, Namespace: m.CurrentNamespace}, &completed); err != nil {
+		t.Fatal(err)
+	}
+	if len(completed.Data) != 1 || completed.Data["first"] != version.Version {
+		t.Errorf("expected only migration first to be recorded, got %v", completed.Data)
+	}
+
+	// The migrations resume with the failed migration
+	failSecond = nil
+	if err := m.migrate(ctx); err != nil {
+		t.Fatalf("AgentMigrator migrate() failed. Error: %s", err)
+	}
+	if expected := []string{"first", "second", "second", "third"}; !slices.Equal(ran, expected) {
+		t.Errorf("expected migrations %v to run, got %v", expected, ran)
+	}
+	if reported, ok := reportedVersion(); !ok || reported != version.Version {
+		t.Errorf("expected agent version %q to be reported, got %q", version.Version, reported)
+	}
+
+	// Completed migrations do not run again after a restart
+	ran = nil
+	if err := m.migrate(ctx); err != nil {
+		t.Fatalf("AgentMigrator migrate() failed. Error: %s", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("expected no migration to run again, got %v", ran)
+	}
+}
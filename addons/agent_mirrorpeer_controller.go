@@ -23,6 +23,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -32,10 +33,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -49,8 +52,14 @@ type MirrorPeerReconciler struct {
 	SpokeClient          client.Client
 	SpokeClusterName     string
 	OdfOperatorNamespace string
+	ProviderClients      *ProviderClients
 	Logger               *slog.Logger
 
+	// UXBackendProxyAddress is the host:port of the ux-backend-proxy, the in-cluster service by default
+	UXBackendProxyAddress string
+	// ServiceAccountToken authenticates the agent to the ux-backend-proxy, the mounted token by default
+	ServiceAccountToken ServiceAccountTokenSource
+
 	testEnvFile      string
 	CurrentNamespace string
 }
@@ -75,7 +84,7 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	hasStorageClientRef, err := utils.IsStorageClientType(ctx, r.SpokeClient, mirrorPeer, true)
+	hasStorageClientRef, err := r.isStorageClientProvider(ctx, mirrorPeer)
 	logger.Info("MirrorPeer has client reference?", "True/False", hasStorageClientRef)
 
 	if err != nil {
@@ -102,15 +111,15 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	agentFinalizer := r.SpokeClusterName + "." + SpokeMirrorPeerFinalizer
-	if len(agentFinalizer) > 63 {
-		agentFinalizer = fmt.Sprintf("%s.%s", r.SpokeClusterName[0:10], SpokeMirrorPeerFinalizer)
-	}
+	agentFinalizer := GetAgentFinalizer(r.SpokeClusterName)
+	legacyAgentFinalizer := GetLegacyAgentFinalizer(r.SpokeClusterName)
 
 	if mirrorPeer.GetDeletionTimestamp().IsZero() {
-		if !utils.ContainsString(mirrorPeer.GetFinalizers(), agentFinalizer) {
+		// The legacy finalizer is replaced in the same update, the MirrorPeer is never left without a finalizer of the agent
+		legacyFinalizerRemoved := legacyAgentFinalizer != "" && controllerutil.RemoveFinalizer(&mirrorPeer, legacyAgentFinalizer)
+		if !utils.ContainsString(mirrorPeer.GetFinalizers(), agentFinalizer) || legacyFinalizerRemoved {
 			logger.Info("Adding finalizer to MirrorPeer", "finalizer", agentFinalizer)
-			mirrorPeer.Finalizers = append(mirrorPeer.Finalizers, agentFinalizer)
+			controllerutil.AddFinalizer(&mirrorPeer, agentFinalizer)
 			if err := r.HubClient.Update(ctx, &mirrorPeer); err != nil {
 				logger.Error("Failed to add finalizer to MirrorPeer", "error", err)
 				return ctrl.Result{}, err
@@ -119,7 +128,7 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	} else {
 		var addonDeletionlock corev1.ConfigMap
 		err = r.SpokeClient.Get(ctx, types.NamespacedName{Namespace: r.CurrentNamespace, Name: AddonDeletionlockName}, &addonDeletionlock)
-		if err != nil {
+		if err != nil && !errors.IsNotFound(err) {
 			return ctrl.Result{}, err
 		}
 
@@ -142,9 +151,11 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, err
 		}
 
-		err = removeFinalizerFromObject(ctx, r.HubClient, &mirrorPeer, agentFinalizer)
-		if err != nil {
-			return ctrl.Result{}, err
+		legacyFinalizerRemoved := legacyAgentFinalizer != "" && controllerutil.RemoveFinalizer(&mirrorPeer, legacyAgentFinalizer)
+		if controllerutil.RemoveFinalizer(&mirrorPeer, agentFinalizer) || legacyFinalizerRemoved {
+			if err := r.HubClient.Update(ctx, &mirrorPeer); err != nil {
+				return ctrl.Result{}, err
+			}
 		}
 
 		logger.Info("MirrorPeer deletion complete")
@@ -173,6 +184,7 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 		err = labelDefaultStorageClasses(ctx, logger, r.SpokeClient, scr.Name, scr.Namespace, storageIds)
 		if err != nil {
+			storageClassLabellingFailures.WithLabelValues(scr.Name).Inc()
 			return ctrl.Result{}, fmt.Errorf("an unknown error has occurred while labelling default StorageClasses: %v", err)
 		}
 
@@ -187,7 +199,9 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logger.Info("Labeled the default VolumeSnapshotClasses successfully")
 	}
 
-	if mirrorPeer.Spec.Type == multiclusterv1alpha1.Async && hasStorageClientRef {
+	// StorageCluster peers of a heterogeneous MirrorPeer are peered with the provider of the StorageClient peer
+	storageClusterPeering := hasStorageClientRef || mirrorPeer.Annotations[utils.StorageClusterPeeringAnnotationKey] == "true"
+	if mirrorPeer.Spec.Type == multiclusterv1alpha1.Async && storageClusterPeering {
 		// TODO(techdebt): Ideally we'd like to cleanup tokens after use and not re-generate token once clients are peered.
 		// But, we currently lack the machinery to make that decision precisely. As a middleground, we will generate a token
 		// and not clean it up. We will re-generate it when it expires.
@@ -213,6 +227,7 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				return ctrl.Result{}, err
 			}
 			logger.Info("Successfully unmarshalled onboarding ticket", "ticketData", ticketData)
+			onboardingTokenExpiration.WithLabelValues(mirrorPeer.Name).Set(float64(ticketData.ExpirationDate))
 			if ticketData.ExpirationDate > time.Now().Unix() {
 				logger.Info("Onboarding token has not expired yet. Not renewing it.", "Token", token.Name, "ExpirationDate", ticketData.ExpirationDate)
 				return ctrl.Result{}, nil
@@ -224,7 +239,16 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 		logger.Info("Creating a new onboarding token", "Token", token.Name)
-		err = createStorageClusterPeerTokenSecret(ctx, r.HubClient, r.Scheme, r.SpokeClusterName, r.OdfOperatorNamespace, mirrorPeer, scr)
+		proxyAddress := r.UXBackendProxyAddress
+		if proxyAddress == "" {
+			proxyAddress = getUXBackendProxyAddress(r.OdfOperatorNamespace)
+		}
+		serviceAccountToken := r.ServiceAccountToken
+		if serviceAccountToken == nil {
+			serviceAccountToken = inClusterServiceAccountToken
+		}
+		err = createStorageClusterPeerTokenSecret(ctx, r.HubClient, r.Scheme, r.SpokeClusterName, serviceAccountToken, proxyAddress, mirrorPeer, scr)
+		onboardingTokenRenewals.WithLabelValues(mirrorPeer.Name, metricsResult(err)).Inc()
 		if err != nil {
 			logger.Error("Failed to create StorageCluster peer token on the hub.", "error", err)
 			return ctrl.Result{}, err
@@ -235,6 +259,21 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{}, nil
 }
 
+// isStorageClientProvider returns true when the spoke cluster takes part in the MirrorPeer as the provider of a
+// StorageClient peer rather than as a StorageCluster peer. The peers of a MirrorPeer may be of different types.
+func (r *MirrorPeerReconciler) isStorageClientProvider(ctx context.Context, mirrorPeer multiclusterv1alpha1.MirrorPeer) (bool, error) {
+	peerRef, err := utils.GetPeerRefForSpokeCluster(&mirrorPeer, r.SpokeClusterName)
+	if err != nil {
+		// The spoke cluster is not a peer, it only provides the storage of a StorageClient peer
+		return true, nil
+	}
+	peerRefType, err := utils.GetPeerRefType(ctx, r.SpokeClient, *peerRef, true)
+	if err != nil {
+		return false, err
+	}
+	return peerRefType == utils.PeerRefTypeStorageClient, nil
+}
+
 func labelDefaultStorageClasses(ctx context.Context, logger *slog.Logger, client client.Client, storageClusterName string, storageClusterNamespace string, storageIdsMap map[utils.CephType]string) error {
 	storageClasses, err := utils.GetDefaultStorageClasses(ctx, client, storageClusterName)
 	if err != nil {
@@ -367,6 +406,8 @@ func (r *MirrorPeerReconciler) hasSpokeCluster(obj client.Object) bool {
 	return false
 }
 
+// hasProviderSpokeCluster returns true when a peer of the MirrorPeer is a StorageClient of the spoke cluster. It only
+// checks the in-memory ProviderClients view since it runs in the event filters.
 func (r *MirrorPeerReconciler) hasProviderSpokeCluster(obj client.Object) bool {
 	mp, ok := obj.(*multiclusterv1alpha1.MirrorPeer)
 	if !ok {
@@ -375,15 +416,9 @@ func (r *MirrorPeerReconciler) hasProviderSpokeCluster(obj client.Object) bool {
 	if mp.Status.Phase == multiclusterv1alpha1.IncompatibleVersion {
 		return false
 	}
-	peerRefs, err := utils.GetPeerRefForProviderCluster(context.TODO(), r.SpokeClient, r.HubClient, mp)
-	if err != nil {
-		r.Logger.Error("Unable to reconcile MirrorPeer", "MirrorPeer", mp.GetName(), "Error", err)
-		return false
-	}
-	if len(peerRefs) > 0 {
-		return true
-	}
-	return false
+	return slices.ContainsFunc(mp.Spec.Items, func(peerRef multiclusterv1alpha1.PeerRef) bool {
+		return r.ProviderClients.Has(peerRef.ClusterName)
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -406,7 +441,7 @@ func (r *MirrorPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	tokenToMirrorPeerMapFunc := func(ctx context.Context, obj client.Object) []ctrl.Request {
 		reqs := []ctrl.Request{}
 		var mirrorPeerList multiclusterv1alpha1.MirrorPeerList
-		err := r.HubClient.List(ctx, &mirrorPeerList)
+		err := r.HubClient.List(ctx, &mirrorPeerList, client.MatchingFields{utils.MirrorPeerPeerRefIndexKey: obj.GetName()})
 		if err != nil {
 			r.Logger.Error("Unable to reconcile MirrorPeer based on token changes.", "error", err)
 			return reqs
@@ -415,23 +450,38 @@ func (r *MirrorPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			if mirrorpeer.Status.Phase == multiclusterv1alpha1.IncompatibleVersion {
 				continue
 			}
-			for _, peerRef := range mirrorpeer.Spec.Items {
-				name := utils.GetSecretNameByPeerRef(peerRef)
-				if name == obj.GetName() {
-					reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mirrorpeer.Name}})
-					break
-				}
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mirrorpeer.Name}})
+		}
+		return reqs
+	}
+
+	// MirrorPeers filtered out before the spoke cluster became the provider of one of their peers are reconciled once
+	// the ProviderClients view changes
+	providerClientsToMirrorPeerMapFunc := func(ctx context.Context, _ client.Object) []ctrl.Request {
+		reqs := []ctrl.Request{}
+		mirrorPeers, err := utils.ListMirrorPeersForClusters(ctx, r.HubClient, r.ProviderClients.ClusterNames())
+		if err != nil {
+			r.Logger.Error("Unable to reconcile MirrorPeer based on StorageClient changes.", "error", err)
+			return reqs
+		}
+		for _, mirrorpeer := range mirrorPeers {
+			if r.hasProviderSpokeCluster(&mirrorpeer) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mirrorpeer.Name}})
 			}
 		}
 		return reqs
 	}
 
 	r.Logger.Info("Setting up controller with manager")
-	mpPredicate := predicate.And(predicate.GenerationChangedPredicate{}, mirrorPeerSpokeClusterPredicate)
+	mpPredicate := predicate.And(
+		predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		mirrorPeerSpokeClusterPredicate,
+	)
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("agent_mirrorpeer_controller").
 		For(&multiclusterv1alpha1.MirrorPeer{}, builder.WithPredicates(mpPredicate)).
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(tokenToMirrorPeerMapFunc), builder.WithPredicates(utils.InternalSecretPredicate)).
+		WatchesRawSource(source.Channel(r.ProviderClients.Changed(), handler.EnqueueRequestsFromMapFunc(providerClientsToMirrorPeerMapFunc))).
 		Complete(r)
 }
 
@@ -467,6 +517,30 @@ func (r *MirrorPeerReconciler) deleteMirrorPeer(ctx context.Context, mirrorPeer
 		return ctrl.Result{}, fmt.Errorf("failed to delete S3 buckets")
 	}
 
+	deleteMirrorPeerMetrics(mirrorPeer.Name)
+
 	r.Logger.Info("Successfully completed the deletion of MirrorPeer resources", "MirrorPeer", mirrorPeer.Name)
 	return ctrl.Result{}, nil
 }
+
+// GetAgentFinalizer returns the finalizer added to MirrorPeers by the agent running on the given ManagedCluster. A
+// name too long for a finalizer is truncated and suffixed with its hash, which keeps the finalizers of clusters
+// sharing the same prefix distinct.
+func GetAgentFinalizer(spokeClusterName string) string {
+	agentFinalizer := spokeClusterName + "." + SpokeMirrorPeerFinalizer
+	if len(agentFinalizer) > 63 {
+		agentFinalizer = fmt.Sprintf("%s-%08x.%s", spokeClusterName[0:setup.TruncatedClusterNameLength],
+			utils.FnvHash(spokeClusterName), SpokeMirrorPeerFinalizer)
+	}
+	return agentFinalizer
+}
+
+// GetLegacyAgentFinalizer returns the finalizer added by earlier agents to the MirrorPeers of a cluster with a name
+// too long for a finalizer, they truncated it to its first 10 characters. It returns an empty string for other
+// clusters.
+func GetLegacyAgentFinalizer(spokeClusterName string) string {
+	if len(spokeClusterName+"."+SpokeMirrorPeerFinalizer) <= 63 {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", spokeClusterName[0:10], SpokeMirrorPeerFinalizer)
+}
@@ -384,3 +384,28 @@ func TestDeleteS3(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAgentFinalizer(t *testing.T) {
+	if got := GetAgentFinalizer("cluster1"); got != "cluster1."+SpokeMirrorPeerFinalizer {
+		t.Errorf("unexpected finalizer %q", got)
+	}
+	if got := GetLegacyAgentFinalizer("cluster1"); got != "" {
+		t.Errorf("expected no legacy finalizer, got %q", got)
+	}
+
+	// Clusters with long names sharing the same prefix get distinct finalizers
+	cluster1 := "cluster-with-a-name-long-enough-to-truncate-the-finalizer-1"
+	cluster2 := "cluster-with-a-name-long-enough-to-truncate-the-finalizer-2"
+	finalizer1, finalizer2 := GetAgentFinalizer(cluster1), GetAgentFinalizer(cluster2)
+	if finalizer1 == finalizer2 {
+		t.Errorf("expected distinct finalizers, got %q for both clusters", finalizer1)
+	}
+	for _, finalizer := range []string{finalizer1, finalizer2} {
+		if len(finalizer) != 63 {
+			t.Errorf("expected finalizer %q to have 63 characters, got %d", finalizer, len(finalizer))
+		}
+	}
+	if got := GetLegacyAgentFinalizer(cluster1); got != "cluster-wi."+SpokeMirrorPeerFinalizer {
+		t.Errorf("unexpected legacy finalizer %q", got)
+	}
+}
@@ -0,0 +1,213 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	storageClusterPeerCRDName               = "storageclusterpeers.ocs.openshift.io"
+	volumeGroupReplicationClassCRDName      = "volumegroupreplicationclasses.replication.storage.openshift.io"
+	cephBlockPoolRadosNamespaceCRDName      = "cephblockpoolradosnamespaces.ceph.rook.io"
+	flattenModeMinimumStorageClusterVersion = "4.17.0"
+)
+
+// ODFCapabilitiesReconciler publishes the ODF capabilities of the spoke cluster as a ClusterClaim. The hub only creates
+// the resources of a feature on ManagedClusters advertising its capability.
+type ODFCapabilitiesReconciler struct {
+	Scheme      *runtime.Scheme
+	SpokeClient client.Client
+	Logger      *slog.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ODFCapabilitiesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Logger.Info("Setting up controller with manager")
+
+	crdPredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return slices.Contains([]string{storageClusterPeerCRDName, volumeGroupReplicationClassCRDName, cephBlockPoolRadosNamespaceCRDName}, object.GetName())
+	})
+
+	storageClusterVersionPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldStorageCluster, ok := e.ObjectOld.(*ocsv1.StorageCluster)
+			if !ok {
+				return false
+			}
+			newStorageCluster, ok := e.ObjectNew.(*ocsv1.StorageCluster)
+			if !ok {
+				return false
+			}
+			return oldStorageCluster.Status.Version != newStorageCluster.Status.Version
+		},
+	}
+
+	eventHandler := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []ctrl.Request {
+		return []ctrl.Request{reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: utils.CapabilitiesClusterClaimName,
+			},
+		}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("odf_capabilities_controller").
+		For(&clusterv1alpha1.ClusterClaim{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(object client.Object) bool {
+				return object.GetName() == utils.CapabilitiesClusterClaimName
+			}),
+		)).
+		Watches(&extv1.CustomResourceDefinition{}, eventHandler, builder.WithPredicates(crdPredicate)).
+		Watches(&ocsv1.StorageCluster{}, eventHandler, builder.WithPredicates(storageClusterVersionPredicate)).
+		Complete(r)
+}
+
+func (r *ODFCapabilitiesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With("Request", req.NamespacedName.String())
+	logger.Info("Publishing ODF capabilities")
+
+	capabilities, err := r.getCapabilities(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to detect ODF capabilities: %w", err)
+	}
+
+	var names []string
+	for _, capability := range capabilities {
+		names = append(names, string(capability))
+	}
+	slices.Sort(names)
+
+	claim := clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: utils.CapabilitiesClusterClaimName,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.SpokeClient, &claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+		claim.Labels[utils.CreatedByLabelKey] = utils.CreatorMulticlusterOrchestrator
+		claim.Spec.Value = strings.Join(names, ",")
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create or update ClusterClaim %q: %w", claim.Name, err)
+	}
+
+	logger.Info("Successfully published ODF capabilities", "Capabilities", names)
+	return ctrl.Result{}, nil
+}
+
+// getCapabilities detects the capabilities from the installed CRDs and from the version of the StorageClusters
+func (r *ODFCapabilitiesReconciler) getCapabilities(ctx context.Context) ([]utils.Capability, error) {
+	var capabilities []utils.Capability
+
+	storageClusterPeerCRD, err := r.getCRD(ctx, storageClusterPeerCRDName)
+	if err != nil {
+		return nil, err
+	}
+	if storageClusterPeerCRD != nil {
+		capabilities = append(capabilities, utils.CapabilityStorageClusterPeer)
+	}
+
+	volumeGroupReplicationClassCRD, err := r.getCRD(ctx, volumeGroupReplicationClassCRDName)
+	if err != nil {
+		return nil, err
+	}
+	if volumeGroupReplicationClassCRD != nil {
+		capabilities = append(capabilities, utils.CapabilityVolumeGroupReplication)
+	}
+
+	radosNamespaceCRD, err := r.getCRD(ctx, cephBlockPoolRadosNamespaceCRDName)
+	if err != nil {
+		return nil, err
+	}
+	if radosNamespaceCRD != nil && hasSpecProperty(radosNamespaceCRD, "mirroring") {
+		capabilities = append(capabilities, utils.CapabilityRadosNamespaceMirroring)
+	}
+
+	flattenMode, err := r.supportsFlattenMode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if flattenMode {
+		capabilities = append(capabilities, utils.CapabilityFlattenMode)
+	}
+
+	return capabilities, nil
+}
+
+// getCRD returns the CRD or nil when it is not installed
+func (r *ODFCapabilitiesReconciler) getCRD(ctx context.Context, name string) (*extv1.CustomResourceDefinition, error) {
+	var crd extv1.CustomResourceDefinition
+	if err := r.SpokeClient.Get(ctx, types.NamespacedName{Name: name}, &crd); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get CustomResourceDefinition %q: %w", name, err)
+	}
+	return &crd, nil
+}
+
+// supportsFlattenMode returns true when all StorageClusters run a version whose VolumeReplicationClasses accept the
+// flattenMode parameter
+func (r *ODFCapabilitiesReconciler) supportsFlattenMode(ctx context.Context) (bool, error) {
+	var storageClusterList ocsv1.StorageClusterList
+	if err := r.SpokeClient.List(ctx, &storageClusterList); err != nil {
+		return false, fmt.Errorf("failed to list StorageClusters: %w", err)
+	}
+	if len(storageClusterList.Items) == 0 {
+		return false, nil
+	}
+	minimum := semver.MustParse(flattenModeMinimumStorageClusterVersion)
+	for _, storageCluster := range storageClusterList.Items {
+		v, err := semver.ParseTolerant(storageCluster.Status.Version)
+		if err != nil {
+			r.Logger.Info("Unable to parse StorageCluster version", "StorageCluster", client.ObjectKeyFromObject(&storageCluster), "Version", storageCluster.Status.Version)
+			return false, nil
+		}
+		// Pre-releases of a version provide the features of the version
+		if (semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}).LT(minimum) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hasSpecProperty returns true when a served version of the CRD has the property in its spec
+func hasSpecProperty(crd *extv1.CustomResourceDefinition, property string) bool {
+	for _, version := range crd.Spec.Versions {
+		if !version.Served || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		spec, ok := version.Schema.OpenAPIV3Schema.Properties["spec"]
+		if !ok {
+			continue
+		}
+		if _, ok := spec.Properties[property]; ok {
+			return true
+		}
+	}
+	return false
+}
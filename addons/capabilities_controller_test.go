@@ -0,0 +1,115 @@
+package addons
+
+import (
+	"context"
+	"testing"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestODFCapabilitiesReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := extv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := ocsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	crd := func(name string, specProperties ...string) *extv1.CustomResourceDefinition {
+		spec := extv1.JSONSchemaProps{Properties: map[string]extv1.JSONSchemaProps{}}
+		for _, property := range specProperties {
+			spec.Properties[property] = extv1.JSONSchemaProps{Type: "object"}
+		}
+		return &extv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: extv1.CustomResourceDefinitionSpec{
+				Versions: []extv1.CustomResourceDefinitionVersion{{
+					Name:   "v1",
+					Served: true,
+					Schema: &extv1.CustomResourceValidation{
+						OpenAPIV3Schema: &extv1.JSONSchemaProps{
+							Properties: map[string]extv1.JSONSchemaProps{"spec": spec},
+						},
+					},
+				}},
+			},
+		}
+	}
+	storageCluster := func(namespace, version string) *ocsv1.StorageCluster {
+		return &ocsv1.StorageCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocs-storagecluster", Namespace: namespace},
+			Status:     ocsv1.StorageClusterStatus{Version: version},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    string
+	}{
+		{
+			name:    "No ODF installed",
+			objects: nil,
+			want:    "",
+		},
+		{
+			name: "All capabilities",
+			objects: []client.Object{
+				crd(storageClusterPeerCRDName),
+				crd(volumeGroupReplicationClassCRDName),
+				crd(cephBlockPoolRadosNamespaceCRDName, "blockPoolName", "mirroring"),
+				storageCluster("openshift-storage", "4.18.0"),
+			},
+			want: "FlattenMode,RadosNamespaceMirroring,StorageClusterPeer,VolumeGroupReplication",
+		},
+		{
+			name: "Older ODF version",
+			objects: []client.Object{
+				crd(cephBlockPoolRadosNamespaceCRDName, "blockPoolName"),
+				storageCluster("openshift-storage", "4.18.0"),
+				storageCluster("openshift-storage-extended", "4.16.3"),
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build()
+			r := ODFCapabilitiesReconciler{
+				Scheme:      scheme,
+				SpokeClient: fakeClient,
+				Logger:      utils.GetLogger(utils.GetZapLogger(true)),
+			}
+
+			ctx := context.TODO()
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.CapabilitiesClusterClaimName}}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("ODFCapabilitiesReconciler Reconcile() failed. Error: %s", err)
+			}
+
+			var claim clusterv1alpha1.ClusterClaim
+			if err := fakeClient.Get(ctx, req.NamespacedName, &claim); err != nil {
+				t.Fatalf("failed to get ClusterClaim. Error: %s", err)
+			}
+			if claim.Spec.Value != tt.want {
+				t.Errorf("expected ClusterClaim value %q, got %q", tt.want, claim.Spec.Value)
+			}
+			if claim.Labels[utils.CreatedByLabelKey] != utils.CreatorMulticlusterOrchestrator {
+				t.Errorf("expected ClusterClaim to be labelled as created by %q", utils.CreatorMulticlusterOrchestrator)
+			}
+		})
+	}
+}
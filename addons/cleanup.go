@@ -0,0 +1,345 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/zapr"
+	obv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	templatev1 "github.com/openshift/api/template/v1"
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	agentStopPollInterval = 2 * time.Second
+	agentStopTimeout      = 2 * time.Minute
+)
+
+// AddonCleanupOptions defines the flags for the pre-delete cleanup of the agent
+type AddonCleanupOptions struct {
+	KubeconfigFile string
+	RetainDRData   bool
+	DevMode        bool
+	// InstallNamespace is the namespace of the addon on the managed cluster, see AddonAgentOptions
+	InstallNamespace string
+
+	testEnvFile string
+}
+
+// NewAddonCleanupCommand returns the command run by the pre-delete hook Job of the addon. The addon manager removes
+// the addon only once the Job completed.
+func NewAddonCleanupCommand() *cobra.Command {
+	o := &AddonCleanupOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Revert the changes of the addon agent on the managed cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			o.RunCleanup(cmd.Context())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.KubeconfigFile, "kubeconfig", "", "Paths to a kubeconfig. Only required if out-of-cluster.")
+	flags.BoolVar(&o.RetainDRData, "retain-dr-data", false, "Keep the ObjectBucketClaims, storage IDs and VolumeReplicationClasses used by disaster recovery.")
+	flags.BoolVar(&o.DevMode, "dev", false, "Set to true for dev environment (Text logging)")
+	flags.StringVar(&o.InstallNamespace, "install-namespace", "", "Namespace of the addon on the managed cluster. Defaults to the namespace of the Job pod.")
+	flags.StringVar(&o.testEnvFile, "test-dotenv", "", "Path to a dotenv file for testing purpose only.")
+
+	return cmd
+}
+
+// RunCleanup reverts the changes of the agent on the managed cluster and exits with an error code on failure, the
+// Job then retries the cleanup
+func (o *AddonCleanupOptions) RunCleanup(ctx context.Context) {
+	zapLogger := utils.GetZapLogger(o.DevMode)
+	defer func() {
+		if err := zapLogger.Sync(); err != nil {
+			zapLogger.Error("Failed to sync zap logger")
+		}
+	}()
+	ctrl.SetLogger(zapr.NewLogger(zapLogger))
+	logger := utils.GetLogger(zapLogger)
+
+	spokeKubeConfig, err := utils.GetClientConfig(o.KubeconfigFile)
+	if err != nil {
+		logger.Error("Failed to get kubeconfig", "error", err)
+		os.Exit(1)
+	}
+	spokeClient, err := utils.GetClientFromConfig(spokeKubeConfig, mgrScheme)
+	if err != nil {
+		logger.Error("Failed to get spoke client", "error", err)
+		os.Exit(1)
+	}
+
+	installNamespace := o.InstallNamespace
+	if installNamespace == "" {
+		installNamespace = utils.GetEnv("POD_NAMESPACE", o.testEnvFile)
+	}
+	// The agent runs on the hosting cluster in hosted mode
+	agentClient := spokeClient
+	if o.KubeconfigFile != "" {
+		agentKubeConfig, err := utils.GetClientConfig("")
+		if err != nil {
+			logger.Error("Failed to get in-cluster kubeconfig", "error", err)
+			os.Exit(1)
+		}
+		agentClient, err = utils.GetClientFromConfig(agentKubeConfig, mgrScheme)
+		if err != nil {
+			logger.Error("Failed to get agent client", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	cleanup := &AgentCleanup{
+		SpokeClient:      spokeClient,
+		AgentClient:      agentClient,
+		AgentNamespace:   utils.GetEnv("POD_NAMESPACE", o.testEnvFile),
+		CurrentNamespace: installNamespace,
+		RetainDRData:     o.RetainDRData,
+		Logger:           logger,
+	}
+	if err := cleanup.Run(ctx); err != nil {
+		logger.Error("Failed to clean up the addon agent", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Addon agent cleanup completed", "RetainDRData", o.RetainDRData)
+}
+
+// AgentCleanup reverts the changes of the agent on the managed cluster
+type AgentCleanup struct {
+	SpokeClient client.Client
+	// AgentClient and AgentNamespace locate the agent Deployment, which is on the hosting cluster in hosted mode
+	AgentClient      client.Client
+	AgentNamespace   string
+	CurrentNamespace string
+	// RetainDRData keeps the resources needed to resume disaster recovery once the addon is installed again
+	RetainDRData bool
+	Logger       *slog.Logger
+}
+
+// Run stops the agent and reverts its changes. The cleanup assumes that no MirrorPeer includes the cluster anymore:
+// unless DR data is retained, it deletes the buckets and storage IDs the MirrorPeers rely on, and it does not remove
+// the finalizers of the agent from the MirrorPeers.
+func (c *AgentCleanup) Run(ctx context.Context) error {
+	if err := c.fenceAgent(ctx); err != nil {
+		return err
+	}
+	if !c.RetainDRData {
+		if err := c.removeStorageIDLabels(ctx); err != nil {
+			return err
+		}
+		if err := c.deleteObjectBucketClaims(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.releaseVolumeReplicationClasses(ctx); err != nil {
+		return err
+	}
+	return c.deleteAddonDeletionLock(ctx)
+}
+
+// fenceAgent stops the agent, which would otherwise restore the changes being reverted. The Deployment is scaled down
+// and the fence ConfigMap keeps idle the agents started again, for example when the ManifestWork of the addon
+// restores the replicas of the Deployment. The fence is owned by the Deployment and removed with it.
+func (c *AgentCleanup) fenceAgent(ctx context.Context) error {
+	var deployment appsv1.Deployment
+	err := c.AgentClient.Get(ctx, types.NamespacedName{Namespace: c.AgentNamespace, Name: AgentDeploymentName}, &deployment)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Deployment %q: %w", AgentDeploymentName, err)
+	}
+
+	fence := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: AgentFenceName, Namespace: c.AgentNamespace}}
+	if err := controllerutil.SetOwnerReference(&deployment, &fence, c.AgentClient.Scheme()); err != nil {
+		return fmt.Errorf("failed to set owner of ConfigMap %q: %w", AgentFenceName, err)
+	}
+	if err := c.AgentClient.Create(ctx, &fence); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ConfigMap %q: %w", AgentFenceName, err)
+	}
+
+	if ptr.Deref(deployment.Spec.Replicas, 1) != 0 {
+		patch := client.MergeFrom(deployment.DeepCopy())
+		deployment.Spec.Replicas = ptr.To[int32](0)
+		if err := c.AgentClient.Patch(ctx, &deployment, patch); err != nil {
+			return fmt.Errorf("failed to scale down Deployment %q: %w", AgentDeploymentName, err)
+		}
+		c.Logger.Info("Scaled down the agent", "Deployment", AgentDeploymentName)
+	}
+
+	// Terminating pods still count, the agent may be completing a reconcile
+	err = wait.PollUntilContextTimeout(ctx, agentStopPollInterval, agentStopTimeout, true, func(ctx context.Context) (bool, error) {
+		var podList corev1.PodList
+		if err := c.AgentClient.List(ctx, &podList, client.InNamespace(c.AgentNamespace),
+			client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+			return false, err
+		}
+		return len(podList.Items) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for the pods of Deployment %q to stop: %w", AgentDeploymentName, err)
+	}
+	c.Logger.Info("Agent is fenced", "Deployment", AgentDeploymentName, "ConfigMap", AgentFenceName)
+	return nil
+}
+
+// isAgentFenced returns true when the cleanup Job fenced the agents of the namespace
+func isAgentFenced(ctx context.Context, c client.Client, namespace string) (bool, error) {
+	var fence corev1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: AgentFenceName}, &fence)
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get ConfigMap %q: %w", AgentFenceName, err)
+	}
+	return true, nil
+}
+
+// removeStorageIDLabels removes the Ramen storage IDs from the default StorageClasses and VolumeSnapshotClasses of the
+// StorageClusters
+func (c *AgentCleanup) removeStorageIDLabels(ctx context.Context) error {
+	storageIDLabel := fmt.Sprintf(RamenLabelTemplate, StorageIDKey)
+
+	var storageClusterList ocsv1.StorageClusterList
+	if err := c.SpokeClient.List(ctx, &storageClusterList); err != nil {
+		return fmt.Errorf("failed to list StorageClusters: %w", err)
+	}
+	for _, storageCluster := range storageClusterList.Items {
+		storageClasses, err := utils.GetDefaultStorageClasses(ctx, c.SpokeClient, storageCluster.Name)
+		if err != nil {
+			return err
+		}
+		for i := range storageClasses {
+			if err := c.removeLabel(ctx, &storageClasses[i], storageIDLabel); err != nil {
+				return err
+			}
+		}
+
+		volumeSnapshotClasses, err := utils.GetDefaultVolumeSnapshotClasses(ctx, c.SpokeClient, storageCluster.Name)
+		if err != nil {
+			return err
+		}
+		for _, vsc := range volumeSnapshotClasses {
+			if err := c.removeLabel(ctx, vsc, storageIDLabel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *AgentCleanup) removeLabel(ctx context.Context, obj client.Object, label string) error {
+	labels := obj.GetLabels()
+	if _, ok := labels[label]; !ok {
+		return nil
+	}
+	delete(labels, label)
+	obj.SetLabels(labels)
+	if err := c.SpokeClient.Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to remove label %q from %q: %w", label, obj.GetName(), err)
+	}
+	c.Logger.Info("Removed label", "Label", label, "Name", obj.GetName())
+	return nil
+}
+
+// deleteObjectBucketClaims deletes the ODR buckets created by the agent for the MirrorPeers
+func (c *AgentCleanup) deleteObjectBucketClaims(ctx context.Context) error {
+	var obcList obv1alpha1.ObjectBucketClaimList
+	if err := c.SpokeClient.List(ctx, &obcList); err != nil {
+		return fmt.Errorf("failed to list ObjectBucketClaims: %w", err)
+	}
+	for i := range obcList.Items {
+		obc := &obcList.Items[i]
+		if !strings.HasPrefix(obc.Name, utils.BucketGenerateName) {
+			continue
+		}
+		if _, ok := obc.Annotations[utils.MirrorPeerNameAnnotationKey]; !ok {
+			continue
+		}
+		if err := c.SpokeClient.Delete(ctx, obc); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ObjectBucketClaim %s/%s: %w", obc.Namespace, obc.Name, err)
+		}
+		c.Logger.Info("Deleted ObjectBucketClaim", "ObjectBucketClaim", obc.Name, "Namespace", obc.Namespace)
+	}
+	return nil
+}
+
+// releaseVolumeReplicationClasses removes the VolumeReplicationClasses distributed to the StorageConsumers, unless DR
+// data is retained, and the finalizers of the agent from their Templates
+func (c *AgentCleanup) releaseVolumeReplicationClasses(ctx context.Context) error {
+	var templateList templatev1.TemplateList
+	if err := c.SpokeClient.List(ctx, &templateList, client.InNamespace(c.CurrentNamespace), client.MatchingLabels{
+		utils.CreatedByLabelKey: utils.CreatorMulticlusterOrchestrator,
+	}); err != nil {
+		return fmt.Errorf("failed to list Templates: %w", err)
+	}
+
+	if !c.RetainDRData && len(templateList.Items) > 0 {
+		var storageConsumerList ocsv1alpha1.StorageConsumerList
+		if err := c.SpokeClient.List(ctx, &storageConsumerList, client.InNamespace(c.CurrentNamespace)); err != nil {
+			return fmt.Errorf("failed to list StorageConsumers: %w", err)
+		}
+		for i := range storageConsumerList.Items {
+			consumer := &storageConsumerList.Items[i]
+			vrcs := slices.DeleteFunc(slices.Clone(consumer.Spec.VolumeReplicationClasses), func(vrc ocsv1alpha1.VolumeReplicationClassSpec) bool {
+				return slices.ContainsFunc(templateList.Items, func(template templatev1.Template) bool { return template.Name == vrc.Name })
+			})
+			if reflect.DeepEqual(vrcs, consumer.Spec.VolumeReplicationClasses) {
+				continue
+			}
+			consumer.Spec.VolumeReplicationClasses = vrcs
+			if err := c.SpokeClient.Update(ctx, consumer); err != nil {
+				return fmt.Errorf("failed to remove VolumeReplicationClasses from StorageConsumer %q: %w", consumer.Name, err)
+			}
+			c.Logger.Info("Removed VolumeReplicationClasses from StorageConsumer", "StorageConsumer", consumer.Name)
+		}
+	}
+
+	for i := range templateList.Items {
+		if err := removeFinalizerFromObject(ctx, c.SpokeClient, &templateList.Items[i], ResourceDistributionFinalizer); err != nil {
+			return fmt.Errorf("failed to remove finalizer from Template %q: %w", templateList.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *AgentCleanup) deleteAddonDeletionLock(ctx context.Context) error {
+	var addonDeletionLock corev1.ConfigMap
+	err := c.SpokeClient.Get(ctx, types.NamespacedName{Namespace: c.CurrentNamespace, Name: AddonDeletionlockName}, &addonDeletionLock)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %q: %w", AddonDeletionlockName, err)
+	}
+	if err := removeFinalizerFromObject(ctx, c.SpokeClient, &addonDeletionLock, ResourceDistributionFinalizer); err != nil {
+		return fmt.Errorf("failed to remove finalizer from ConfigMap %q: %w", AddonDeletionlockName, err)
+	}
+	if err := c.SpokeClient.Delete(ctx, &addonDeletionLock); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ConfigMap %q: %w", AddonDeletionlockName, err)
+	}
+	c.Logger.Info("Deleted addon deletion lock", "ConfigMap", AddonDeletionlockName)
+	return nil
+}
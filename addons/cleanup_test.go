@@ -0,0 +1,164 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	obv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAgentCleanup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		appsv1.AddToScheme, corev1.AddToScheme, storagev1.AddToScheme, snapshotv1.AddToScheme, obv1alpha1.AddToScheme,
+		templatev1.AddToScheme, ocsv1.AddToScheme, ocsv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storageIDLabel := fmt.Sprintf(RamenLabelTemplate, StorageIDKey)
+	newObjects := func() []client.Object {
+		return []client.Object{
+			&ocsv1.StorageCluster{ObjectMeta: metav1.ObjectMeta{Name: "ocs-storagecluster", Namespace: odfNamespace}},
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf(utils.DefaultCephRBDStorageClassTemplate, "ocs-storagecluster"),
+				Labels: map[string]string{storageIDLabel: "rbd-id"},
+			}},
+			&snapshotv1.VolumeSnapshotClass{ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf(utils.DefaultRBDVSCNameTemplate, "ocs-storagecluster"),
+				Labels: map[string]string{storageIDLabel: "rbd-id"},
+			}},
+			&obv1alpha1.ObjectBucketClaim{ObjectMeta: metav1.ObjectMeta{
+				Name:        utils.BucketGenerateName + "-0123456789ab",
+				Namespace:   odfNamespace,
+				Annotations: map[string]string{utils.MirrorPeerNameAnnotationKey: "mirrorpeer"},
+			}},
+			&templatev1.Template{ObjectMeta: metav1.ObjectMeta{
+				Name:       "vrc-1",
+				Namespace:  odfNamespace,
+				Labels:     map[string]string{utils.CreatedByLabelKey: utils.CreatorMulticlusterOrchestrator},
+				Finalizers: []string{ResourceDistributionFinalizer},
+			}},
+			&ocsv1alpha1.StorageConsumer{
+				ObjectMeta: metav1.ObjectMeta{Name: "consumer", Namespace: odfNamespace},
+				Spec: ocsv1alpha1.StorageConsumerSpec{VolumeReplicationClasses: []ocsv1alpha1.VolumeReplicationClassSpec{
+					{Name: "vrc-1"}, {Name: "other"},
+				}},
+			},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:       AddonDeletionlockName,
+				Namespace:  odfNamespace,
+				Finalizers: []string{ResourceDistributionFinalizer},
+			}},
+		}
+	}
+
+	ctx := context.TODO()
+	for _, retainDRData := range []bool{false, true} {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newObjects()...).Build()
+		// The agent runs on another cluster, as in hosted mode
+		agentClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: AgentDeploymentName, Namespace: "agent-namespace"},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: ptr.To[int32](2),
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "token-exchange-agent"}},
+				},
+			},
+		).Build()
+		cleanup := AgentCleanup{
+			SpokeClient:      fakeClient,
+			AgentClient:      agentClient,
+			AgentNamespace:   "agent-namespace",
+			CurrentNamespace: odfNamespace,
+			RetainDRData:     retainDRData,
+			Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+		}
+		if err := cleanup.Run(ctx); err != nil {
+			t.Fatalf("AgentCleanup Run() failed. Error: %s", err)
+		}
+		// A second run after a Job retry is a no-op
+		if err := cleanup.Run(ctx); err != nil {
+			t.Fatalf("AgentCleanup Run() failed. Error: %s", err)
+		}
+
+		var deployment appsv1.Deployment
+		if err := agentClient.Get(ctx, types.NamespacedName{Name: AgentDeploymentName, Namespace: "agent-namespace"}, &deployment); err != nil {
+			t.Fatal(err)
+		}
+		if *deployment.Spec.Replicas != 0 {
+			t.Errorf("retainDRData=%t: expected the agent to be scaled down, got %d replicas", retainDRData, *deployment.Spec.Replicas)
+		}
+		fenced, err := isAgentFenced(ctx, agentClient, "agent-namespace")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fenced {
+			t.Errorf("retainDRData=%t: expected the agent to be fenced", retainDRData)
+		}
+		var fence corev1.ConfigMap
+		if err := agentClient.Get(ctx, types.NamespacedName{Name: AgentFenceName, Namespace: "agent-namespace"}, &fence); err != nil {
+			t.Fatal(err)
+		}
+		if len(fence.OwnerReferences) != 1 || fence.OwnerReferences[0].Name != AgentDeploymentName {
+			t.Errorf("retainDRData=%t: expected the fence to be owned by the agent Deployment, got %v", retainDRData, fence.OwnerReferences)
+		}
+
+		var sc storagev1.StorageClass
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf(utils.DefaultCephRBDStorageClassTemplate, "ocs-storagecluster")}, &sc); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sc.Labels[storageIDLabel]; ok == !retainDRData {
+			t.Errorf("retainDRData=%t: unexpected storage ID labels %v", retainDRData, sc.Labels)
+		}
+
+		var obc obv1alpha1.ObjectBucketClaim
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: utils.BucketGenerateName + "-0123456789ab", Namespace: odfNamespace}, &obc)
+		if k8serrors.IsNotFound(err) == retainDRData {
+			t.Errorf("retainDRData=%t: unexpected ObjectBucketClaim lookup error %v", retainDRData, err)
+		}
+
+		var consumer ocsv1alpha1.StorageConsumer
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: "consumer", Namespace: odfNamespace}, &consumer); err != nil {
+			t.Fatal(err)
+		}
+		expectedVRCs := 1
+		if retainDRData {
+			expectedVRCs = 2
+		}
+		if len(consumer.Spec.VolumeReplicationClasses) != expectedVRCs {
+			t.Errorf("retainDRData=%t: unexpected VolumeReplicationClasses %v", retainDRData, consumer.Spec.VolumeReplicationClasses)
+		}
+
+		var template templatev1.Template
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: "vrc-1", Namespace: odfNamespace}, &template); err != nil {
+			t.Fatal(err)
+		}
+		if len(template.Finalizers) != 0 {
+			t.Errorf("retainDRData=%t: expected the finalizer to be removed from the Template", retainDRData)
+		}
+
+		var lock corev1.ConfigMap
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: AddonDeletionlockName, Namespace: odfNamespace}, &lock); !k8serrors.IsNotFound(err) {
+			t.Errorf("retainDRData=%t: expected the addon deletion lock to be deleted, got %v", retainDRData, err)
+		}
+	}
+}
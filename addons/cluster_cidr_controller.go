@@ -0,0 +1,273 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// maxClusterClaimValueLength is the maximum length of a ClusterClaim value
+	maxClusterClaimValueLength = 1024
+
+	// ClusterCIDRsPublishedCondition reports on the ManagedClusterAddOn whether the CIDRs of the managed cluster are
+	// published as a ClusterClaim
+	ClusterCIDRsPublishedCondition = "ClusterCIDRsPublished"
+
+	// Reasons of the ClusterCIDRsPublished condition of the ManagedClusterAddOn
+	ReasonClusterCIDRsPublished = "CIDRsPublished"
+	ReasonClusterCIDRsNotFound  = "CIDRsNotFound"
+	ReasonClusterCIDRsTooLong   = "CIDRsTooLong"
+)
+
+var (
+	egressIPGVK     = schema.GroupVersionKind{Group: "k8s.ovn.org", Version: "v1", Kind: "EgressIP"}
+	egressIPListGVK = schema.GroupVersionKind{Group: "k8s.ovn.org", Version: "v1", Kind: "EgressIPList"}
+)
+
+// ClusterCIDRReconciler publishes the node and egress CIDRs of the spoke cluster as a ClusterClaim. The claim is
+// synced to the ManagedCluster status on the hub, where it is used to fill in DRCluster.Spec.CIDRs for fencing.
+// The outcome is tracked as the ClusterCIDRsPublished condition which the AgentHealthReporter reports on the
+// ManagedClusterAddOn.
+type ClusterCIDRReconciler struct {
+	Scheme      *runtime.Scheme
+	SpokeClient client.Client
+	Logger      *slog.Logger
+
+	mu        sync.RWMutex
+	condition *metav1.Condition
+}
+
+// Condition returns the ClusterCIDRsPublished condition, it is not set until the CIDRs are reconciled
+func (r *ClusterCIDRReconciler) Condition() (metav1.Condition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.condition == nil {
+		return metav1.Condition{}, false
+	}
+	return *r.condition, true
+}
+
+func (r *ClusterCIDRReconciler) setCondition(status metav1.ConditionStatus, reason, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.condition = &metav1.Condition{
+		Type:    ClusterCIDRsPublishedCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterCIDRReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Logger.Info("Setting up controller with manager")
+
+	nodeAddressesPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return !reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses)
+		},
+	}
+
+	eventHandler := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []ctrl.Request {
+		return []ctrl.Request{reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: utils.CIDRsClusterClaimName,
+			},
+		}}
+	})
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		Named("cluster_cidr_controller").
+		For(&clusterv1alpha1.ClusterClaim{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(object client.Object) bool {
+				return object.GetName() == utils.CIDRsClusterClaimName
+			}),
+		)).
+		Watches(&corev1.Node{}, eventHandler, builder.WithPredicates(nodeAddressesPredicate))
+
+	// Clusters without the EgressIP API of OVN-Kubernetes have no egress IPs to watch
+	if _, err := mgr.GetRESTMapper().RESTMapping(egressIPGVK.GroupKind(), egressIPGVK.Version); err == nil {
+		egressIP := &unstructured.Unstructured{}
+		egressIP.SetGroupVersionKind(egressIPGVK)
+		controllerBuilder = controllerBuilder.Watches(egressIP, eventHandler, builder.WithPredicates(egressIPStatusPredicate()))
+	} else if meta.IsNoMatchError(err) {
+		r.Logger.Info("EgressIP API not found. Egress IPs are not watched")
+	} else {
+		return fmt.Errorf("failed to look up the EgressIP API: %w", err)
+	}
+
+	return controllerBuilder.Complete(r)
+}
+
+// egressIPStatusPredicate passes EgressIP updates which change the egress IPs assigned to the nodes
+func egressIPStatusPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldEgressIP, ok := e.ObjectOld.(*unstructured.Unstructured)
+			if !ok {
+				return false
+			}
+			newEgressIP, ok := e.ObjectNew.(*unstructured.Unstructured)
+			if !ok {
+				return false
+			}
+			oldItems, _, _ := unstructured.NestedSlice(oldEgressIP.Object, "status", "items")
+			newItems, _, _ := unstructured.NestedSlice(newEgressIP.Object, "status", "items")
+			return !reflect.DeepEqual(oldItems, newItems)
+		},
+	}
+}
+
+func (r *ClusterCIDRReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With("Request", req.NamespacedName.String())
+	logger.Info("Publishing cluster CIDRs")
+
+	cidrs, err := r.getClusterCIDRs(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to collect cluster CIDRs: %w", err)
+	}
+	if len(cidrs) == 0 {
+		logger.Info("No cluster CIDRs found. Skipping publishing of CIDRs")
+		r.setCondition(metav1.ConditionFalse, ReasonClusterCIDRsNotFound, "No node or egress IP found on the managed cluster")
+		return ctrl.Result{}, nil
+	}
+
+	value := strings.Join(cidrs, ",")
+	if len(value) > maxClusterClaimValueLength {
+		logger.Error("Cluster CIDRs exceed the maximum ClusterClaim value length. Set the CIDRs on the ManagedCluster instead",
+			"Annotation", utils.DRClusterCIDRsAnnotationKey, "CIDRCount", len(cidrs))
+		r.setCondition(metav1.ConditionFalse, ReasonClusterCIDRsTooLong,
+			fmt.Sprintf("The %d CIDRs of the managed cluster exceed the %d characters of a ClusterClaim. Set them with the %s annotation of the ManagedCluster instead",
+				len(cidrs), maxClusterClaimValueLength, utils.DRClusterCIDRsAnnotationKey))
+		return ctrl.Result{}, nil
+	}
+
+	claim := clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: utils.CIDRsClusterClaimName,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.SpokeClient, &claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+		claim.Labels[utils.CreatedByLabelKey] = utils.CreatorMulticlusterOrchestrator
+		claim.Spec.Value = value
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create or update ClusterClaim %q: %w", claim.Name, err)
+	}
+
+	logger.Info("Successfully published cluster CIDRs", "CIDRs", cidrs)
+	r.setCondition(metav1.ConditionTrue, ReasonClusterCIDRsPublished,
+		fmt.Sprintf("%d CIDRs are published as the %s ClusterClaim", len(cidrs), utils.CIDRsClusterClaimName))
+	return ctrl.Result{}, nil
+}
+
+// getClusterCIDRs returns the sorted host CIDRs of the node addresses and of the OVN-Kubernetes egress IPs
+func (r *ClusterCIDRReconciler) getClusterCIDRs(ctx context.Context) ([]string, error) {
+	var cidrs []string
+
+	var nodeList corev1.NodeList
+	if err := r.SpokeClient.List(ctx, &nodeList); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodeList.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type != corev1.NodeInternalIP && address.Type != corev1.NodeExternalIP {
+				continue
+			}
+			if cidr, ok := hostCIDR(address.Address); ok {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+
+	egressIPs, err := r.getEgressIPs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range egressIPs {
+		if cidr, ok := hostCIDR(ip); ok {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	slices.Sort(cidrs)
+	return slices.Compact(cidrs), nil
+}
+
+// getEgressIPs returns the egress IPs assigned by OVN-Kubernetes. Clusters without the EgressIP API have no egress IPs.
+func (r *ClusterCIDRReconciler) getEgressIPs(ctx context.Context) ([]string, error) {
+	var egressIPList unstructured.UnstructuredList
+	egressIPList.SetGroupVersionKind(egressIPListGVK)
+	if err := r.SpokeClient.List(ctx, &egressIPList); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list EgressIPs: %w", err)
+	}
+
+	var ips []string
+	for _, egressIP := range egressIPList.Items {
+		items, _, err := unstructured.NestedSlice(egressIP.Object, "status", "items")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read status of EgressIP %q: %w", egressIP.GetName(), err)
+		}
+		for _, item := range items {
+			status, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ip, ok := status["egressIP"].(string); ok {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips, nil
+}
+
+// hostCIDR returns the single host CIDR of an IP address
+func hostCIDR(address string) (string, bool) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return fmt.Sprintf("%s/32", ip.String()), true
+	}
+	return fmt.Sprintf("%s/128", ip.String()), true
+}
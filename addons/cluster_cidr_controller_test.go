@@ -0,0 +1,117 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestClusterCIDRReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	node := func(name string, addresses ...corev1.NodeAddress) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Addresses: addresses},
+		}
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		node("node-1",
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+			corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node-1"},
+		),
+		node("node-2",
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+		),
+		node("node-3",
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+		),
+	).Build()
+
+	r := ClusterCIDRReconciler{
+		Scheme:      scheme,
+		SpokeClient: fakeClient,
+		Logger:      utils.GetLogger(utils.GetZapLogger(true)),
+	}
+
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.CIDRsClusterClaimName}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("ClusterCIDRReconciler Reconcile() failed. Error: %s", err)
+	}
+
+	var claim clusterv1alpha1.ClusterClaim
+	if err := fakeClient.Get(ctx, req.NamespacedName, &claim); err != nil {
+		t.Fatalf("failed to get ClusterClaim. Error: %s", err)
+	}
+	want := "10.0.0.1/32,10.0.0.2/32,fd00::1/128"
+	if claim.Spec.Value != want {
+		t.Errorf("expected ClusterClaim value %q, got %q", want, claim.Spec.Value)
+	}
+	if condition, ok := r.Condition(); !ok || condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the ClusterCIDRsPublished condition to be true, got %v", condition)
+	}
+
+	// CIDRs which do not fit in a ClusterClaim are reported on the condition and the claim is left unchanged
+	for i := range 100 {
+		if err := fakeClient.Create(ctx, node(fmt.Sprintf("node-%d", i+4),
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: fmt.Sprintf("10.0.1.%d", i)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("ClusterCIDRReconciler Reconcile() failed. Error: %s", err)
+	}
+	condition, ok := r.Condition()
+	if !ok || condition.Status != metav1.ConditionFalse || condition.Reason != ReasonClusterCIDRsTooLong {
+		t.Errorf("expected the ClusterCIDRsPublished condition to report the CIDRs as too long, got %v", condition)
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, &claim); err != nil {
+		t.Fatalf("failed to get ClusterClaim. Error: %s", err)
+	}
+	if claim.Spec.Value != want {
+		t.Errorf("expected ClusterClaim value %q, got %q", want, claim.Spec.Value)
+	}
+}
+
+func TestEgressIPStatusPredicate(t *testing.T) {
+	egressIP := func(ips ...string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(egressIPGVK)
+		u.SetName("egressip")
+		var items []interface{}
+		for _, ip := range ips {
+			items = append(items, map[string]interface{}{"node": "node-1", "egressIP": ip})
+		}
+		if err := unstructured.SetNestedSlice(u.Object, items, "status", "items"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	p := egressIPStatusPredicate()
+	if p.Update(event.UpdateEvent{ObjectOld: egressIP("10.0.0.1"), ObjectNew: egressIP("10.0.0.1")}) {
+		t.Error("expected unchanged egress IPs to be filtered out")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: egressIP("10.0.0.1"), ObjectNew: egressIP("10.0.0.1", "10.0.0.2")}) {
+		t.Error("expected changed egress IPs to pass")
+	}
+}
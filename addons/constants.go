@@ -1,5 +1,7 @@
 package addons
 
+import "github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+
 type OBCTypeValue string
 
 const (
@@ -11,11 +13,13 @@ const (
 	RamenLabelTemplate            = "ramendr.openshift.io/%s"
 	StorageIDKey                  = "storageid"
 	CephFSProvisionerTemplate     = "%s.cephfs.csi.ceph.com"
-	SpokeMirrorPeerFinalizer      = "spoke.multicluster.odf.openshift.io"
+	SpokeMirrorPeerFinalizer      = setup.MirrorPeerFinalizerSuffix
 	ResourceDistributionFinalizer = "multicluster.odf.openshift.io/resource-distribution-controller"
 	OBCTypeAnnotationKey          = "multicluster.odf.openshift.io/obc-type"
 	OBCNameAnnotationKey          = "multicluster.odf.openshift.io/obc-name"
 	AddonDeletionlockName         = "token-exchange-addon-lock"
+	AgentDeploymentName           = "token-exchange-agent"
+	AgentFenceName                = "token-exchange-agent-fence"
 )
 
 var (
@@ -2,7 +2,9 @@ package addons
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 
 	replicationv1alpha1 "github.com/csi-addons/kubernetes-csi-addons/apis/replication.storage/v1alpha1"
@@ -33,6 +35,7 @@ import (
 	addonutils "open-cluster-management.io/addon-framework/pkg/utils"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -48,6 +51,7 @@ func init() {
 	utilruntime.Must(appsv1.AddToScheme(mgrScheme))
 	utilruntime.Must(clientgoscheme.AddToScheme(mgrScheme))
 	utilruntime.Must(clusterv1.AddToScheme(mgrScheme))
+	utilruntime.Must(clusterv1alpha1.AddToScheme(mgrScheme))
 	utilruntime.Must(multiclusterv1alpha1.AddToScheme(mgrScheme))
 	utilruntime.Must(addonapiv1alpha1.AddToScheme(mgrScheme))
 	utilruntime.Must(replicationv1alpha1.AddToScheme(mgrScheme))
@@ -75,6 +79,7 @@ func NewAddonAgentCommand() *cobra.Command {
 	}
 
 	o.AddFlags(cmd)
+	cmd.AddCommand(NewAddonCleanupCommand())
 
 	return cmd
 }
@@ -82,21 +87,31 @@ func NewAddonAgentCommand() *cobra.Command {
 // AddonAgentOptions defines the flags for agent
 type AddonAgentOptions struct {
 	MetricsAddr          string
+	MetricsCertDir       string
 	EnableLeaderElection bool
 	ProbeAddr            string
 	HubKubeconfigFile    string
 	KubeconfigFile       string
 	SpokeClusterName     string
 	OdfOperatorNamespace string
-	DRMode               string
-	DevMode              bool
+	// DRModes are the types of the MirrorPeers sharing the addon of the cluster. The agent handles each MirrorPeer
+	// according to its own type.
+	DRModes []string
+	DevMode bool
+	// UXBackendProxyAddress overrides the address of the ux-backend-proxy, which is required when the agent runs
+	// outside of the managed cluster
+	UXBackendProxyAddress string
+	// InstallNamespace is the namespace of the addon on the managed cluster. It differs from the namespace of the
+	// agent pod in hosted mode, where the agent runs on the hosting cluster.
+	InstallNamespace string
 
 	testEnvFile string
 }
 
 func (o *AddonAgentOptions) AddFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
-	flags.StringVar(&o.MetricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flags.StringVar(&o.MetricsAddr, "metrics-bind-address", ":8443", "The address the HTTPS metrics endpoint binds to.")
+	flags.StringVar(&o.MetricsCertDir, "metrics-cert-dir", "", "The directory of the tls.crt and tls.key of the HTTPS metrics endpoint. A self-signed certificate is used when empty.")
 	flags.StringVar(&o.ProbeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flags.BoolVar(&o.EnableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
@@ -105,11 +120,33 @@ func (o *AddonAgentOptions) AddFlags(cmd *cobra.Command) {
 	flags.StringVar(&o.KubeconfigFile, "kubeconfig", "", "Paths to a kubeconfig. Only required if out-of-cluster.")
 	flags.StringVar(&o.SpokeClusterName, "cluster-name", o.SpokeClusterName, "Name of spoke cluster.")
 	flags.StringVar(&o.OdfOperatorNamespace, "odf-operator-namespace", o.OdfOperatorNamespace, "Namespace of ODF operator on the spoke cluster.")
-	flags.StringVar(&o.DRMode, "mode", o.DRMode, "The DR mode of token exchange addon. Valid values are: 'sync', 'async'")
+	flags.StringSliceVar(&o.DRModes, "mode", o.DRModes, "Comma separated DR modes of the MirrorPeers of the cluster. Valid values are: 'sync', 'async'")
 	flags.BoolVar(&o.DevMode, "dev", false, "Set to true for dev environment (Text logging)")
+	flags.StringVar(&o.UXBackendProxyAddress, "ux-backend-proxy-address", "", "The host:port of the ux-backend-proxy of the managed cluster. Defaults to its in-cluster service.")
+	flags.StringVar(&o.InstallNamespace, "install-namespace", "", "Namespace of the addon on the managed cluster. Defaults to the namespace of the agent pod.")
 	flags.StringVar(&o.testEnvFile, "test-dotenv", "", "Path to a dotenv file for testing purpose only.")
 }
 
+// isHosted returns true when the agent runs on a hosting cluster with an external kubeconfig of the managed cluster
+func (o *AddonAgentOptions) isHosted() bool {
+	return o.KubeconfigFile != ""
+}
+
+// getInstallNamespace returns the namespace of the addon on the managed cluster
+func (o *AddonAgentOptions) getInstallNamespace() string {
+	if o.InstallNamespace != "" {
+		return o.InstallNamespace
+	}
+	return utils.GetEnv("POD_NAMESPACE", o.testEnvFile)
+}
+
+func (o *AddonAgentOptions) getUXBackendProxyAddress() string {
+	if o.UXBackendProxyAddress != "" {
+		return o.UXBackendProxyAddress
+	}
+	return getUXBackendProxyAddress(o.OdfOperatorNamespace)
+}
+
 // RunAgent starts the controllers on agent to process work from hub.
 func (o *AddonAgentOptions) RunAgent(ctx context.Context) {
 	zapLogger := utils.GetZapLogger(o.DevMode)
@@ -120,35 +157,46 @@ func (o *AddonAgentOptions) RunAgent(ctx context.Context) {
 	}()
 	ctrl.SetLogger(zapr.NewLogger(zapLogger))
 	logger := utils.GetLogger(zapLogger)
-	logger.Info("Starting addon agents.", "Version", version.Version)
+	logger.Info("Starting addon agents.", "Version", version.Version, "DRModes", o.DRModes)
+	for _, mode := range o.DRModes {
+		if mode != string(multiclusterv1alpha1.Sync) && mode != string(multiclusterv1alpha1.Async) {
+			logger.Error("Invalid DR mode", "Mode", mode)
+			os.Exit(1)
+		}
+	}
 	cc, err := addonutils.NewConfigChecker("agent kubeconfig checker", o.HubKubeconfigFile)
 	if err != nil {
 		logger.Error("ConfigChecker could not be created", "error", err)
 		os.Exit(1)
 	}
 
+	health := NewAgentHealth(spokeManagerName, hubManagerName)
+
 	logger.Info("Serving health probes on port 8000")
-	go setup.ServeHealthProbes(ctx.Done(), ":8000", cc.Check, logger)
+	go setup.ServeHealthProbes(ctx.Done(), ":8000", cc.Check, func(_ *http.Request) error { return health.Check() }, logger)
 
-	logger.Info("Starting spoke manager")
-	go runSpokeManager(ctx, *o, logger)
+	providerClients := NewProviderClients()
 
-	logger.Info("Starting hub manager")
-	go runHubManager(ctx, *o, logger)
+	// The hub manager is started by the spoke manager once elected, so that the replica leading the spoke manager
+	// also runs the hub manager sharing its provider clients
+	logger.Info("Starting spoke manager", "LeaderElection", o.EnableLeaderElection)
+	go superviseManager(ctx, spokeManagerName, health, managerRestartBackoff, func(ctx context.Context) error {
+		return runSpokeManager(ctx, *o, providerClients, health, logger)
+	}, logger)
 
 	logger.Info("Addon agent is running, waiting for context cancellation")
 	<-ctx.Done()
 	logger.Info("Addon agent has stopped")
 }
 
-func runHubManager(ctx context.Context, options AddonAgentOptions, logger *slog.Logger) {
-	currentNamespace := utils.GetEnv("POD_NAMESPACE", options.testEnvFile)
+func runHubManager(ctx context.Context, options AddonAgentOptions, providerClients *ProviderClients, health *AgentHealth, logger *slog.Logger) error {
+	currentNamespace := options.getInstallNamespace()
 
 	hubConfig, err := utils.GetClientConfig(options.HubKubeconfigFile)
 	if err != nil {
-		logger.Error("Failed to get kubeconfig", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
+	instrumentHubConfig(hubConfig)
 
 	mgr, err := ctrl.NewManager(hubConfig, ctrl.Options{
 		Scheme: mgrScheme,
@@ -157,6 +205,7 @@ func runHubManager(ctx context.Context, options AddonAgentOptions, logger *slog.
 		},
 		HealthProbeBindAddress: "0", // disable health probe
 		ReadinessEndpointName:  "0", // disable readiness probe
+		Controller:             supervisedControllerOptions,
 		Cache: cache.Options{
 			DefaultNamespaces: map[string]cache.Config{
 				options.SpokeClusterName: {},
@@ -164,97 +213,167 @@ func runHubManager(ctx context.Context, options AddonAgentOptions, logger *slog.
 		},
 	})
 	if err != nil {
-		logger.Error("Failed to start manager", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to start manager: %w", err)
+	}
+
+	if err := utils.AddMirrorPeerIndexers(ctx, mgr.GetFieldIndexer()); err != nil {
+		return fmt.Errorf("failed to register field indexers: %w", err)
+	}
+	if err := utils.AddManagedClusterIndexers(ctx, mgr.GetFieldIndexer()); err != nil {
+		return fmt.Errorf("failed to register field indexers: %w", err)
 	}
 
 	spokeKubeConfig, err := utils.GetClientConfig(options.KubeconfigFile)
 	if err != nil {
-		logger.Error("Failed to get kubeconfig", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
 
 	spokeClient, err := utils.GetClientFromConfig(spokeKubeConfig, mgr.GetScheme())
 	if err != nil {
-		logger.Error("Failed to get spoke client", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get spoke client: %w", err)
+	}
+
+	serviceAccountToken := inClusterServiceAccountToken
+	if options.isHosted() {
+		spokeKubeClient, err := kubernetes.NewForConfig(spokeKubeConfig)
+		if err != nil {
+			return fmt.Errorf("failed to get spoke kube client: %w", err)
+		}
+		serviceAccountToken = requestServiceAccountToken(spokeKubeClient, currentNamespace)
 	}
 
 	if err = (&MirrorPeerReconciler{
-		Scheme:               mgr.GetScheme(),
-		HubClient:            mgr.GetClient(),
-		SpokeClient:          spokeClient,
-		SpokeClusterName:     options.SpokeClusterName,
-		OdfOperatorNamespace: options.OdfOperatorNamespace,
-		Logger:               logger.With("controller", "MirrorPeerReconciler"),
-		testEnvFile:          options.testEnvFile,
-		CurrentNamespace:     currentNamespace,
+		Scheme:                mgr.GetScheme(),
+		HubClient:             mgr.GetClient(),
+		SpokeClient:           spokeClient,
+		SpokeClusterName:      options.SpokeClusterName,
+		OdfOperatorNamespace:  options.OdfOperatorNamespace,
+		ProviderClients:       providerClients,
+		Logger:                logger.With("controller", "MirrorPeerReconciler"),
+		testEnvFile:           options.testEnvFile,
+		CurrentNamespace:      currentNamespace,
+		UXBackendProxyAddress: options.getUXBackendProxyAddress(),
+		ServiceAccountToken:   serviceAccountToken,
 	}).SetupWithManager(mgr); err != nil {
-		logger.Error("Failed to create MirrorPeer controller", "controller", "MirrorPeer", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create MirrorPeer controller: %w", err)
+	}
+
+	if err := mgr.Add(health.ManagerRunnable(hubManagerName, mgr)); err != nil {
+		return fmt.Errorf("failed to add health tracking to hub manager: %w", err)
 	}
 
 	logger.Info("Starting hub controller manager")
 	if err := mgr.Start(ctx); err != nil {
-		logger.Error("Problem running hub controller manager", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("problem running hub controller manager: %w", err)
 	}
+	return nil
 }
 
-func runSpokeManager(ctx context.Context, options AddonAgentOptions, logger *slog.Logger) {
-	currentNamespace := utils.GetEnv("POD_NAMESPACE", options.testEnvFile)
+// runSpokeManager runs the spoke manager. With leader election enabled, only the elected replica runs the controllers,
+// the hub manager and the lease updater of the addon.
+func runSpokeManager(ctx context.Context, options AddonAgentOptions, providerClients *ProviderClients, health *AgentHealth, logger *slog.Logger) error {
+	currentNamespace := options.getInstallNamespace()
+	// The leases are in the namespace of the agent pod, which is on the hosting cluster in hosted mode
+	podNamespace := utils.GetEnv("POD_NAMESPACE", options.testEnvFile)
 
 	spokeKubeConfig, err := utils.GetClientConfig(options.KubeconfigFile)
 	if err != nil {
-		logger.Error("Failed to get kubeconfig", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	// The leases of the addon and of the leader election are on the cluster running the agent, which is the hosting
+	// cluster in hosted mode
+	leaseKubeConfig := spokeKubeConfig
+	if options.isHosted() {
+		leaseKubeConfig, err = utils.GetClientConfig("")
+		if err != nil {
+			return fmt.Errorf("failed to get in-cluster kubeconfig: %w", err)
+		}
+	}
+
+	// The cleanup Job of the addon fences the agent before reverting its changes
+	leaseClient, err := utils.GetClientFromConfig(leaseKubeConfig, mgrScheme)
+	if err != nil {
+		return fmt.Errorf("failed to get client of the agent cluster: %w", err)
+	}
+	fenced, err := isAgentFenced(ctx, leaseClient, podNamespace)
+	if err != nil {
+		return err
+	}
+	if fenced {
+		logger.Info("Agent is fenced by the addon cleanup, waiting for its removal", "ConfigMap", AgentFenceName)
+		<-ctx.Done()
+		return nil
 	}
 
 	mgr, err := ctrl.NewManager(spokeKubeConfig, ctrl.Options{
 		Scheme: mgrScheme,
+		// The metrics of both managers are served by the spoke manager, on every replica. Only authenticated and
+		// authorized scrapers can access them.
 		Metrics: server.Options{
-			BindAddress: "0", // disable metrics
+			BindAddress:    options.MetricsAddr,
+			SecureServing:  true,
+			CertDir:        options.MetricsCertDir,
+			FilterProvider: metricsFilterProvider(leaseKubeConfig),
 		},
-		HealthProbeBindAddress: "0", // disable health probe
-		ReadinessEndpointName:  "0", // disable readiness probe
+		HealthProbeBindAddress:        "0", // disable health probe
+		ReadinessEndpointName:         "0", // disable readiness probe
+		LeaderElection:                options.EnableLeaderElection,
+		LeaderElectionID:              leaderElectionID,
+		LeaderElectionNamespace:       podNamespace,
+		LeaderElectionConfig:          leaseKubeConfig,
+		LeaderElectionReleaseOnCancel: true,
+		Controller:                    supervisedControllerOptions,
 	})
 
 	if err != nil {
-		logger.Error("Failed to start manager", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to start manager: %w", err)
 	}
 
 	spokeKubeClient, err := kubernetes.NewForConfig(spokeKubeConfig)
 	if err != nil {
-		logger.Error("Failed to get spoke kube client", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get spoke kube client: %w", err)
+	}
+
+	leaseKubeClient, err := kubernetes.NewForConfig(leaseKubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get lease kube client: %w", err)
+	}
+
+	// Replicas stand by until they win the leader election of the spoke manager
+	if options.EnableLeaderElection {
+		if err := mgr.Add(health.ElectionRunnable()); err != nil {
+			return fmt.Errorf("failed to add leader election tracking to spoke manager: %w", err)
+		}
+	}
+	if err := mgr.Add(health.LeaderRunnable()); err != nil {
+		return fmt.Errorf("failed to add leader tracking to spoke manager: %w", err)
 	}
 
 	if err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
 		logger.Info("Starting lease updater")
 		leaseUpdater := lease.NewLeaseUpdater(
-			spokeKubeClient,
+			leaseKubeClient,
 			setup.TokenExchangeName,
-			currentNamespace,
+			podNamespace,
+			health.IsHealthy,
 		)
 		leaseUpdater.Start(ctx)
 		<-ctx.Done()
 		return nil
 	})); err != nil {
-		logger.Error("Failed to start lease updater", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to start lease updater: %w", err)
 	}
 
 	hubConfig, err := utils.GetClientConfig(options.HubKubeconfigFile)
 	if err != nil {
-		logger.Error("Failed to get kubeconfig", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
+	instrumentHubConfig(hubConfig)
 
 	hubClient, err := utils.GetClientFromConfig(hubConfig, mgr.GetScheme())
 	if err != nil {
-		logger.Error("Failed to get hub client", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get hub client: %w", err)
 	}
 
 	if err = (&S3SecretReconciler{
@@ -266,8 +385,7 @@ func runSpokeManager(ctx context.Context, options AddonAgentOptions, logger *slo
 		testEnvFile:      options.testEnvFile,
 		CurrentNamespace: currentNamespace,
 	}).SetupWithManager(mgr); err != nil {
-		logger.Error("Failed to create S3Secret controller", "controller", "S3Secret", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create S3Secret controller: %w", err)
 	}
 
 	if err = (&ResourceDistributionReconciler{
@@ -278,8 +396,91 @@ func runSpokeManager(ctx context.Context, options AddonAgentOptions, logger *slo
 		Logger:           logger.With("controller", "ResourceDistributionReconciler"),
 		CurrentNamespace: currentNamespace,
 	}).SetupWithManager(mgr); err != nil {
-		logger.Error("Failed to create ResourceDistributionReconciler controller", "controller", "ResourceDistributionReconciler", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create ResourceDistributionReconciler controller: %w", err)
+	}
+
+	clusterCIDRReconciler := &ClusterCIDRReconciler{
+		Scheme:      mgr.GetScheme(),
+		SpokeClient: mgr.GetClient(),
+		Logger:      logger.With("controller", "ClusterCIDRReconciler"),
+	}
+	if err = clusterCIDRReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to create ClusterCIDRReconciler controller: %w", err)
+	}
+
+	if err = (&ODFCapabilitiesReconciler{
+		Scheme:      mgr.GetScheme(),
+		SpokeClient: mgr.GetClient(),
+		Logger:      logger.With("controller", "ODFCapabilitiesReconciler"),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to create ODFCapabilitiesReconciler controller: %w", err)
+	}
+
+	if err = (&StorageIDsReconciler{
+		Scheme:           mgr.GetScheme(),
+		SpokeClient:      mgr.GetClient(),
+		SpokeClusterName: options.SpokeClusterName,
+		Logger:           logger.With("controller", "StorageIDsReconciler"),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to create StorageIDsReconciler controller: %w", err)
+	}
+
+	if err = (&ProviderClientsReconciler{
+		Scheme:           mgr.GetScheme(),
+		HubClient:        hubClient,
+		SpokeClient:      mgr.GetClient(),
+		ProviderClients:  providerClients,
+		Logger:           logger.With("controller", "ProviderClientsReconciler"),
+		CurrentNamespace: currentNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to create ProviderClientsReconciler controller: %w", err)
+	}
+
+	if err := mgr.Add(health.ManagerRunnable(spokeManagerName, mgr)); err != nil {
+		return fmt.Errorf("failed to add health tracking to spoke manager: %w", err)
+	}
+
+	migrator := &AgentMigrator{
+		HubClient:        hubClient,
+		SpokeClient:      mgr.GetClient(),
+		SpokeClusterName: options.SpokeClusterName,
+		CurrentNamespace: currentNamespace,
+		Logger:           logger.With("component", "AgentMigrator"),
+	}
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		logger.Info("Starting agent migrations", "Version", version.Version)
+		migrator.Start(ctx)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("failed to start agent migrations: %w", err)
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		logger.Info("Starting agent health reporter")
+		(&AgentHealthReporter{
+			Health:                health,
+			Migrator:              migrator,
+			ClusterCIDRs:          clusterCIDRReconciler,
+			HubClient:             hubClient,
+			SpokeClient:           mgr.GetClient(),
+			SpokeClusterName:      options.SpokeClusterName,
+			UXBackendProxyAddress: options.getUXBackendProxyAddress(),
+			Logger:                logger.With("component", "AgentHealthReporter"),
+		}).Start(ctx)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("failed to start agent health reporter: %w", err)
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		logger.Info("Starting odf-info refresher")
+		(&ODFInfoRefresher{
+			SpokeClient: mgr.GetClient(),
+			Logger:      logger.With("component", "ODFInfoRefresher"),
+		}).Start(ctx)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("failed to start odf-info refresher: %w", err)
 	}
 
 	addonDeletionLock := corev1.ConfigMap{
@@ -290,13 +491,21 @@ func runSpokeManager(ctx context.Context, options AddonAgentOptions, logger *slo
 	}
 	_, err = spokeKubeClient.CoreV1().ConfigMaps(currentNamespace).Create(ctx, &addonDeletionLock, metav1.CreateOptions{})
 	if err != nil && !errors.IsAlreadyExists(err) {
-		logger.Error("Error occurred when creating addon deletion lock", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error occurred when creating addon deletion lock: %w", err)
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		superviseManager(ctx, hubManagerName, health, managerRestartBackoff, func(ctx context.Context) error {
+			return runHubManager(ctx, options, providerClients, health, logger)
+		}, logger)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("failed to start hub manager: %w", err)
 	}
 
 	logger.Info("Starting spoke controller manager")
 	if err := mgr.Start(ctx); err != nil {
-		logger.Error("Problem running spoke controller manager", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("problem running spoke controller manager: %w", err)
 	}
+	return nil
 }
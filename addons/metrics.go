@@ -0,0 +1,124 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+const (
+	metricsResultSuccess = "success"
+	metricsResultFailure = "failure"
+)
+
+var (
+	obcSecretSyncDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "odf_multicluster_orchestrator_agent_obc_secret_sync_duration_seconds",
+			Help:    "Duration of the sync of the S3 secret of an ObjectBucketClaim to the hub",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"result"},
+	)
+	onboardingTokenRenewals = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "odf_multicluster_orchestrator_agent_onboarding_token_renewals_total",
+			Help: "Number of StorageCluster peer onboarding tokens requested for a MirrorPeer, because it had none or it expired",
+		},
+		[]string{"mirror_peer", "result"},
+	)
+	onboardingTokenExpiration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_agent_onboarding_token_expiration_timestamp_seconds",
+			Help: "Unix timestamp of the expiration of the StorageCluster peer onboarding token of a MirrorPeer",
+		},
+		[]string{"mirror_peer"},
+	)
+	storageClassLabellingFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "odf_multicluster_orchestrator_agent_storageclass_labelling_failures_total",
+			Help: "Number of failures to label the default StorageClasses of a StorageCluster with their storage IDs",
+		},
+		[]string{"storage_cluster"},
+	)
+	resourceDistributionUpdates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "odf_multicluster_orchestrator_agent_resource_distribution_updates_total",
+			Help: "Number of updates of the VolumeReplicationClasses distributed to a StorageConsumer",
+		},
+		[]string{"storage_consumer", "result"},
+	)
+	hubAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "odf_multicluster_orchestrator_agent_hub_api_errors_total",
+			Help: "Number of failed requests of the agent to the hub API server by HTTP status code, or \"error\" when no response was received",
+		},
+		[]string{"code"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(obcSecretSyncDuration, onboardingTokenRenewals, onboardingTokenExpiration,
+		storageClassLabellingFailures, resourceDistributionUpdates, hubAPIErrors)
+}
+
+// metricsFilterProvider protects the metrics endpoint with the authentication and authorization of the cluster running
+// the agent. In hosted mode the metrics are scraped on the hosting cluster, while the spoke manager serving them is
+// configured for the managed cluster.
+func metricsFilterProvider(config *rest.Config) func(*rest.Config, *http.Client) (metricsserver.Filter, error) {
+	return func(_ *rest.Config, _ *http.Client) (metricsserver.Filter, error) {
+		httpClient, err := rest.HTTPClientFor(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http client for the metrics authentication: %w", err)
+		}
+		return filters.WithAuthenticationAndAuthorization(config, httpClient)
+	}
+}
+
+func metricsResult(err error) string {
+	if err != nil {
+		return metricsResultFailure
+	}
+	return metricsResultSuccess
+}
+
+// deleteMirrorPeerMetrics removes the onboarding token metrics of a deleted MirrorPeer
+func deleteMirrorPeerMetrics(mirrorPeerName string) {
+	labels := prometheus.Labels{"mirror_peer": mirrorPeerName}
+	onboardingTokenRenewals.DeletePartialMatch(labels)
+	onboardingTokenExpiration.DeletePartialMatch(labels)
+}
+
+// hubAPIErrorsRoundTripper counts the failed requests to the hub API server. Not found and conflict responses are
+// part of the regular reconciliation and are not counted.
+type hubAPIErrorsRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt *hubAPIErrorsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	switch {
+	case err != nil:
+		if !errors.Is(err, context.Canceled) {
+			hubAPIErrors.WithLabelValues("error").Inc()
+		}
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusConflict:
+		hubAPIErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
+
+// instrumentHubConfig counts the failed requests of the clients created from the hub config
+func instrumentHubConfig(config *rest.Config) {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &hubAPIErrorsRoundTripper{next: rt}
+	})
+}
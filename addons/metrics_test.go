@@ -0,0 +1,86 @@
+package addons
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+type failingRoundTripper struct{}
+
+func (failingRoundTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+// hubAPIErrorCount returns the hub API errors of the given code served by the metrics registry of the agent
+func hubAPIErrorCount(t *testing.T, code string) float64 {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "odf_multicluster_orchestrator_agent_hub_api_errors_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "code" && label.GetValue() == code {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestHubAPIErrorsRoundTripper(t *testing.T) {
+	statusCodes := []int{http.StatusOK, http.StatusNotFound, http.StatusConflict, http.StatusForbidden, http.StatusServiceUnavailable}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statusCode, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	forbidden, unavailable, failed := hubAPIErrorCount(t, "403"), hubAPIErrorCount(t, "503"), hubAPIErrorCount(t, "error")
+
+	rt := &hubAPIErrorsRoundTripper{next: http.DefaultTransport}
+	for _, statusCode := range statusCodes {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"?status="+strconv.Itoa(statusCode), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	rt = &hubAPIErrorsRoundTripper{next: failingRoundTripper{}}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	if got := hubAPIErrorCount(t, "403") - forbidden; got != 1 {
+		t.Errorf("expected 1 forbidden request, got %v", got)
+	}
+	if got := hubAPIErrorCount(t, "503") - unavailable; got != 1 {
+		t.Errorf("expected 1 unavailable request, got %v", got)
+	}
+	if got := hubAPIErrorCount(t, "error") - failed; got != 1 {
+		t.Errorf("expected 1 failed request, got %v", got)
+	}
+	for _, code := range []string{"200", "404", "409"} {
+		if got := hubAPIErrorCount(t, code); got != 0 {
+			t.Errorf("expected no errors counted for status %s, got %v", code, got)
+		}
+	}
+}
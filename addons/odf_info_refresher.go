@@ -0,0 +1,81 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// odfInfoRefreshInterval is the interval of the refresh stamps of the odf-info, well below the staleness threshold of
+// the hub
+const odfInfoRefreshInterval = 10 * time.Minute
+
+// ODFInfoRefresher stamps the odf-info ConfigMaps published by the ClusterClaims of the spoke cluster with their
+// refresh time. The hub reads the stamp through the ManagedClusterViews of the odf-info, so that an odf-info which
+// did not change is still seen as fresh while the spoke cluster keeps reporting it.
+type ODFInfoRefresher struct {
+	SpokeClient client.Client
+	Logger      *slog.Logger
+}
+
+// Start refreshes the odf-info until the context is cancelled
+func (r *ODFInfoRefresher) Start(ctx context.Context) {
+	ticker := time.NewTicker(odfInfoRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.refresh(ctx, time.Now()); err != nil {
+			r.Logger.Error("Failed to refresh odf-info", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *ODFInfoRefresher) refresh(ctx context.Context, now time.Time) error {
+	var claimList clusterv1alpha1.ClusterClaimList
+	if err := r.SpokeClient.List(ctx, &claimList); err != nil {
+		return fmt.Errorf("failed to list ClusterClaims: %w", err)
+	}
+
+	var errs []error
+	for _, claim := range claimList.Items {
+		if !utils.IsODFInfoClusterClaim(claim.Name) {
+			continue
+		}
+		namespace, name, found := strings.Cut(claim.Spec.Value, "/")
+		if !found {
+			errs = append(errs, fmt.Errorf("invalid format for namespaced name claim %q: expected 'namespace/name', got '%s'", claim.Name, claim.Spec.Value))
+			continue
+		}
+		namespacedName := types.NamespacedName{Namespace: namespace, Name: name}
+		var configMap corev1.ConfigMap
+		if err := r.SpokeClient.Get(ctx, namespacedName, &configMap); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to get ConfigMap %q: %w", namespacedName, err))
+			}
+			continue
+		}
+		original := configMap.DeepCopy()
+		if configMap.Annotations == nil {
+			configMap.Annotations = map[string]string{}
+		}
+		configMap.Annotations[utils.ODFInfoRefreshTimeAnnotationKey] = now.UTC().Format(time.RFC3339)
+		if err := r.SpokeClient.Patch(ctx, &configMap, client.MergeFrom(original)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stamp the refresh time of ConfigMap %q: %w", namespacedName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package addons
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestODFInfoRefresherRefresh(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	claim := func(name, value string) *clusterv1alpha1.ClusterClaim {
+		return &clusterv1alpha1.ClusterClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       clusterv1alpha1.ClusterClaimSpec{Value: value},
+		}
+	}
+	odfInfo := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.ODFInfoConfigMapName, Namespace: "openshift-storage"},
+		Data:       map[string]string{"openshift-storage_ocs-storagecluster.config.yaml": "version: 4.19.0"},
+	}
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "openshift-storage"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		claim(utils.OdfInfoClusterClaimNamespacedName, "openshift-storage/"+utils.ODFInfoConfigMapName),
+		// The ConfigMap of an additional odf-info ClusterClaim was deleted
+		claim("extra."+utils.OdfInfoClusterClaimNamespacedName, "openshift-storage-extra/"+utils.ODFInfoConfigMapName),
+		claim("other.claim", "openshift-storage/other"),
+		odfInfo, other,
+	).Build()
+
+	r := ODFInfoRefresher{SpokeClient: fakeClient, Logger: utils.GetLogger(utils.GetZapLogger(true))}
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	if err := r.refresh(context.TODO(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: odfInfo.Name, Namespace: odfInfo.Namespace}, &configMap); err != nil {
+		t.Fatal(err)
+	}
+	if got := configMap.Annotations[utils.ODFInfoRefreshTimeAnnotationKey]; got != "2025-04-01T12:00:00Z" {
+		t.Errorf("expected the odf-info to be stamped with its refresh time, got %q", got)
+	}
+	if len(configMap.Data) != 1 {
+		t.Errorf("expected the data of the odf-info to be kept, got %v", configMap.Data)
+	}
+
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: other.Name, Namespace: other.Namespace}, &configMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := configMap.Annotations[utils.ODFInfoRefreshTimeAnnotationKey]; ok {
+		t.Error("expected ConfigMaps of other ClusterClaims not to be stamped")
+	}
+}
@@ -16,11 +16,14 @@ import (
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -34,12 +37,46 @@ type OnboardingTicket struct {
 	StorageCluster    types.UID             `json:"storageCluster"`
 }
 
-func requestStorageClusterPeerToken(ctx context.Context, proxyServiceNamespace string) ([]byte, error) {
+// agentServiceAccountName is the service account of the agent on the managed cluster
+const agentServiceAccountName = "token-exchange-agent-sa"
+
+// ServiceAccountTokenSource returns the token of the agent service account authenticating the agent to the
+// ux-backend-proxy of the managed cluster
+type ServiceAccountTokenSource func(ctx context.Context) ([]byte, error)
+
+// inClusterServiceAccountToken reads the token mounted into the agent pod running on the managed cluster
+func inClusterServiceAccountToken(_ context.Context) ([]byte, error) {
 	token, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read token: %w", err)
 	}
-	url := fmt.Sprintf("https://ux-backend-proxy.%s.svc.cluster.local:8888/onboarding/peer-tokens", proxyServiceNamespace)
+	return token, nil
+}
+
+// requestServiceAccountToken returns a ServiceAccountTokenSource requesting short-lived tokens of the agent service
+// account from the managed cluster. In hosted mode the agent pod runs on the hosting cluster, its own service
+// account token is not valid on the managed cluster.
+func requestServiceAccountToken(kubeClient kubernetes.Interface, namespace string) ServiceAccountTokenSource {
+	return func(ctx context.Context) ([]byte, error) {
+		tokenRequest := &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				ExpirationSeconds: ptr.To(int64(600)),
+			},
+		}
+		tokenRequest, err := kubeClient.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, agentServiceAccountName, tokenRequest, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to request a token for service account %s/%s: %w", namespace, agentServiceAccountName, err)
+		}
+		return []byte(tokenRequest.Status.Token), nil
+	}
+}
+
+func requestStorageClusterPeerToken(ctx context.Context, serviceAccountToken ServiceAccountTokenSource, proxyAddress string) ([]byte, error) {
+	token, err := serviceAccountToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("https://%s/onboarding/peer-tokens", proxyAddress)
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &http.Transport{
@@ -72,7 +109,7 @@ func requestStorageClusterPeerToken(ctx context.Context, proxyServiceNamespace s
 	return body, nil
 }
 
-func createStorageClusterPeerTokenSecret(ctx context.Context, client client.Client, scheme *runtime.Scheme, spokeClusterName string, odfOperatorNamespace string, mirrorPeer multiclusterv1alpha1.MirrorPeer, storageClusterRef *v1alpha1.StorageClusterRef) error {
+func createStorageClusterPeerTokenSecret(ctx context.Context, client client.Client, scheme *runtime.Scheme, spokeClusterName string, serviceAccountToken ServiceAccountTokenSource, proxyAddress string, mirrorPeer multiclusterv1alpha1.MirrorPeer, storageClusterRef *v1alpha1.StorageClusterRef) error {
 	uniqueSecretName := string(mirrorPeer.GetUID())
 	_, err := utils.FetchSecretWithName(ctx, client, types.NamespacedName{Namespace: spokeClusterName, Name: uniqueSecretName})
 	if err != nil && !errors.IsNotFound(err) {
@@ -82,7 +119,7 @@ func createStorageClusterPeerTokenSecret(ctx context.Context, client client.Clie
 		return errors.NewAlreadyExists(corev1.Resource("secret"), uniqueSecretName)
 	}
 
-	token, err := requestStorageClusterPeerToken(ctx, odfOperatorNamespace)
+	token, err := requestStorageClusterPeerToken(ctx, serviceAccountToken, proxyAddress)
 	if err != nil {
 		return fmt.Errorf("unable to generate StorageClusterPeer token. %w", err)
 	}
@@ -109,7 +146,14 @@ func createStorageClusterPeerTokenSecret(ctx context.Context, client client.Clie
 		return fmt.Errorf("failed to set owner reference for secret %s/%s: %w", spokeClusterName, uniqueSecretName, err)
 	}
 
-	return client.Create(ctx, tokenSecret)
+	if err := client.Create(ctx, tokenSecret); err != nil {
+		return err
+	}
+
+	if ticketData, err := UnmarshalOnboardingToken(tokenSecret); err == nil {
+		onboardingTokenExpiration.WithLabelValues(mirrorPeer.Name).Set(float64(ticketData.ExpirationDate))
+	}
+	return nil
 }
 
 func deleteStorageClusterPeerTokenSecret(ctx context.Context, client client.Client, tokenNamespace string, tokenName string) error {
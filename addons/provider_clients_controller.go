@@ -0,0 +1,173 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// providerClientsResyncInterval is the interval at which StorageClients whose ManagedCluster is not known to the hub
+// yet are resolved again
+const providerClientsResyncInterval = time.Minute
+
+// ProviderClients is an in-memory view of the ManagedClusters running StorageClients of the spoke cluster. It lets the
+// event filters of the agent tell whether the spoke cluster provides storage to a MirrorPeer without any API call.
+type ProviderClients struct {
+	mu           sync.RWMutex
+	clusterNames []string
+	changed      chan event.GenericEvent
+}
+
+func NewProviderClients() *ProviderClients {
+	return &ProviderClients{
+		// A single pending notification is enough since watchers re-evaluate the whole view
+		changed: make(chan event.GenericEvent, 1),
+	}
+}
+
+// Has returns true when a StorageClient of the spoke cluster runs on the ManagedCluster
+func (p *ProviderClients) Has(clusterName string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Contains(p.clusterNames, clusterName)
+}
+
+// ClusterNames returns the ManagedClusters of the view
+func (p *ProviderClients) ClusterNames() []string {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Clone(p.clusterNames)
+}
+
+// Changed returns the channel notified after the ManagedClusters of the view changed
+func (p *ProviderClients) Changed() <-chan event.GenericEvent {
+	return p.changed
+}
+
+// set replaces the ManagedClusters of the view. It returns true and notifies Changed when they differ.
+func (p *ProviderClients) set(clusterNames []string) bool {
+	clusterNames = slices.Clone(clusterNames)
+	slices.Sort(clusterNames)
+	clusterNames = slices.Compact(clusterNames)
+
+	p.mu.Lock()
+	if slices.Equal(p.clusterNames, clusterNames) {
+		p.mu.Unlock()
+		return false
+	}
+	p.clusterNames = clusterNames
+	p.mu.Unlock()
+
+	select {
+	case p.changed <- event.GenericEvent{Object: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: utils.ODFInfoConfigMapName}}}:
+	default:
+	}
+	return true
+}
+
+// ProviderClientsReconciler refreshes the ProviderClients view from the clients listed in the odf-info ConfigMap of
+// the spoke cluster
+type ProviderClientsReconciler struct {
+	Scheme           *runtime.Scheme
+	HubClient        client.Client
+	SpokeClient      client.Client
+	ProviderClients  *ProviderClients
+	Logger           *slog.Logger
+	CurrentNamespace string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProviderClientsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Logger.Info("Setting up controller with manager")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("provider_clients_controller").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(object client.Object) bool {
+				return object.GetName() == utils.ODFInfoConfigMapName && object.GetNamespace() == r.CurrentNamespace
+			}),
+		)).
+		Complete(r)
+}
+
+func (r *ProviderClientsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With("Request", req.NamespacedName.String())
+	logger.Info("Refreshing the ManagedClusters of the StorageClients")
+
+	var clientClusterIDs []string
+	cm, err := utils.GetODFInfoConfigMap(ctx, r.SpokeClient, r.CurrentNamespace)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to get ODF Info ConfigMap for namespace %s: %w", r.CurrentNamespace, err)
+	}
+	if err == nil {
+		clientClusterIDs, err = getClientClusterIDs(cm)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var clusterNames, unresolved []string
+	if len(clientClusterIDs) > 0 {
+		for _, clusterID := range clientClusterIDs {
+			mc, err := utils.GetManagedClusterById(ctx, r.HubClient, clusterID)
+			if err != nil {
+				if k8serrors.IsNotFound(err) {
+					unresolved = append(unresolved, clusterID)
+					continue
+				}
+				return ctrl.Result{}, fmt.Errorf("failed to get ManagedCluster of StorageClient cluster %q: %w", clusterID, err)
+			}
+			clusterNames = append(clusterNames, mc.Name)
+		}
+	}
+
+	if r.ProviderClients.set(clusterNames) {
+		logger.Info("ManagedClusters of the StorageClients changed", "ManagedClusters", clusterNames)
+	}
+	if len(unresolved) > 0 {
+		// StorageClients may be onboarded before their cluster is imported into the hub
+		logger.Info("StorageClients run on clusters unknown to the hub, retrying later", "ClusterIDs", unresolved)
+		return ctrl.Result{RequeueAfter: providerClientsResyncInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// getClientClusterIDs returns the cluster IDs of the StorageClients listed in the odf-info ConfigMap
+func getClientClusterIDs(cm *corev1.ConfigMap) ([]string, error) {
+	var clusterIDs []string
+	for key, value := range cm.Data {
+		var odfInfo ocsv1alpha1.OdfInfoData
+		if err := yaml.Unmarshal([]byte(value), &odfInfo); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ODF info data for key %s: %w", key, err)
+		}
+		for _, storageClient := range odfInfo.Clients {
+			if storageClient.ClusterID != "" && !slices.Contains(clusterIDs, storageClient.ClusterID) {
+				clusterIDs = append(clusterIDs, storageClient.ClusterID)
+			}
+		}
+	}
+	slices.Sort(clusterIDs)
+	return clusterIDs, nil
+}
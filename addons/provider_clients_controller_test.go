@@ -0,0 +1,112 @@
+package addons
+
+import (
+	"context"
+	"testing"
+
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestProviderClientsReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	odfInfo := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.ODFInfoConfigMapName, Namespace: odfNamespace},
+		Data: map[string]string{
+			"test-namespace_test-storagecluster.config.yaml": `
+version: 4.18.0
+deploymentType: internal
+clients:
+  - name: client-1
+    clusterId: id-1
+  - name: client-2
+    clusterId: id-2
+storageCluster:
+  namespacedName:
+    namespace: test-namespace
+    name: test-storagecluster
+`,
+		},
+	}
+	managedCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Labels: map[string]string{"clusterID": "id-1"}},
+	}
+	fakeSpokeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(odfInfo).Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(managedCluster).
+		WithIndex(&clusterv1.ManagedCluster{}, utils.ManagedClusterIDIndexKey, utils.ManagedClusterIDIndexFunc).Build()
+
+	providerClients := NewProviderClients()
+	r := ProviderClientsReconciler{
+		Scheme:           scheme,
+		HubClient:        fakeHubClient,
+		SpokeClient:      fakeSpokeClient,
+		ProviderClients:  providerClients,
+		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+		CurrentNamespace: odfNamespace,
+	}
+
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.ODFInfoConfigMapName, Namespace: odfNamespace}}
+	res, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("ProviderClientsReconciler Reconcile() failed. Error: %s", err)
+	}
+	// The cluster of client-2 is not imported into the hub yet
+	if res.RequeueAfter != providerClientsResyncInterval {
+		t.Errorf("expected requeue after %s, got %s", providerClientsResyncInterval, res.RequeueAfter)
+	}
+	if !providerClients.Has("cluster1") || providerClients.Has("cluster2") {
+		t.Errorf("expected only cluster1 in the view, got %v", providerClients.clusterNames)
+	}
+	select {
+	case <-providerClients.Changed():
+	default:
+		t.Error("expected a notification after the view changed")
+	}
+
+	mirrorPeer := &multiclusterv1alpha1.MirrorPeer{Spec: multiclusterv1alpha1.MirrorPeerSpec{Items: []multiclusterv1alpha1.PeerRef{
+		{ClusterName: "cluster1"}, {ClusterName: "cluster3"},
+	}}}
+	agent := MirrorPeerReconciler{SpokeClusterName: "provider", ProviderClients: providerClients}
+	if !agent.hasProviderSpokeCluster(mirrorPeer) {
+		t.Error("expected the MirrorPeer to have a StorageClient of the spoke cluster")
+	}
+
+	managedCluster2 := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Labels: map[string]string{"clusterID": "id-2"}},
+	}
+	if err := fakeHubClient.Create(ctx, managedCluster2); err != nil {
+		t.Fatal(err)
+	}
+	res, err = r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("ProviderClientsReconciler Reconcile() failed. Error: %s", err)
+	}
+	if res.RequeueAfter != 0 || !providerClients.Has("cluster2") {
+		t.Errorf("expected cluster2 in the view without requeue, got %v", providerClients.clusterNames)
+	}
+
+	if err := fakeSpokeClient.Delete(ctx, odfInfo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("ProviderClientsReconciler Reconcile() failed. Error: %s", err)
+	}
+	if providerClients.Has("cluster1") || agent.hasProviderSpokeCluster(mirrorPeer) {
+		t.Errorf("expected an empty view, got %v", providerClients.clusterNames)
+	}
+}
@@ -2,6 +2,7 @@ package addons
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"reflect"
@@ -11,6 +12,7 @@ import (
 	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -122,6 +124,11 @@ func (r *ResourceDistributionReconciler) Reconcile(ctx context.Context, req ctrl
 
 	var addonDeletionlock corev1.ConfigMap
 	err = r.SpokeClient.Get(ctx, types.NamespacedName{Namespace: r.CurrentNamespace, Name: AddonDeletionlockName}, &addonDeletionlock)
+	if errors.IsNotFound(err) {
+		// The lock is created on startup and deleted by the cleanup of the addon, nothing is distributed after it
+		logger.Info("Addon deletion lock not found, skipping the distribution of resources", "ConfigMap", AddonDeletionlockName)
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -179,17 +186,20 @@ func (r *ResourceDistributionReconciler) Reconcile(ctx context.Context, req ctrl
 
 	for _, template := range templateList.Items {
 		logger.Info("Distributing template", "Template", template.GetName())
-		if template.DeletionTimestamp.IsZero() {
+		// Only VolumeReplicationClasses are distributed through the StorageConsumers, the templates of other kinds
+		// are removed from them
+		distribute := template.DeletionTimestamp.IsZero() && isVolumeReplicationClassTemplate(&template)
+		if distribute {
 			err = addFinalizerToObject(ctx, r.SpokeClient, &template, ResourceDistributionFinalizer)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 		}
-		for _, foundConsumer := range storageConsumerList.Items {
+		for i, foundConsumer := range storageConsumerList.Items {
 			expectedConsumer := foundConsumer.DeepCopy()
 			vrc := ocsv1alpha1.VolumeReplicationClassSpec{Name: template.Name}
 			index := slices.Index(expectedConsumer.Spec.VolumeReplicationClasses, vrc)
-			if template.DeletionTimestamp.IsZero() {
+			if distribute {
 				if slices.Contains(addVRCToConsumers, foundConsumer.GetName()) {
 					logger.Info("Adding VRC to StorageConsumer", "VRC", vrc, "StorageConsumer", foundConsumer.GetName())
 					if index == -1 {
@@ -202,16 +212,19 @@ func (r *ResourceDistributionReconciler) Reconcile(ctx context.Context, req ctrl
 					}
 				}
 			} else {
-				logger.Info("Template is being deleted. Removing VRC from StorageConsumer", "VRC", vrc, "StorageConsumer", foundConsumer.GetName())
+				logger.Info("Template is being deleted or is not a VolumeReplicationClass. Removing VRC from StorageConsumer", "VRC", vrc, "StorageConsumer", foundConsumer.GetName())
 				if index != -1 {
 					expectedConsumer.Spec.VolumeReplicationClasses = slices.Delete(expectedConsumer.Spec.VolumeReplicationClasses, index, index+1)
 				}
 			}
 			if !reflect.DeepEqual(expectedConsumer.Spec.VolumeReplicationClasses, foundConsumer.Spec.VolumeReplicationClasses) {
 				err := r.SpokeClient.Update(ctx, expectedConsumer)
+				resourceDistributionUpdates.WithLabelValues(expectedConsumer.Name, metricsResult(err)).Inc()
 				if err != nil {
 					return ctrl.Result{}, fmt.Errorf("unable to add VolumeReplicationClasses to StorageConsumer %q: %w", expectedConsumer.Name, err)
 				}
+				// The next templates are applied on top of the updated StorageConsumer
+				storageConsumerList.Items[i] = *expectedConsumer
 			}
 			logger.Info("VolumeReplicationClass was updated on StorageConsumers.", "StorageConsumer", expectedConsumer.GetName())
 		}
@@ -225,3 +238,15 @@ func (r *ResourceDistributionReconciler) Reconcile(ctx context.Context, req ctrl
 	logger.Info("Successfully distributed resources to all StorageConsumers.")
 	return ctrl.Result{}, nil
 }
+
+// isVolumeReplicationClassTemplate returns true if the template holds a VolumeReplicationClass
+func isVolumeReplicationClassTemplate(template *templatev1.Template) bool {
+	if len(template.Objects) == 0 {
+		return false
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(template.Objects[0].Raw, &typeMeta); err != nil {
+		return false
+	}
+	return typeMeta.Kind == "VolumeReplicationClass"
+}
@@ -0,0 +1,121 @@
+package addons
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResourceDistributionReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme, templatev1.AddToScheme, ocsv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newTemplate := func(name, kind string) *templatev1.Template {
+		return &templatev1.Template{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: odfNamespace,
+				Labels:    map[string]string{utils.CreatedByLabelKey: utils.CreatorMulticlusterOrchestrator},
+			},
+			Objects: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion":"replication.storage.openshift.io/v1alpha1","kind":"` + kind + `","metadata":{"name":"` + name + `"}}`)},
+			},
+		}
+	}
+
+	// The consumer of the mapped client gets the VolumeReplicationClass. A VolumeGroupReplicationClass distributed
+	// by an earlier release is removed from it.
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: AddonDeletionlockName, Namespace: odfNamespace}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.StorageClientMappingConfigMapName, Namespace: odfNamespace},
+			Data:       map[string]string{"client-1": "client-2"},
+		},
+		newTemplate("rbd-volumereplicationclass-1", "VolumeReplicationClass"),
+		newTemplate("rbd-volumegroupreplicationclass-1", "VolumeGroupReplicationClass"),
+		&ocsv1alpha1.StorageConsumer{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer-1", Namespace: odfNamespace},
+			Spec: ocsv1alpha1.StorageConsumerSpec{VolumeReplicationClasses: []ocsv1alpha1.VolumeReplicationClassSpec{
+				{Name: "rbd-volumegroupreplicationclass-1"},
+			}},
+			Status: ocsv1alpha1.StorageConsumerStatus{Client: &ocsv1alpha1.ClientStatus{ID: "client-1"}},
+		},
+		&ocsv1alpha1.StorageConsumer{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer-2", Namespace: odfNamespace},
+			Status:     ocsv1alpha1.StorageConsumerStatus{Client: &ocsv1alpha1.ClientStatus{ID: "client-3"}},
+		},
+	).Build()
+
+	r := ResourceDistributionReconciler{
+		SpokeClient:      fakeClient,
+		CurrentNamespace: odfNamespace,
+		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+	}
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.StorageClientMappingConfigMapName, Namespace: odfNamespace}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]ocsv1alpha1.VolumeReplicationClassSpec{
+		"consumer-1": {{Name: "rbd-volumereplicationclass-1"}},
+		"consumer-2": nil,
+	}
+	for name, vrcs := range expected {
+		var consumer ocsv1alpha1.StorageConsumer
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: odfNamespace}, &consumer); err != nil {
+			t.Fatal(err)
+		}
+		if len(consumer.Spec.VolumeReplicationClasses) != len(vrcs) ||
+			(len(vrcs) > 0 && !reflect.DeepEqual(consumer.Spec.VolumeReplicationClasses, vrcs)) {
+			t.Errorf("expected VolumeReplicationClasses %v on StorageConsumer %q, got %v", vrcs, name, consumer.Spec.VolumeReplicationClasses)
+		}
+	}
+
+	var template templatev1.Template
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: "rbd-volumegroupreplicationclass-1", Namespace: odfNamespace}, &template); err != nil {
+		t.Fatal(err)
+	}
+	if len(template.Finalizers) != 0 {
+		t.Errorf("expected no finalizer on the VolumeGroupReplicationClass Template, got %v", template.Finalizers)
+	}
+}
+
+func TestResourceDistributionReconcileWithoutLock(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// The addon deletion lock was deleted by the cleanup of the addon
+	r := ResourceDistributionReconciler{
+		SpokeClient:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		CurrentNamespace: odfNamespace,
+		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.StorageClientMappingConfigMapName, Namespace: odfNamespace}}
+	result, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsZero() {
+		t.Errorf("expected no requeue, got %v", result)
+	}
+}
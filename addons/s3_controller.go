@@ -101,7 +101,9 @@ func (r *S3SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	mirrorPeerName := obc.Annotations[utils.MirrorPeerNameAnnotationKey]
 	obcType := obc.Annotations[OBCTypeAnnotationKey]
 
+	syncStarted := time.Now()
 	err = r.syncBlueSecretForS3(ctx, obc.Name, obc.Namespace, mirrorPeerName, obcType)
+	obcSecretSyncDuration.WithLabelValues(metricsResult(err)).Observe(time.Since(syncStarted).Seconds())
 	if err != nil {
 		logger.Error("Failed to sync Blue Secret for S3", "error", err)
 		return ctrl.Result{}, err
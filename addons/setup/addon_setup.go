@@ -6,14 +6,20 @@ import (
 	"embed"
 	"encoding/pem"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"slices"
 
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -24,6 +30,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -43,8 +50,42 @@ var tokenExchangeDeploymentFiles = []string{
 	"tokenexchange-manifests/spoke_clusterrolebinding.yaml",
 	"tokenexchange-manifests/spoke_rolebinding.yaml",
 	"tokenexchange-manifests/spoke_deployment.yaml",
+	"tokenexchange-manifests/spoke_cleanup_job.yaml",
+	"tokenexchange-manifests/spoke_metrics_service.yaml",
+	"tokenexchange-manifests/spoke_servicemonitor.yaml",
+	"tokenexchange-manifests/spoke_metrics_reader_serviceaccount.yaml",
+	"tokenexchange-manifests/spoke_metrics_reader_secret.yaml",
+	"tokenexchange-manifests/spoke_metrics_reader_clusterrole.yaml",
+	"tokenexchange-manifests/spoke_metrics_reader_clusterrolebinding.yaml",
 }
 
+// tokenExchangeHostingFiles are deployed on the hosting cluster in hosted mode, the agent renews its lease and
+// authenticates the scrapers of its metrics there
+var tokenExchangeHostingFiles = []string{
+	"tokenexchange-manifests/hosting_namespace.yaml",
+	"tokenexchange-manifests/hosting_serviceaccount.yaml",
+	"tokenexchange-manifests/hosting_role.yaml",
+	"tokenexchange-manifests/hosting_rolebinding.yaml",
+	"tokenexchange-manifests/hosting_clusterrole.yaml",
+	"tokenexchange-manifests/hosting_clusterrolebinding.yaml",
+}
+
+const (
+	// InstallModeDefault deploys the agent on the managed cluster
+	InstallModeDefault = "Default"
+	// InstallModeHosted deploys the agent on the hosting cluster of a hosted control plane cluster. The agent reaches
+	// the managed cluster through the external kubeconfig provided by the klusterlet.
+	InstallModeHosted = "Hosted"
+
+	// RetainDRDataAnnotationKey set to "true" on the ManagedClusterAddOn keeps the ObjectBucketClaims, storage IDs and
+	// VolumeReplicationClasses of the managed cluster when the addon is removed
+	RetainDRDataAnnotationKey = "multicluster.odf.openshift.io/retain-dr-data"
+
+	// ManagedInstallNamespaceAnnotationKey records the namespace of the addon on the managed cluster in hosted mode,
+	// where the install namespace of the ManagedClusterAddOn is the namespace of the agent on the hosting cluster
+	ManagedInstallNamespaceAnnotationKey = "multicluster.odf.openshift.io/managed-install-namespace"
+)
+
 //go:embed tokenexchange-manifests
 var exchangeManifestFiles embed.FS
 
@@ -52,9 +93,31 @@ type Addons struct {
 	Client     client.Client
 	AgentImage string
 	AddonName  string
+	Logger     *slog.Logger
 }
 
-// Manifests generates manifestworks to deploy the token exchange addon agent on the managed cluster
+// getInstallMode returns the install mode of the addon and the hosting cluster in hosted mode
+func getInstallMode(addon *addonapiv1alpha1.ManagedClusterAddOn) (string, string) {
+	hostingClusterName, ok := addon.Annotations[addonapiv1alpha1.HostingClusterNameAnnotationKey]
+	if !ok {
+		return InstallModeDefault, ""
+	}
+	return InstallModeHosted, hostingClusterName
+}
+
+// HostingInstallNamespace returns the namespace of the agent of the managed cluster on its hosting cluster. Each
+// hosted cluster gets its own namespace so that the agents of the clusters sharing a hosting cluster do not overwrite
+// each other.
+func HostingInstallNamespace(installNamespace, clusterName string) string {
+	namespace := fmt.Sprintf("%s-%s", installNamespace, clusterName)
+	if len(namespace) > 63 {
+		namespace = fmt.Sprintf("%s-%s", namespace[:52], utils.CreateUniqueName(installNamespace, clusterName)[:10])
+	}
+	return namespace
+}
+
+// Manifests generates manifestworks to deploy the token exchange addon agent on the managed cluster, or on its
+// hosting cluster in hosted mode
 func (a *Addons) Manifests(cluster *clusterv1.ManagedCluster, addon *addonapiv1alpha1.ManagedClusterAddOn) ([]runtime.Object, error) {
 	objects := []runtime.Object{}
 
@@ -81,36 +144,74 @@ func (a *Addons) Manifests(cluster *clusterv1.ManagedCluster, addon *addonapiv1a
 		return objects, fmt.Errorf("error while getting ODF operator namespace on the spoke cluster %q. Expected ClusterClaim does not exist", cluster.Name)
 	}
 
+	installMode, _ := getInstallMode(addon)
+
+	hostingNamespace := installNamespace
+	if managedNamespace, ok := addon.Annotations[ManagedInstallNamespaceAnnotationKey]; ok && installMode == InstallModeHosted {
+		installNamespace = managedNamespace
+	}
+
 	manifestConfig := struct {
-		KubeConfigSecret      string
-		ClusterName           string
-		AddonInstallNamespace string
-		OdfOperatorNamespace  string
-		Image                 string
-		DRMode                string
-		Group                 string
-		User                  string
+		KubeConfigSecret        string
+		ManagedKubeConfigSecret string
+		InstallMode             string
+		RetainDRData            bool
+		ClusterName             string
+		AddonInstallNamespace   string
+		HostingNamespace        string
+		OdfOperatorNamespace    string
+		Image                   string
+		DRMode                  string
+		Group                   string
+		User                    string
 	}{
-		KubeConfigSecret:      fmt.Sprintf("%s-hub-kubeconfig", a.AddonName),
-		AddonInstallNamespace: installNamespace,
-		OdfOperatorNamespace:  odfOperatorNamespace,
-		ClusterName:           cluster.Name,
-		Image:                 a.AgentImage,
-		DRMode:                addon.Annotations[utils.DRModeAnnotationKey],
-		Group:                 groups[0],
-		User:                  user,
+		KubeConfigSecret:        fmt.Sprintf("%s-hub-kubeconfig", a.AddonName),
+		ManagedKubeConfigSecret: fmt.Sprintf("%s-managed-kubeconfig", a.AddonName),
+		InstallMode:             installMode,
+		RetainDRData:            addon.Annotations[RetainDRDataAnnotationKey] == "true",
+		AddonInstallNamespace:   installNamespace,
+		HostingNamespace:        hostingNamespace,
+		OdfOperatorNamespace:    odfOperatorNamespace,
+		ClusterName:             cluster.Name,
+		Image:                   a.AgentImage,
+		DRMode:                  addon.Annotations[utils.DRModeAnnotationKey],
+		Group:                   groups[0],
+		User:                    user,
+	}
+
+	deploymentConfig, err := a.getDeploymentConfig(context.TODO(), addon)
+	if err != nil {
+		return objects, err
+	}
+
+	files := tokenExchangeDeploymentFiles
+	if installMode == InstallModeHosted {
+		files = append(slices.Clone(files), tokenExchangeHostingFiles...)
 	}
 
-	for _, file := range tokenExchangeDeploymentFiles {
+	for _, file := range files {
 		template, err := exchangeManifestFiles.ReadFile(file)
 		if err != nil {
 			return objects, err
 		}
 		raw := assets.MustCreateAssetFromTemplate(file, template, &manifestConfig).Data
 		object, _, err := genericCodec.Decode(raw, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			// Kinds of other operators, such as the ServiceMonitor of the Prometheus operator, are not part of the
+			// generic scheme
+			unstructuredObject := &unstructured.Unstructured{}
+			err = yaml.Unmarshal(raw, &unstructuredObject.Object)
+			object = unstructuredObject
+		}
 		if err != nil {
 			return nil, err
 		}
+		switch typed := object.(type) {
+		case *appsv1.Deployment:
+			deploymentConfig.apply(typed)
+		case *batchv1.Job:
+			deploymentConfig.applyPodSpec(&typed.Spec.Template.Spec)
+		}
 		objects = append(objects, object)
 	}
 
@@ -126,6 +227,8 @@ func (a *Addons) GetAgentAddonOptions() agent.AgentAddonOptions {
 			CSRApproveCheck:   a.csrApproveCheck,
 			PermissionConfig:  a.permissionConfig,
 		},
+		SupportedConfigGVRs: []schema.GroupVersionResource{AddOnDeploymentConfigGVR},
+		HostedModeEnabled:   true,
 	}
 }
 
@@ -189,6 +292,12 @@ func (a *Addons) permissionConfig(cluster *clusterv1.ManagedCluster, addon *addo
 	var err error
 	ctx := context.TODO()
 
+	// The roles below grant the verbs the agent needs, the admission policies restrict them to the MirrorPeers and
+	// secrets of the agent
+	if err := a.ensureAgentAdmissionPolicies(ctx); err != nil {
+		return err
+	}
+
 	clusterrole := rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "open-cluster-management:token-exchange:agent",
@@ -0,0 +1,315 @@
+package setup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestManifestsInstallModes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	a := Addons{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+		AgentImage: "quay.io/ocs-dev/odf-multicluster-orchestrator:latest",
+		AddonName:  TokenExchangeName,
+	}
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: utils.OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+			},
+		},
+	}
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: TokenExchangeName, Namespace: cluster.Name},
+		Spec:       addonapiv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "open-cluster-management-agent-addon"},
+	}
+
+	getAgent := func(objects []runtime.Object) (*appsv1.Deployment, int) {
+		var deployment *appsv1.Deployment
+		var hostingObjects int
+		for _, object := range objects {
+			accessor, err := meta.Accessor(object)
+			assert.NoError(t, err)
+			if accessor.GetAnnotations()[addonapiv1alpha1.HostedManifestLocationAnnotationKey] == addonapiv1alpha1.HostedManifestLocationHostingValue {
+				hostingObjects++
+			}
+			if d, ok := object.(*appsv1.Deployment); ok {
+				deployment = d
+			}
+		}
+		return deployment, hostingObjects
+	}
+
+	objects, err := a.Manifests(cluster, addon)
+	assert.NoError(t, err)
+	assert.Len(t, objects, len(tokenExchangeDeploymentFiles))
+	deployment, _ := getAgent(objects)
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--metrics-bind-address=:8443")
+	var serviceMonitor *unstructured.Unstructured
+	for _, object := range objects {
+		if u, ok := object.(*unstructured.Unstructured); ok {
+			serviceMonitor = u
+		}
+	}
+	if assert.NotNil(t, serviceMonitor) {
+		assert.Equal(t, "ServiceMonitor", serviceMonitor.GetKind())
+		assert.Equal(t, "open-cluster-management-agent-addon", serviceMonitor.GetNamespace())
+		endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+		assert.NoError(t, err)
+		if assert.Len(t, endpoints, 1) {
+			endpoint := endpoints[0].(map[string]any)
+			assert.Equal(t, "https", endpoint["scheme"])
+			assert.NotContains(t, endpoint, "bearerTokenFile")
+			credentials, _, err := unstructured.NestedString(endpoint, "authorization", "credentials", "name")
+			assert.NoError(t, err)
+			assert.Equal(t, "token-exchange-agent-metrics-reader-token", credentials)
+			serverName, _, err := unstructured.NestedString(endpoint, "tlsConfig", "serverName")
+			assert.NoError(t, err)
+			assert.Equal(t, "token-exchange-agent-metrics.open-cluster-management-agent-addon.svc", serverName)
+			_, insecure, err := unstructured.NestedBool(endpoint, "tlsConfig", "insecureSkipVerify")
+			assert.NoError(t, err)
+			assert.False(t, insecure)
+		}
+	}
+	for _, object := range objects {
+		if service, ok := object.(*corev1.Service); ok {
+			assert.Equal(t, "token-exchange-agent-metrics-cert", service.Annotations["service.beta.openshift.io/serving-cert-secret-name"])
+		}
+		if role, ok := object.(*rbacv1.ClusterRole); ok && role.Name == "token-exchange-agent-metrics-reader-cluster1" {
+			assert.Equal(t, []string{"/metrics"}, role.Rules[0].NonResourceURLs)
+		}
+	}
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--metrics-cert-dir=/var/run/metrics-cert")
+	assert.NotContains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--kubeconfig=/var/run/managed/kubeconfig")
+	assert.Len(t, deployment.Spec.Template.Spec.Volumes, 2)
+	assert.Equal(t, "token-exchange-agent-metrics-cert", deployment.Spec.Template.Spec.Volumes[1].Secret.SecretName)
+
+	addon.Annotations = map[string]string{addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting-cluster"}
+	objects, err = a.Manifests(cluster, addon)
+	assert.NoError(t, err)
+	assert.Len(t, objects, len(tokenExchangeDeploymentFiles)+len(tokenExchangeHostingFiles))
+	deployment, hostingObjects := getAgent(objects)
+	// The agent, its cleanup Job, the metrics Service and ServiceMonitor and the service account and RBAC of their
+	// scrapers with the service account, role and role binding for the lease and the RBAC of the metrics
+	// authentication run on the hosting cluster
+	assert.Equal(t, 8+len(tokenExchangeHostingFiles), hostingObjects)
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--kubeconfig=/var/run/managed/kubeconfig")
+	assert.Equal(t, TokenExchangeName+"-managed-kubeconfig", deployment.Spec.Template.Spec.Volumes[1].Secret.SecretName)
+	for _, object := range objects {
+		if role, ok := object.(*rbacv1.Role); ok && role.Annotations[addonapiv1alpha1.HostedManifestLocationAnnotationKey] == addonapiv1alpha1.HostedManifestLocationHostingValue {
+			assert.Equal(t, "coordination.k8s.io", role.Rules[0].APIGroups[0])
+		}
+		if binding, ok := object.(*rbacv1.ClusterRoleBinding); ok && binding.Annotations[addonapiv1alpha1.HostedManifestLocationAnnotationKey] == addonapiv1alpha1.HostedManifestLocationHostingValue {
+			assert.Contains(t, []string{"token-exchange-agent-metrics-auth-cluster1", "token-exchange-agent-metrics-reader-cluster1"}, binding.Name)
+			assert.Equal(t, binding.Name, binding.RoleRef.Name)
+			assert.Equal(t, "open-cluster-management-agent-addon", binding.Subjects[0].Namespace)
+		}
+	}
+}
+
+func TestManifestsCleanupJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	a := Addons{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+		AgentImage: "quay.io/ocs-dev/odf-multicluster-orchestrator:latest",
+		AddonName:  TokenExchangeName,
+	}
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: utils.OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+			},
+		},
+	}
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: TokenExchangeName, Namespace: cluster.Name},
+		Spec:       addonapiv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "open-cluster-management-agent-addon"},
+	}
+
+	getJob := func() *batchv1.Job {
+		objects, err := a.Manifests(cluster, addon)
+		assert.NoError(t, err)
+		for _, object := range objects {
+			if job, ok := object.(*batchv1.Job); ok {
+				return job
+			}
+		}
+		t.Fatal("expected a cleanup Job")
+		return nil
+	}
+
+	job := getJob()
+	assert.Contains(t, job.Annotations, addonapiv1alpha1.AddonPreDeleteHookAnnotationKey)
+	assert.Equal(t, []string{"addons", "cleanup"}, job.Spec.Template.Spec.Containers[0].Args)
+
+	addon.Annotations = map[string]string{RetainDRDataAnnotationKey: "true"}
+	job = getJob()
+	assert.Equal(t, []string{"addons", "cleanup", "--retain-dr-data"}, job.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestManifestsHostedInstallNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	a := Addons{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+		AgentImage: "quay.io/ocs-dev/odf-multicluster-orchestrator:latest",
+		AddonName:  TokenExchangeName,
+	}
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: utils.OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+			},
+		},
+	}
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TokenExchangeName,
+			Namespace: cluster.Name,
+			Annotations: map[string]string{
+				addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting-cluster",
+				ManagedInstallNamespaceAnnotationKey:             "open-cluster-management-agent-addon",
+			},
+		},
+		Spec: addonapiv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: HostingInstallNamespace("open-cluster-management-agent-addon", cluster.Name),
+		},
+	}
+
+	objects, err := a.Manifests(cluster, addon)
+	assert.NoError(t, err)
+
+	// The agent and its cleanup Job run on the hosting cluster, the namespace of their pod is not the namespace of
+	// the addon on the managed cluster
+	var deployment *appsv1.Deployment
+	var job *batchv1.Job
+	for _, object := range objects {
+		switch typed := object.(type) {
+		case *appsv1.Deployment:
+			deployment = typed
+		case *batchv1.Job:
+			job = typed
+		}
+	}
+	if assert.NotNil(t, deployment) {
+		assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--install-namespace=open-cluster-management-agent-addon")
+	}
+	if assert.NotNil(t, job) {
+		assert.Equal(t, []string{"addons", "cleanup", "--kubeconfig=/var/run/managed/kubeconfig", "--install-namespace=open-cluster-management-agent-addon"},
+			job.Spec.Template.Spec.Containers[0].Args)
+	}
+
+	// The service account of the TokenRequest and the role of the agent are in the install namespace on the managed
+	// cluster, the agent and its RBAC are in the namespace of the cluster on the hosting cluster
+	var hostingNamespace *corev1.Namespace
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		assert.NoError(t, err)
+		if accessor.GetAnnotations()[addonapiv1alpha1.HostedManifestLocationAnnotationKey] == addonapiv1alpha1.HostedManifestLocationHostingValue {
+			if namespace, ok := object.(*corev1.Namespace); ok {
+				hostingNamespace = namespace
+			} else if accessor.GetNamespace() != "" {
+				assert.Equal(t, "open-cluster-management-agent-addon-cluster1", accessor.GetNamespace(), "%T %s", object, accessor.GetName())
+			}
+			continue
+		}
+		if accessor.GetNamespace() != "" {
+			assert.Equal(t, "open-cluster-management-agent-addon", accessor.GetNamespace(), "%T %s", object, accessor.GetName())
+		}
+	}
+	if assert.NotNil(t, hostingNamespace) {
+		assert.Equal(t, "open-cluster-management-agent-addon-cluster1", hostingNamespace.Name)
+		assert.Equal(t, "true", hostingNamespace.Labels["addon.open-cluster-management.io/namespace"])
+	}
+}
+
+func TestManifestsHostedClustersShareHostingCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	a := Addons{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+		AgentImage: "quay.io/ocs-dev/odf-multicluster-orchestrator:latest",
+		AddonName:  TokenExchangeName,
+	}
+
+	// getHostingObjects renders the addon of a cluster hosted on hosting-cluster and returns the keys of the objects it
+	// deploys on the hosting cluster
+	getHostingObjects := func(clusterName string) []string {
+		cluster := &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+			Status: clusterv1.ManagedClusterStatus{
+				ClusterClaims: []clusterv1.ManagedClusterClaim{
+					{Name: utils.OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+				},
+			},
+		}
+		addon := &addonapiv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      TokenExchangeName,
+				Namespace: clusterName,
+				Annotations: map[string]string{
+					addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting-cluster",
+					ManagedInstallNamespaceAnnotationKey:             "openshift-storage",
+				},
+			},
+			Spec: addonapiv1alpha1.ManagedClusterAddOnSpec{
+				InstallNamespace: HostingInstallNamespace("openshift-storage", clusterName),
+			},
+		}
+		objects, err := a.Manifests(cluster, addon)
+		assert.NoError(t, err)
+
+		var keys []string
+		for _, object := range objects {
+			accessor, err := meta.Accessor(object)
+			assert.NoError(t, err)
+			if accessor.GetAnnotations()[addonapiv1alpha1.HostedManifestLocationAnnotationKey] != addonapiv1alpha1.HostedManifestLocationHostingValue {
+				continue
+			}
+			if _, ok := object.(*corev1.Namespace); ok {
+				keys = append(keys, fmt.Sprintf("Namespace/%s", accessor.GetName()))
+			} else {
+				keys = append(keys, fmt.Sprintf("%T/%s/%s", object, accessor.GetNamespace(), accessor.GetName()))
+			}
+		}
+		return keys
+	}
+
+	objects1 := getHostingObjects("cluster1")
+	objects2 := getHostingObjects("cluster2")
+	assert.Contains(t, objects1, "Namespace/openshift-storage-cluster1")
+	assert.Contains(t, objects2, "Namespace/openshift-storage-cluster2")
+	assert.Contains(t, objects1, "*v1.ClusterRole//token-exchange-agent-metrics-auth-cluster1")
+	for _, key := range objects1 {
+		assert.NotContains(t, objects2, key)
+	}
+}
+
+func TestHostingInstallNamespace(t *testing.T) {
+	assert.Equal(t, "openshift-storage-cluster1", HostingInstallNamespace("openshift-storage", "cluster1"))
+
+	// Long namespaces are truncated with a hash of the names, which keeps them unique
+	namespace1 := HostingInstallNamespace("openshift-storage", "hosted-cluster-with-a-very-long-name-number-1")
+	namespace2 := HostingInstallNamespace("openshift-storage", "hosted-cluster-with-a-very-long-name-number-2")
+	assert.Len(t, namespace1, 63)
+	assert.NotEqual(t, namespace1, namespace2)
+}
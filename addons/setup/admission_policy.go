@@ -0,0 +1,205 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// MirrorPeerAdmissionPolicyName is the ValidatingAdmissionPolicy limiting the agents to their own finalizer on the
+	// MirrorPeers of their cluster
+	MirrorPeerAdmissionPolicyName = "odf-multicluster-token-exchange-agent-mirrorpeers"
+	// SecretAdmissionPolicyName is the ValidatingAdmissionPolicy limiting the agents to the secrets labelled by the
+	// orchestrator
+	SecretAdmissionPolicyName = "odf-multicluster-token-exchange-agent-secrets"
+
+	agentGroupPrefix = "system:open-cluster-management:cluster:"
+)
+
+// getAgentAdmissionPolicies returns the ValidatingAdmissionPolicies narrowing what the agents can write on the hub.
+// RBAC can neither restrict an update to the finalizers of an object nor restrict access by label, so the agent
+// roles keep the verbs they need and the policies reject any other change made by an agent.
+func (a *Addons) getAgentAdmissionPolicies() []admissionregistrationv1.ValidatingAdmissionPolicy {
+	// The agent of a cluster authenticates with the group system:open-cluster-management:cluster:<cluster>:addon:<addon>
+	agentGroupSuffix := ":addon:" + a.AddonName
+	isAgentGroup := fmt.Sprintf("g.startsWith('%s') && g.endsWith('%s')", agentGroupPrefix, agentGroupSuffix)
+	matchConditions := []admissionregistrationv1.MatchCondition{
+		{
+			Name:       "token-exchange-agent",
+			Expression: fmt.Sprintf("request.userInfo.groups.exists(g, %s)", isAgentGroup),
+		},
+	}
+	clusterName := admissionregistrationv1.Variable{
+		Name: "clusterName",
+		Expression: fmt.Sprintf("request.userInfo.groups.filter(g, %s).map(g, g.substring(%d, size(g) - %d))[0]",
+			isAgentGroup, len(agentGroupPrefix), len(agentGroupSuffix)),
+	}
+	newObject := func(name string, spec admissionregistrationv1.ValidatingAdmissionPolicySpec) admissionregistrationv1.ValidatingAdmissionPolicy {
+		spec.FailurePolicy = ptr.To(admissionregistrationv1.Fail)
+		spec.MatchConditions = matchConditions
+		return admissionregistrationv1.ValidatingAdmissionPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{utils.CreatedByLabelKey: utils.CreatorMulticlusterOrchestrator},
+			},
+			Spec: spec,
+		}
+	}
+	forbidden := ptr.To(metav1.StatusReasonForbidden)
+	isAgentFinalizer := fmt.Sprintf("(variables.agentFinalizerTruncated ? "+
+		"f.startsWith(variables.truncatedAgentFinalizerPrefix) && f.endsWith('.%s') && size(f) == 63 : "+
+		"f == variables.agentFinalizer)", MirrorPeerFinalizerSuffix)
+
+	mirrorPeerPolicy := newObject(MirrorPeerAdmissionPolicyName, admissionregistrationv1.ValidatingAdmissionPolicySpec{
+		MatchConstraints: &admissionregistrationv1.MatchResources{
+			ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+				RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Update},
+					Rule: admissionregistrationv1.Rule{
+						APIGroups:   []string{"multicluster.odf.openshift.io"},
+						APIVersions: []string{"*"},
+						Resources:   []string{"mirrorpeers"},
+					},
+				},
+			}},
+		},
+		Variables: []admissionregistrationv1.Variable{
+			clusterName,
+			{
+				// Mirrors GetAgentFinalizer of the agent. The hash suffixing the name of a cluster too long for a
+				// finalizer can not be computed in CEL, its finalizer is matched by its truncated name, length and suffix.
+				Name:       "agentFinalizerTruncated",
+				Expression: fmt.Sprintf("size(variables.clusterName) + %d > 63", len(MirrorPeerFinalizerSuffix)+1),
+			},
+			{
+				Name:       "agentFinalizer",
+				Expression: fmt.Sprintf("variables.clusterName + '.%s'", MirrorPeerFinalizerSuffix),
+			},
+			{
+				Name: "truncatedAgentFinalizerPrefix",
+				Expression: fmt.Sprintf("variables.agentFinalizerTruncated ? variables.clusterName.substring(0, %d) + '-' : ''",
+					TruncatedClusterNameLength),
+			},
+			{
+				// Mirrors GetLegacyAgentFinalizer of the agent, which only removes it
+				Name: "legacyAgentFinalizer",
+				Expression: fmt.Sprintf("variables.agentFinalizerTruncated ? variables.clusterName.substring(0, 10) + '.%s' : ''",
+					MirrorPeerFinalizerSuffix),
+			},
+			{
+				// The agents of the providers of StorageClient peers manage the MirrorPeer for their StorageClients
+				Name: "providerClusters",
+				Expression: fmt.Sprintf("'%[1]s' in oldObject.metadata.?annotations.orValue({}) ? oldObject.metadata.annotations['%[1]s'].split(',') : []",
+					utils.ProviderClustersAnnotationKey),
+			},
+			{
+				Name:       "oldFinalizers",
+				Expression: "oldObject.metadata.?finalizers.orValue([])",
+			},
+			{
+				Name:       "newFinalizers",
+				Expression: "object.metadata.?finalizers.orValue([])",
+			},
+		},
+		Validations: []admissionregistrationv1.Validation{
+			{
+				Expression: "oldObject.spec.items.exists(i, i.clusterName == variables.clusterName) || variables.clusterName in variables.providerClusters",
+				Message:    "token exchange agents can only update the MirrorPeers of their cluster",
+				Reason:     forbidden,
+			},
+			{
+				Expression: "object.spec == oldObject.spec && " +
+					"object.metadata.?labels.orValue({}) == oldObject.metadata.?labels.orValue({}) && " +
+					"object.metadata.?annotations.orValue({}) == oldObject.metadata.?annotations.orValue({}) && " +
+					"object.metadata.?ownerReferences.orValue([]) == oldObject.metadata.?ownerReferences.orValue([])",
+				Message: "token exchange agents can only update the finalizers of MirrorPeers",
+				Reason:  forbidden,
+			},
+			{
+				Expression: fmt.Sprintf("variables.newFinalizers.all(f, f in variables.oldFinalizers || %[1]s) && "+
+					"variables.oldFinalizers.all(f, f in variables.newFinalizers || %[1]s || f == variables.legacyAgentFinalizer)",
+					isAgentFinalizer),
+				Message: "token exchange agents can only add or remove their own finalizer",
+				Reason:  forbidden,
+			},
+		},
+	})
+
+	secretPolicy := newObject(SecretAdmissionPolicyName, admissionregistrationv1.ValidatingAdmissionPolicySpec{
+		MatchConstraints: &admissionregistrationv1.MatchResources{
+			ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+				RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+					Operations: []admissionregistrationv1.OperationType{
+						admissionregistrationv1.Create,
+						admissionregistrationv1.Update,
+						admissionregistrationv1.Delete,
+					},
+					Rule: admissionregistrationv1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   []string{"secrets"},
+					},
+				},
+			}},
+		},
+		Validations: []admissionregistrationv1.Validation{
+			{
+				Expression: fmt.Sprintf("request.operation == 'DELETE' || '%s' in object.metadata.?labels.orValue({})", utils.SecretLabelTypeKey),
+				Message:    fmt.Sprintf("token exchange agents can only write secrets labelled with %s", utils.SecretLabelTypeKey),
+				Reason:     forbidden,
+			},
+			{
+				Expression: fmt.Sprintf("request.operation == 'CREATE' || '%s' in oldObject.metadata.?labels.orValue({})", utils.SecretLabelTypeKey),
+				Message:    fmt.Sprintf("token exchange agents can only modify secrets labelled with %s", utils.SecretLabelTypeKey),
+				Reason:     forbidden,
+			},
+		},
+	})
+
+	return []admissionregistrationv1.ValidatingAdmissionPolicy{mirrorPeerPolicy, secretPolicy}
+}
+
+// ensureAgentAdmissionPolicies creates or updates the admission policies of the agents and their bindings. Hubs
+// older than Kubernetes 1.30 do not serve ValidatingAdmissionPolicies, the agents are only restricted by RBAC there.
+func (a *Addons) ensureAgentAdmissionPolicies(ctx context.Context) error {
+	policyKind := admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingAdmissionPolicy")
+	if _, err := a.Client.RESTMapper().RESTMapping(policyKind.GroupKind(), policyKind.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			a.Logger.Warn("ValidatingAdmissionPolicies are not served by the hub, the token exchange agents are only restricted by RBAC", "Kind", policyKind.String())
+			return nil
+		}
+		return fmt.Errorf("failed to discover %s: %w", policyKind, err)
+	}
+	for _, desired := range a.getAgentAdmissionPolicies() {
+		policy := admissionregistrationv1.ValidatingAdmissionPolicy{ObjectMeta: metav1.ObjectMeta{Name: desired.Name}}
+		_, err := controllerutil.CreateOrUpdate(ctx, a.Client, &policy, func() error {
+			policy.Labels = desired.Labels
+			policy.Spec = desired.Spec
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create or update ValidatingAdmissionPolicy %q: %w", desired.Name, err)
+		}
+
+		binding := admissionregistrationv1.ValidatingAdmissionPolicyBinding{ObjectMeta: metav1.ObjectMeta{Name: desired.Name}}
+		_, err = controllerutil.CreateOrUpdate(ctx, a.Client, &binding, func() error {
+			binding.Labels = desired.Labels
+			binding.Spec = admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+				PolicyName:        desired.Name,
+				ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create or update ValidatingAdmissionPolicyBinding %q: %w", desired.Name, err)
+		}
+	}
+	return nil
+}
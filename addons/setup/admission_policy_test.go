@@ -0,0 +1,306 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apiserver/pkg/cel/environment"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureAgentAdmissionPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, admissionregistrationv1.AddToScheme(scheme))
+	a := Addons{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).Build(),
+		AddonName: TokenExchangeName,
+	}
+
+	ctx := context.TODO()
+	// A second run updates the existing objects
+	assert.NoError(t, a.ensureAgentAdmissionPolicies(ctx))
+	assert.NoError(t, a.ensureAgentAdmissionPolicies(ctx))
+
+	for _, name := range []string{MirrorPeerAdmissionPolicyName, SecretAdmissionPolicyName} {
+		var policy admissionregistrationv1.ValidatingAdmissionPolicy
+		assert.NoError(t, a.Client.Get(ctx, types.NamespacedName{Name: name}, &policy))
+		assert.Equal(t, admissionregistrationv1.Fail, *policy.Spec.FailurePolicy)
+		assert.Len(t, policy.Spec.MatchConditions, 1)
+		assert.Contains(t, policy.Spec.MatchConditions[0].Expression, ":addon:"+TokenExchangeName)
+		assert.NotEmpty(t, policy.Spec.Validations)
+
+		var binding admissionregistrationv1.ValidatingAdmissionPolicyBinding
+		assert.NoError(t, a.Client.Get(ctx, types.NamespacedName{Name: name}, &binding))
+		assert.Equal(t, name, binding.Spec.PolicyName)
+		assert.Equal(t, []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny}, binding.Spec.ValidationActions)
+	}
+}
+
+func TestEnsureAgentAdmissionPoliciesUnsupported(t *testing.T) {
+	// The hub does not serve ValidatingAdmissionPolicies
+	a := Addons{
+		Client:    fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		AddonName: TokenExchangeName,
+		Logger:    utils.GetLogger(utils.GetZapLogger(true)),
+	}
+	assert.NoError(t, a.ensureAgentAdmissionPolicies(context.TODO()))
+}
+
+// evaluatePolicy evaluates the match conditions, variables and validations of a policy like the API server does. It
+// returns whether the policy matches the request and the messages of the validations denying it.
+func evaluatePolicy(t *testing.T, policy admissionregistrationv1.ValidatingAdmissionPolicy, request, object, oldObject map[string]any) (bool, []string) {
+	t.Helper()
+	// ValidatingAdmissionPolicies are served since Kubernetes 1.30
+	env, err := environment.MustBaseEnvSet(version.MajorMinor(1, 30), true).StoredExpressionsEnv().Extend(
+		cel.Variable("request", cel.DynType),
+		cel.Variable("object", cel.DynType),
+		cel.Variable("oldObject", cel.DynType),
+		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if !assert.NoError(t, err) {
+		return false, nil
+	}
+	variables := map[string]any{}
+	activation := map[string]any{"request": request, "object": object, "oldObject": oldObject, "variables": variables}
+	eval := func(expression string) any {
+		ast, issues := env.Compile(expression)
+		if !assert.NoError(t, issues.Err(), expression) {
+			return nil
+		}
+		program, err := env.Program(ast)
+		if !assert.NoError(t, err, expression) {
+			return nil
+		}
+		out, _, err := program.Eval(activation)
+		if !assert.NoError(t, err, expression) {
+			return nil
+		}
+		return out.Value()
+	}
+
+	for _, condition := range policy.Spec.MatchConditions {
+		if eval(condition.Expression) != true {
+			return false, nil
+		}
+	}
+	// Variables can refer to the variables declared before them
+	for _, variable := range policy.Spec.Variables {
+		variables[variable.Name] = eval(variable.Expression)
+	}
+	var denied []string
+	for _, validation := range policy.Spec.Validations {
+		if eval(validation.Expression) != true {
+			denied = append(denied, validation.Message)
+		}
+	}
+	return true, denied
+}
+
+func TestAgentAdmissionPolicyExpressions(t *testing.T) {
+	a := Addons{AddonName: TokenExchangeName}
+	policies := map[string]admissionregistrationv1.ValidatingAdmissionPolicy{}
+	for _, policy := range a.getAgentAdmissionPolicies() {
+		policies[policy.Name] = policy
+	}
+
+	agentRequest := func(clusterName, operation string) map[string]any {
+		return map[string]any{
+			"operation": operation,
+			"userInfo": map[string]any{
+				"groups": []any{
+					"system:authenticated",
+					"system:open-cluster-management:cluster:" + clusterName + ":addon:" + TokenExchangeName,
+					"system:open-cluster-management:addon:" + TokenExchangeName,
+				},
+			},
+		}
+	}
+	longClusterName := "cluster-with-a-name-long-enough-to-truncate-the-finalizer"
+	// Mirrors GetAgentFinalizer and GetLegacyAgentFinalizer of the agent
+	longFinalizer := fmt.Sprintf("%s-%08x.%s", longClusterName[:TruncatedClusterNameLength], utils.FnvHash(longClusterName), MirrorPeerFinalizerSuffix)
+	legacyLongFinalizer := longClusterName[:10] + "." + MirrorPeerFinalizerSuffix
+	// Another cluster sharing the first 10 characters of the long name
+	otherLongFinalizer := fmt.Sprintf("%s-%08x.%s", "cluster-wi-another", utils.FnvHash("cluster-wi-another-cluster"), MirrorPeerFinalizerSuffix)
+	mirrorPeer := func(finalizers ...string) map[string]any {
+		metadata := map[string]any{"name": "mirrorpeer", "labels": map[string]any{"app": "odf"}}
+		if len(finalizers) > 0 {
+			var values []any
+			for _, finalizer := range finalizers {
+				values = append(values, finalizer)
+			}
+			metadata["finalizers"] = values
+		}
+		return map[string]any{
+			"metadata": metadata,
+			"spec": map[string]any{
+				"items": []any{
+					map[string]any{"clusterName": "cluster1"},
+					map[string]any{"clusterName": longClusterName},
+				},
+			},
+		}
+	}
+	finalizer := "cluster1." + MirrorPeerFinalizerSuffix
+	otherFinalizer := "cluster2." + MirrorPeerFinalizerSuffix
+	withSpec := func(object map[string]any, spec map[string]any) map[string]any {
+		object["spec"] = spec
+		return object
+	}
+	// The MirrorPeer of two StorageClients whose provider runs on provider1
+	clientMirrorPeer := func(finalizers ...string) map[string]any {
+		object := withSpec(mirrorPeer(finalizers...), map[string]any{
+			"items": []any{
+				map[string]any{"clusterName": "client1"},
+				map[string]any{"clusterName": "client2"},
+			},
+		})
+		object["metadata"].(map[string]any)["annotations"] = map[string]any{utils.ProviderClustersAnnotationKey: "provider0,provider1"}
+		return object
+	}
+	providerFinalizer := "provider1." + MirrorPeerFinalizerSuffix
+
+	mirrorPeerTests := []struct {
+		name      string
+		request   map[string]any
+		object    map[string]any
+		oldObject map[string]any
+		matches   bool
+		denied    int
+	}{
+		{
+			name:    "requests of other users are not matched",
+			request: map[string]any{"operation": "UPDATE", "userInfo": map[string]any{"groups": []any{"system:masters"}}},
+			object:  mirrorPeer(), oldObject: mirrorPeer(finalizer),
+		},
+		{
+			name:    "the agent adds its finalizer",
+			request: agentRequest("cluster1", "UPDATE"),
+			object:  mirrorPeer(otherFinalizer, finalizer), oldObject: mirrorPeer(otherFinalizer),
+			matches: true,
+		},
+		{
+			name:    "the agent removes its finalizer",
+			request: agentRequest("cluster1", "UPDATE"),
+			object:  mirrorPeer(), oldObject: mirrorPeer(finalizer),
+			matches: true,
+		},
+		{
+			name:    "the agent of a cluster with a long name adds its truncated finalizer",
+			request: agentRequest(longClusterName, "UPDATE"),
+			object:  mirrorPeer(longFinalizer), oldObject: mirrorPeer(),
+			matches: true,
+		},
+		{
+			name:    "the agent of a cluster with a long name replaces its legacy finalizer",
+			request: agentRequest(longClusterName, "UPDATE"),
+			object:  mirrorPeer(longFinalizer), oldObject: mirrorPeer(legacyLongFinalizer),
+			matches: true,
+		},
+		{
+			name:    "the agent of a cluster with a long name adds its legacy finalizer",
+			request: agentRequest(longClusterName, "UPDATE"),
+			object:  mirrorPeer(legacyLongFinalizer), oldObject: mirrorPeer(),
+			matches: true, denied: 1,
+		},
+		{
+			name:    "the agent of a cluster with a long name removes the finalizer of a cluster sharing its legacy prefix",
+			request: agentRequest(longClusterName, "UPDATE"),
+			object:  mirrorPeer(longFinalizer), oldObject: mirrorPeer(longFinalizer, otherLongFinalizer),
+			matches: true, denied: 1,
+		},
+		{
+			name:    "the agent removes the finalizer of another agent",
+			request: agentRequest("cluster1", "UPDATE"),
+			object:  mirrorPeer(finalizer), oldObject: mirrorPeer(finalizer, otherFinalizer),
+			matches: true, denied: 1,
+		},
+		{
+			name:      "the agent updates the spec",
+			request:   agentRequest("cluster1", "UPDATE"),
+			object:    withSpec(mirrorPeer(), map[string]any{"items": []any{map[string]any{"clusterName": "cluster1"}}}),
+			oldObject: mirrorPeer(),
+			matches:   true, denied: 1,
+		},
+		{
+			name:    "the agent of a provider adds its finalizer to the MirrorPeer of its StorageClients",
+			request: agentRequest("provider1", "UPDATE"),
+			object:  clientMirrorPeer(providerFinalizer), oldObject: clientMirrorPeer(),
+			matches: true,
+		},
+		{
+			name:    "the agent of a provider removes its finalizer from the MirrorPeer of its StorageClients",
+			request: agentRequest("provider1", "UPDATE"),
+			object:  clientMirrorPeer(), oldObject: clientMirrorPeer(providerFinalizer),
+			matches: true,
+		},
+		{
+			name:    "the agent of another provider adds its finalizer to the MirrorPeer of StorageClients",
+			request: agentRequest("provider2", "UPDATE"),
+			object:  clientMirrorPeer("provider2." + MirrorPeerFinalizerSuffix), oldObject: clientMirrorPeer(),
+			matches: true, denied: 1,
+		},
+		{
+			name:    "the agent of a provider removes the provider clusters annotation",
+			request: agentRequest("provider1", "UPDATE"),
+			object: func() map[string]any {
+				object := clientMirrorPeer()
+				delete(object["metadata"].(map[string]any), "annotations")
+				return object
+			}(),
+			oldObject: clientMirrorPeer(),
+			matches:   true, denied: 1,
+		},
+		{
+			name:    "the agent of another cluster adds its finalizer",
+			request: agentRequest("cluster2", "UPDATE"),
+			object:  mirrorPeer(otherFinalizer), oldObject: mirrorPeer(),
+			matches: true, denied: 1,
+		},
+	}
+	for _, tt := range mirrorPeerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, denied := evaluatePolicy(t, policies[MirrorPeerAdmissionPolicyName], tt.request, tt.object, tt.oldObject)
+			assert.Equal(t, tt.matches, matches)
+			assert.Len(t, denied, tt.denied, denied)
+		})
+	}
+
+	secret := func(labelled bool) map[string]any {
+		metadata := map[string]any{"name": "secret"}
+		if labelled {
+			metadata["labels"] = map[string]any{utils.SecretLabelTypeKey: "GREEN"}
+		}
+		return map[string]any{"metadata": metadata}
+	}
+	secretTests := []struct {
+		name      string
+		operation string
+		object    map[string]any
+		oldObject map[string]any
+		denied    int
+	}{
+		{name: "the agent creates a labelled secret", operation: "CREATE", object: secret(true)},
+		{name: "the agent creates an unlabelled secret", operation: "CREATE", object: secret(false), denied: 1},
+		{name: "the agent updates a labelled secret", operation: "UPDATE", object: secret(true), oldObject: secret(true)},
+		{name: "the agent labels an unlabelled secret", operation: "UPDATE", object: secret(true), oldObject: secret(false), denied: 1},
+		{name: "the agent removes the label of a secret", operation: "UPDATE", object: secret(false), oldObject: secret(true), denied: 1},
+		{name: "the agent deletes a labelled secret", operation: "DELETE", oldObject: secret(true)},
+		{name: "the agent deletes an unlabelled secret", operation: "DELETE", oldObject: secret(false), denied: 1},
+	}
+	for _, tt := range secretTests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, denied := evaluatePolicy(t, policies[SecretAdmissionPolicyName], agentRequest("cluster1", tt.operation), tt.object, tt.oldObject)
+			assert.True(t, matches)
+			assert.Len(t, denied, tt.denied, denied)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+const (
+	// AgentUpgradedCondition reports on the ManagedClusterAddOn the progress of the upgrade migrations of the agent
+	AgentUpgradedCondition = "AgentUpgraded"
+
+	// Reasons of the AgentUpgraded condition of the ManagedClusterAddOn
+	ReasonAgentUpgradeCompleted  = "UpgradeCompleted"
+	ReasonAgentUpgradeInProgress = "UpgradeInProgress"
+	ReasonAgentUpgradeFailed     = "UpgradeFailed"
+
+	// AgentVersionClusterClaimName is the ClusterClaim published by the agent with its version once the migrations
+	// of its upgrade completed
+	AgentVersionClusterClaimName = "agentversion.odf.openshift.io"
+)
+
+// AgentVersionMessage is the message of the AgentUpgraded condition of an agent running the version
+func AgentVersionMessage(version string) string {
+	return "The token exchange agent runs version " + version
+}
+
+// GetAgentVersion returns the version published by the agent of the ManagedCluster. The version is only published
+// once the agent completed the migrations of its upgrade, agents which did not complete an upgrade yet keep
+// publishing their previous version.
+func GetAgentVersion(mc *clusterv1.ManagedCluster) (string, bool) {
+	version, ok := utils.GetClusterClaimValue(mc, AgentVersionClusterClaimName)
+	if !ok || version == "" {
+		return "", false
+	}
+	return version, true
+}
@@ -11,16 +11,23 @@ import (
 
 const (
 	TokenExchangeName = "tokenexchange"
+	// MirrorPeerFinalizerSuffix is the suffix of the finalizers added to MirrorPeers by the agents
+	MirrorPeerFinalizerSuffix = "spoke.multicluster.odf.openshift.io"
+	// TruncatedClusterNameLength is the length of the prefix of a cluster name kept in the finalizer of its agent when
+	// the full name does not fit in the 63 characters of a finalizer. The prefix is followed by a dash and the 8
+	// hexadecimal characters of the FNV hash of the full name.
+	TruncatedClusterNameLength = 63 - len(MirrorPeerFinalizerSuffix) - len(".") - len("-") - 8
 )
 
 // ServeHealthProbes starts a server to check healthz and readyz probes
-func ServeHealthProbes(stop <-chan struct{}, healthProbeBindAddress string, configCheck healthz.Checker, logger *slog.Logger) {
+func ServeHealthProbes(stop <-chan struct{}, healthProbeBindAddress string, configCheck healthz.Checker, managersCheck healthz.Checker, logger *slog.Logger) {
 	healthzHandler := &healthz.Handler{Checks: map[string]healthz.Checker{
 		"healthz-ping": healthz.Ping,
 		"configz-ping": configCheck,
 	}}
 	readyzHandler := &healthz.Handler{Checks: map[string]healthz.Checker{
-		"readyz-ping": healthz.Ping,
+		"readyz-ping":    healthz.Ping,
+		"managers-ready": managersCheck,
 	}}
 
 	mux := http.NewServeMux()
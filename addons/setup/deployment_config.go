@@ -0,0 +1,226 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Customized variables of the AddOnDeploymentConfig supported by the token exchange agent
+const (
+	ImagePullPolicyVariable = "ImagePullPolicy"
+	CPURequestVariable      = "CPURequest"
+	CPULimitVariable        = "CPULimit"
+	MemoryRequestVariable   = "MemoryRequest"
+	MemoryLimitVariable     = "MemoryLimit"
+	// ReplicasVariable is the number of agent replicas, one of them is elected to run the agent and the others stand by
+	ReplicasVariable = "Replicas"
+	// UXBackendProxyAddressVariable is the host:port of the ux-backend-proxy of the managed cluster. It is required in
+	// hosted mode, where the agent cannot resolve the services of the managed cluster.
+	UXBackendProxyAddressVariable = "UXBackendProxyAddress"
+)
+
+// AddOnDeploymentConfigGVR is the configuration type of the addon customizing the agent Deployment
+var AddOnDeploymentConfigGVR = schema.GroupVersionResource{
+	Group:    addonapiv1alpha1.GroupVersion.Group,
+	Version:  addonapiv1alpha1.GroupVersion.Version,
+	Resource: "addondeploymentconfigs",
+}
+
+// DeploymentConfig holds the customizations of the agent Deployment from the AddOnDeploymentConfigs of the
+// ManagedClusterAddOn
+type DeploymentConfig struct {
+	NodeSelector    map[string]string
+	Tolerations     []corev1.Toleration
+	ImagePullPolicy corev1.PullPolicy
+	Resources       corev1.ResourceRequirements
+	ProxyConfig     addonapiv1alpha1.ProxyConfig
+	Registries      []addonapiv1alpha1.ImageMirror
+	Replicas        *int32
+
+	UXBackendProxyAddress string
+}
+
+// getDeploymentConfig merges the AddOnDeploymentConfigs referenced by the ManagedClusterAddOn. The references are
+// resolved by the addon manager from the ManagedClusterAddOn, falling back to the default config of the
+// ClusterManagementAddOn. Later references override earlier ones.
+func (a *Addons) getDeploymentConfig(ctx context.Context, addon *addonapiv1alpha1.ManagedClusterAddOn) (DeploymentConfig, error) {
+	var config DeploymentConfig
+	for _, configReference := range addon.Status.ConfigReferences {
+		if configReference.Group != AddOnDeploymentConfigGVR.Group ||
+			configReference.Resource != AddOnDeploymentConfigGVR.Resource {
+			continue
+		}
+		var addOnDeploymentConfig addonapiv1alpha1.AddOnDeploymentConfig
+		namespacedName := types.NamespacedName{Name: configReference.Name, Namespace: configReference.Namespace}
+		if err := a.Client.Get(ctx, namespacedName, &addOnDeploymentConfig); err != nil {
+			return config, fmt.Errorf("failed to get AddOnDeploymentConfig %q: %w", namespacedName, err)
+		}
+		if err := config.merge(addOnDeploymentConfig.Spec); err != nil {
+			return config, fmt.Errorf("AddOnDeploymentConfig %q is invalid: %w", namespacedName, err)
+		}
+	}
+	return config, nil
+}
+
+func (c *DeploymentConfig) merge(spec addonapiv1alpha1.AddOnDeploymentConfigSpec) error {
+	if spec.NodePlacement != nil {
+		c.NodeSelector = spec.NodePlacement.NodeSelector
+		c.Tolerations = spec.NodePlacement.Tolerations
+	}
+	if spec.ProxyConfig.HTTPProxy != "" || spec.ProxyConfig.HTTPSProxy != "" || spec.ProxyConfig.NoProxy != "" {
+		c.ProxyConfig = spec.ProxyConfig
+	}
+	if len(spec.Registries) > 0 {
+		c.Registries = spec.Registries
+	}
+	for _, variable := range spec.CustomizedVariables {
+		switch variable.Name {
+		case ImagePullPolicyVariable:
+			policy := corev1.PullPolicy(variable.Value)
+			if !slices.Contains([]corev1.PullPolicy{corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever}, policy) {
+				return fmt.Errorf("validation: unsupported image pull policy %q", variable.Value)
+			}
+			c.ImagePullPolicy = policy
+		case CPURequestVariable, CPULimitVariable, MemoryRequestVariable, MemoryLimitVariable:
+			quantity, err := resource.ParseQuantity(variable.Value)
+			if err != nil {
+				return fmt.Errorf("validation: invalid quantity %q for %q: %w", variable.Value, variable.Name, err)
+			}
+			c.setResource(variable.Name, quantity)
+		case ReplicasVariable:
+			replicas, err := strconv.ParseInt(variable.Value, 10, 32)
+			if err != nil || replicas < 1 {
+				return fmt.Errorf("validation: %q must be a positive integer, got %q", variable.Name, variable.Value)
+			}
+			c.Replicas = ptr.To(int32(replicas))
+		case UXBackendProxyAddressVariable:
+			if _, _, err := net.SplitHostPort(variable.Value); err != nil {
+				return fmt.Errorf("validation: invalid ux-backend-proxy address %q: %w", variable.Value, err)
+			}
+			c.UXBackendProxyAddress = variable.Value
+		}
+	}
+	return nil
+}
+
+func (c *DeploymentConfig) setResource(name string, quantity resource.Quantity) {
+	resourceList := &c.Resources.Requests
+	if name == CPULimitVariable || name == MemoryLimitVariable {
+		resourceList = &c.Resources.Limits
+	}
+	if *resourceList == nil {
+		*resourceList = corev1.ResourceList{}
+	}
+	resourceName := corev1.ResourceCPU
+	if name == MemoryRequestVariable || name == MemoryLimitVariable {
+		resourceName = corev1.ResourceMemory
+	}
+	(*resourceList)[resourceName] = quantity
+}
+
+// image returns the image pulled from the first matching mirror of the registries
+func (c *DeploymentConfig) image(image string) string {
+	for _, registry := range c.Registries {
+		if registry.Source != "" && strings.HasPrefix(image, registry.Source) {
+			return registry.Mirror + strings.TrimPrefix(image, registry.Source)
+		}
+	}
+	return image
+}
+
+// apply customizes the agent Deployment. Any change of the pod template rolls out the agent.
+func (c *DeploymentConfig) apply(deployment *appsv1.Deployment) {
+	if c.Replicas != nil {
+		deployment.Spec.Replicas = c.Replicas
+	}
+	podSpec := &deployment.Spec.Template.Spec
+	c.applyPodSpec(podSpec)
+	if c.UXBackendProxyAddress != "" {
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].Args = append(podSpec.Containers[i].Args, "--ux-backend-proxy-address="+c.UXBackendProxyAddress)
+		}
+	}
+}
+
+// applyPodSpec customizes the placement, images, resources and proxy of the pods of the addon
+func (c *DeploymentConfig) applyPodSpec(podSpec *corev1.PodSpec) {
+	podSpec.NodeSelector = c.NodeSelector
+	podSpec.Tolerations = c.Tolerations
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		container.Image = c.image(container.Image)
+		if c.ImagePullPolicy != "" {
+			container.ImagePullPolicy = c.ImagePullPolicy
+		}
+		// Only the resources set by the config override the ones of the manifest
+		for resourceName, quantity := range c.Resources.Requests {
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = corev1.ResourceList{}
+			}
+			container.Resources.Requests[resourceName] = quantity
+		}
+		for resourceName, quantity := range c.Resources.Limits {
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = corev1.ResourceList{}
+			}
+			container.Resources.Limits[resourceName] = quantity
+		}
+		for _, env := range []corev1.EnvVar{
+			{Name: "HTTP_PROXY", Value: c.ProxyConfig.HTTPProxy},
+			{Name: "HTTPS_PROXY", Value: c.ProxyConfig.HTTPSProxy},
+			{Name: "NO_PROXY", Value: c.ProxyConfig.NoProxy},
+		} {
+			if env.Value != "" {
+				container.Env = append(container.Env, env)
+			}
+		}
+	}
+}
+
+// EnsureClusterManagementAddOn registers AddOnDeploymentConfigs as a supported configuration of the addon. A default
+// config set on the ClusterManagementAddOn applies to all clusters without their own config.
+func (a *Addons) EnsureClusterManagementAddOn(ctx context.Context) error {
+	clusterManagementAddOn := addonapiv1alpha1.ClusterManagementAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: a.AddonName,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, a.Client, &clusterManagementAddOn, func() error {
+		if clusterManagementAddOn.Spec.AddOnMeta.DisplayName == "" {
+			clusterManagementAddOn.Spec.AddOnMeta = addonapiv1alpha1.AddOnMeta{
+				DisplayName: "Token Exchange",
+				Description: "Exchanges the secrets required to set up disaster recovery between ODF clusters",
+			}
+		}
+		supported := slices.ContainsFunc(clusterManagementAddOn.Spec.SupportedConfigs, func(config addonapiv1alpha1.ConfigMeta) bool {
+			return config.Group == AddOnDeploymentConfigGVR.Group && config.Resource == AddOnDeploymentConfigGVR.Resource
+		})
+		if !supported {
+			clusterManagementAddOn.Spec.SupportedConfigs = append(clusterManagementAddOn.Spec.SupportedConfigs, addonapiv1alpha1.ConfigMeta{
+				ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+					Group:    AddOnDeploymentConfigGVR.Group,
+					Resource: AddOnDeploymentConfigGVR.Resource,
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update ClusterManagementAddOn %q: %w", a.AddonName, err)
+	}
+	return nil
+}
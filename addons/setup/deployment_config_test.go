@@ -0,0 +1,116 @@
+package setup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetDeploymentConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+
+	globalConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "global", Namespace: "openshift-operators"},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			NodePlacement: &addonapiv1alpha1.NodePlacement{
+				NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+				Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}},
+			},
+			ProxyConfig: addonapiv1alpha1.ProxyConfig{HTTPSProxy: "http://proxy:3128", NoProxy: ".cluster.local"},
+			Registries:  []addonapiv1alpha1.ImageMirror{{Source: "quay.io/ocs-dev", Mirror: "mirror.example.com/ocs-dev"}},
+		},
+	}
+	clusterConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster1"},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{Name: ImagePullPolicyVariable, Value: string(corev1.PullIfNotPresent)},
+				{Name: MemoryLimitVariable, Value: "512Mi"},
+				{Name: CPURequestVariable, Value: "100m"},
+				{Name: UXBackendProxyAddressVariable, Value: "ux-backend-proxy.apps.cluster1.example.com:443"},
+				{Name: ReplicasVariable, Value: "3"},
+			},
+		},
+	}
+	invalidConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "cluster1"},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{{Name: ImagePullPolicyVariable, Value: "Sometimes"}},
+		},
+	}
+	a := Addons{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(globalConfig, clusterConfig, invalidConfig).Build(),
+		AddonName: TokenExchangeName,
+	}
+
+	configReference := func(config *addonapiv1alpha1.AddOnDeploymentConfig) addonapiv1alpha1.ConfigReference {
+		return addonapiv1alpha1.ConfigReference{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{Group: AddOnDeploymentConfigGVR.Group, Resource: AddOnDeploymentConfigGVR.Resource},
+			ConfigReferent:      addonapiv1alpha1.ConfigReferent{Name: config.Name, Namespace: config.Namespace},
+		}
+	}
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{
+		Status: addonapiv1alpha1.ManagedClusterAddOnStatus{
+			ConfigReferences: []addonapiv1alpha1.ConfigReference{configReference(globalConfig), configReference(clusterConfig)},
+		},
+	}
+
+	config, err := a.getDeploymentConfig(context.TODO(), addon)
+	assert.NoError(t, err)
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:            "token-exchange-agent",
+		Image:           "quay.io/ocs-dev/odf-multicluster-orchestrator:latest",
+		ImagePullPolicy: corev1.PullAlways,
+		Env:             []corev1.EnvVar{{Name: "POD_NAMESPACE"}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	}}
+	config.apply(deployment)
+
+	assert.Equal(t, ptr.To(int32(3)), deployment.Spec.Replicas)
+	podSpec := deployment.Spec.Template.Spec
+	assert.Equal(t, globalConfig.Spec.NodePlacement.NodeSelector, podSpec.NodeSelector)
+	assert.Equal(t, globalConfig.Spec.NodePlacement.Tolerations, podSpec.Tolerations)
+	container := podSpec.Containers[0]
+	assert.Equal(t, "mirror.example.com/ocs-dev/odf-multicluster-orchestrator:latest", container.Image)
+	assert.Equal(t, corev1.PullIfNotPresent, container.ImagePullPolicy)
+	assert.Equal(t, resource.MustParse("512Mi"), container.Resources.Limits[corev1.ResourceMemory])
+	assert.Equal(t, resource.MustParse("100m"), container.Resources.Requests[corev1.ResourceCPU])
+	// The resources of the manifest which are not set by the config are kept
+	assert.Equal(t, resource.MustParse("128Mi"), container.Resources.Requests[corev1.ResourceMemory])
+	assert.NotContains(t, container.Resources.Limits, corev1.ResourceCPU)
+
+	unconfigured := &appsv1.Deployment{}
+	unconfigured.Spec.Template.Spec.Containers = []corev1.Container{{Resources: deployment.Spec.Template.Spec.Containers[0].Resources}}
+	(&DeploymentConfig{}).apply(unconfigured)
+	assert.Equal(t, container.Resources, unconfigured.Spec.Template.Spec.Containers[0].Resources)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "POD_NAMESPACE"},
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+		{Name: "NO_PROXY", Value: ".cluster.local"},
+	}, container.Env)
+	assert.Equal(t, []string{"--ux-backend-proxy-address=ux-backend-proxy.apps.cluster1.example.com:443"}, container.Args)
+
+	addon.Status.ConfigReferences = append(addon.Status.ConfigReferences, configReference(invalidConfig))
+	_, err = a.getDeploymentConfig(context.TODO(), addon)
+	assert.ErrorContains(t, err, "validation: ")
+
+	var invalidReplicas DeploymentConfig
+	err = invalidReplicas.merge(addonapiv1alpha1.AddOnDeploymentConfigSpec{
+		CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{{Name: ReplicasVariable, Value: "0"}},
+	})
+	assert.ErrorContains(t, err, "validation: ")
+}
@@ -0,0 +1,126 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// StorageIDsReconciler publishes the storage IDs of the default StorageClasses of the StorageClusters of the spoke
+// cluster as a ClusterClaim. The storage IDs are computed from the Ceph FSID together with the RADOS namespace or
+// the subvolume group of the StorageClasses, which only the spoke cluster knows. The hub compares them across the
+// peers of sync MirrorPeers.
+type StorageIDsReconciler struct {
+	Scheme           *runtime.Scheme
+	SpokeClient      client.Client
+	SpokeClusterName string
+	Logger           *slog.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StorageIDsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Logger.Info("Setting up controller with manager")
+
+	eventHandler := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []ctrl.Request {
+		return []ctrl.Request{reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: utils.StorageIDsClusterClaimName,
+			},
+		}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("storage_ids_controller").
+		For(&clusterv1alpha1.ClusterClaim{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(object client.Object) bool {
+				return object.GetName() == utils.StorageIDsClusterClaimName
+			}),
+		)).
+		Watches(&storagev1.StorageClass{}, eventHandler).
+		Watches(&ocsv1.StorageCluster{}, eventHandler).
+		Complete(r)
+}
+
+func (r *StorageIDsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With("Request", req.NamespacedName.String())
+	logger.Info("Publishing storage IDs")
+
+	storageIDs, err := r.getStorageIDs(ctx)
+	if len(storageIDs) == 0 {
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to compute storage IDs: %w", err)
+		}
+		logger.Info("No storage IDs found. Skipping publishing of storage IDs")
+		return ctrl.Result{}, nil
+	}
+
+	value := utils.FormatStorageIDs(storageIDs)
+	if len(value) > maxClusterClaimValueLength {
+		logger.Error("Storage IDs exceed the maximum ClusterClaim value length", "StorageClusterCount", len(storageIDs))
+		return ctrl.Result{}, nil
+	}
+
+	claim := clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: utils.StorageIDsClusterClaimName,
+		},
+	}
+	_, updateErr := controllerutil.CreateOrUpdate(ctx, r.SpokeClient, &claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+		claim.Labels[utils.CreatedByLabelKey] = utils.CreatorMulticlusterOrchestrator
+		claim.Spec.Value = value
+		return nil
+	})
+	if updateErr != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create or update ClusterClaim %q: %w", claim.Name, updateErr)
+	}
+	// The storage IDs of StorageClusters whose Ceph cluster is not ready yet are published once it is
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to compute storage IDs: %w", err)
+	}
+
+	logger.Info("Successfully published storage IDs", "StorageIDs", value)
+	return ctrl.Result{}, nil
+}
+
+// getStorageIDs returns the storage IDs of the default StorageClasses keyed by StorageCluster. StorageClusters whose
+// storage IDs can not be computed are left out and reported in the returned error.
+func (r *StorageIDsReconciler) getStorageIDs(ctx context.Context) (map[types.NamespacedName]map[utils.CephType]string, error) {
+	var storageClusterList ocsv1.StorageClusterList
+	if err := r.SpokeClient.List(ctx, &storageClusterList); err != nil {
+		return nil, fmt.Errorf("failed to list StorageClusters: %w", err)
+	}
+
+	storageIDs := make(map[types.NamespacedName]map[utils.CephType]string)
+	var errs []error
+	for _, storageCluster := range storageClusterList.Items {
+		namespacedName := client.ObjectKeyFromObject(&storageCluster)
+		ids, err := utils.GetStorageIdsForDefaultStorageClasses(ctx, r.SpokeClient, namespacedName, r.SpokeClusterName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("StorageCluster %q: %w", namespacedName, err))
+			continue
+		}
+		if len(ids) > 0 {
+			storageIDs[namespacedName] = ids
+		}
+	}
+	return storageIDs, errors.Join(errs...)
+}
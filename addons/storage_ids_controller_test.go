@@ -0,0 +1,61 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStorageIDsReconcile(t *testing.T) {
+	ctx := context.TODO()
+	storageCluster := ocsv1.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      storageClusterName,
+			Namespace: odfNamespace,
+		},
+	}
+	// The Ceph cluster of the second StorageCluster is not ready yet
+	pendingStorageCluster := ocsv1.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pending-storagecluster",
+			Namespace: odfNamespace,
+		},
+	}
+	pendingStorageClass := GetTestCephRBDStorageClass()
+	pendingStorageClass.Name = fmt.Sprintf(utils.DefaultCephRBDStorageClassTemplate, pendingStorageCluster.Name)
+	fakeSpokeClient := fake.NewClientBuilder().WithScheme(mgrScheme).WithObjects(&storageCluster, &pendingStorageCluster,
+		GetTestCephCluster(), GetTestCephRBDStorageClass(), GetTestCephFSStorageClass(), pendingStorageClass).Build()
+
+	r := StorageIDsReconciler{
+		Scheme:           mgrScheme,
+		SpokeClient:      fakeSpokeClient,
+		SpokeClusterName: "cluster1",
+		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: utils.StorageIDsClusterClaimName}}
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("StorageIDsReconciler Reconcile() succeeded although the Ceph cluster of a StorageCluster is missing")
+	}
+
+	var claim clusterv1alpha1.ClusterClaim
+	if err := fakeSpokeClient.Get(ctx, req.NamespacedName, &claim); err != nil {
+		t.Fatalf("failed to get ClusterClaim. Error: %s", err)
+	}
+	expected := fmt.Sprintf("%s/%s/cephfs=53bcee28df765abb22cdba2d3d16b63c,%s/%s/rbd=d9b40172f24bf4752da07dfc1ad9c982",
+		odfNamespace, storageClusterName, odfNamespace, storageClusterName)
+	if claim.Spec.Value != expected {
+		t.Errorf("expected ClusterClaim value %q, got %q", expected, claim.Spec.Value)
+	}
+	if claim.Labels[utils.CreatedByLabelKey] != utils.CreatorMulticlusterOrchestrator {
+		t.Errorf("expected ClusterClaim to be labelled as created by the orchestrator, got labels %v", claim.Labels)
+	}
+}
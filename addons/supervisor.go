@@ -0,0 +1,66 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+)
+
+const (
+	// leaderElectionID is the lease on the spoke cluster electing the agent replica running the managers
+	leaderElectionID = "token-exchange-agent"
+
+	// managerStableDuration is the time after which a running manager is considered recovered, its next failure is
+	// restarted without delay again
+	managerStableDuration = 5 * time.Minute
+)
+
+// managerRestartBackoff delays the restarts of a failing manager
+var managerRestartBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    8,
+	Cap:      2 * time.Minute,
+}
+
+// supervisedControllerOptions let a manager restarted by superviseManager create its controllers again, their names
+// are still registered by the controllers of the failed manager
+var supervisedControllerOptions = config.Controller{
+	SkipNameValidation: ptr.To(true),
+}
+
+// superviseManager runs a manager until the context is cancelled. A manager which fails, for example after losing
+// the leader election or failing to reach its cluster, is restarted with an exponential backoff instead of exiting
+// the agent.
+func superviseManager(ctx context.Context, name string, health *AgentHealth, backoff wait.Backoff, run func(context.Context) error, logger *slog.Logger) {
+	delay := backoff.DelayFunc()
+	for {
+		started := time.Now()
+		err := run(ctx)
+		if ctx.Err() != nil {
+			logger.Info("Manager stopped", "Manager", name)
+			return
+		}
+		if err == nil {
+			err = errors.New("manager exited")
+		}
+		health.setManagerState(name, fmt.Errorf("restarting: %w", err))
+		if time.Since(started) > managerStableDuration {
+			delay = backoff.DelayFunc()
+		}
+		restartIn := delay()
+		logger.Error("Manager failed, restarting", "Manager", name, "RestartIn", restartIn, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartIn):
+		}
+	}
+}
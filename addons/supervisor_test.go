@@ -0,0 +1,105 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+func TestSuperviseManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	health := NewAgentHealth(spokeManagerName)
+	leaderElectionLost := errors.New("leader election lost")
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		superviseManager(ctx, spokeManagerName, health, wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}, func(ctx context.Context) error {
+			runs++
+			if runs < 3 {
+				return leaderElectionLost
+			}
+			health.setElectionState(electionLeading)
+			health.setManagerState(spokeManagerName, nil)
+			<-ctx.Done()
+			return nil
+		}, utils.GetLogger(utils.GetZapLogger(true)))
+	}()
+
+	if err := wait.PollUntilContextTimeout(ctx, time.Millisecond, 5*time.Second, true, func(_ context.Context) (bool, error) {
+		return health.IsHealthy(), nil
+	}); err != nil {
+		t.Fatalf("expected the manager to be restarted until it runs, got %d runs", runs)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the supervisor to stop with its context")
+	}
+	if runs != 3 {
+		t.Errorf("expected 3 runs of the manager, got %d", runs)
+	}
+}
+
+func TestAgentHealthStandby(t *testing.T) {
+	health := NewAgentHealth(spokeManagerName, hubManagerName)
+	if err := health.Check(); err == nil {
+		t.Error("expected a replica whose spoke manager has not started to not be ready")
+	}
+
+	// start runs a runnable until the returned function stops it
+	start := func(runnable manager.Runnable) func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = runnable.Start(ctx)
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	poll := func(ready bool, message string) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(context.TODO(), time.Millisecond, 5*time.Second, true, func(_ context.Context) (bool, error) {
+			return (health.Check() == nil) == ready, nil
+		}); err != nil {
+			t.Error(message)
+		}
+	}
+
+	stopElection := start(health.ElectionRunnable())
+	poll(true, "expected a replica waiting for the leader election to be ready")
+
+	stopLeading := start(health.LeaderRunnable())
+	poll(false, "expected the elected replica to report its managers which are not running")
+	health.setManagerState(spokeManagerName, nil)
+	health.setManagerState(hubManagerName, nil)
+	poll(true, "expected the elected replica to be ready once its managers run")
+
+	// The spoke manager of the leader fails and is restarted by the supervisor
+	stopLeading()
+	stopElection()
+	if err := health.Check(); err == nil {
+		t.Error("expected a replica whose spoke manager stopped to not be ready")
+	}
+	health.setManagerState(spokeManagerName, errors.New("restarting: leader election lost"))
+	health.setManagerState(hubManagerName, errors.New("manager stopped"))
+	if err := health.Check(); err == nil {
+		t.Error("expected a restarting replica to not be ready")
+	}
+
+	stopElection = start(health.ElectionRunnable())
+	defer stopElection()
+	poll(true, "expected the restarted replica to be ready while it waits for the leader election")
+}
@@ -24,14 +24,54 @@ type PhaseType string
 type DRType string
 
 const (
-	IncompatibleVersion PhaseType = "IncompatibleVersion"
-	ExchangingSecret    PhaseType = "ExchangingSecret"
-	ExchangedSecret     PhaseType = "ExchangedSecret"
-	S3ProfileSynced     PhaseType = "S3ProfileSynced"
-	S3ProfileSyncing    PhaseType = "S3ProfileSyncing"
-	Deleting            PhaseType = "Deleting"
-	Sync                DRType    = "sync"
-	Async               DRType    = "async"
+	// Deprecated: version incompatibilities are reported by the VersionCompatible condition
+	IncompatibleVersion       PhaseType = "IncompatibleVersion"
+	ExchangingSecret          PhaseType = "ExchangingSecret"
+	ExchangedSecret           PhaseType = "ExchangedSecret"
+	S3ProfileSynced           PhaseType = "S3ProfileSynced"
+	S3ProfileSyncing          PhaseType = "S3ProfileSyncing"
+	Deleting                  PhaseType = "Deleting"
+	InvalidSyncPeers          PhaseType = "InvalidSyncPeers"
+	PeerStorageNotFound       PhaseType = "PeerStorageNotFound"
+	StalePeerStorage          PhaseType = "StalePeerStorage"
+	InvalidHeterogeneousPeers PhaseType = "InvalidHeterogeneousPeers"
+	Sync                      DRType    = "sync"
+	Async                     DRType    = "async"
+)
+
+const (
+	// MirrorPeerConditionClusterDetached reports whether a ManagedCluster of the MirrorPeer has been detached from the hub
+	MirrorPeerConditionClusterDetached = "ClusterDetached"
+
+	MirrorPeerReasonManagedClusterDetached = "ManagedClusterDetached"
+	MirrorPeerReasonManagedClusterAttached = "ManagedClusterAttached"
+
+	// MirrorPeerConditionVersionCompatible reports whether the StorageCluster versions of the MirrorPeer comply with
+	// the version skew policy of the orchestrator
+	MirrorPeerConditionVersionCompatible = "VersionCompatible"
+
+	MirrorPeerReasonVersionsMatch             = "VersionsMatch"
+	MirrorPeerReasonVersionSkewAllowed        = "VersionSkewAllowed"
+	MirrorPeerReasonVersionAllowListed        = "VersionAllowListed"
+	MirrorPeerReasonVersionSkewExceeded       = "VersionSkewExceeded"
+	MirrorPeerReasonFeatureVersionUnsupported = "FeatureVersionUnsupported"
+	MirrorPeerReasonVersionUnknown            = "VersionUnknown"
+	MirrorPeerReasonVersionPolicyInvalid      = "VersionPolicyInvalid"
+
+	// MirrorPeerConditionCapabilitiesSupported reports whether the ODF versions of both peers support the
+	// capabilities the MirrorPeer relies on
+	MirrorPeerConditionCapabilitiesSupported = "CapabilitiesSupported"
+
+	MirrorPeerReasonCapabilitiesSupported = "CapabilitiesSupported"
+	MirrorPeerReasonCapabilitiesMissing   = "CapabilitiesMissing"
+
+	// MirrorPeerConditionAgentsUpgraded reports whether the token exchange agents of both peers run the version of
+	// the orchestrator and completed their upgrade migrations
+	MirrorPeerConditionAgentsUpgraded = "AgentsUpgraded"
+
+	MirrorPeerReasonAgentsUpgraded      = "AgentsUpgraded"
+	MirrorPeerReasonAgentUpgradePending = "AgentUpgradePending"
+	MirrorPeerReasonAgentVersionUnknown = "AgentVersionUnknown"
 )
 
 // StorageClusterRef holds a reference to a StorageCluster
@@ -82,6 +122,9 @@ type MirrorPeerSpec struct {
 type MirrorPeerStatus struct {
 	Phase   PhaseType `json:"phase,omitempty"`
 	Message string    `json:"message,omitempty"`
+	// Conditions describe the state of the MirrorPeer.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Red Hat OpenShift Data Foundation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StorageInventorySynced is the condition type reporting whether the inventory reflects the odf-info of the ManagedCluster
+	StorageInventorySynced = "Synced"
+	// StorageInventoryStale is the condition type reporting whether the odf-info of a provider has not been refreshed
+	// within the configured threshold
+	StorageInventoryStale = "Stale"
+)
+
+// StorageInventorySpec defines the ManagedCluster described by the StorageInventory
+type StorageInventorySpec struct {
+	// ClusterName is the name of the ManagedCluster the inventory is collected from.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec.clusterName is immutable."
+	ClusterName string `json:"clusterName"`
+}
+
+// StorageProvider describes a StorageCluster running on the ManagedCluster
+type StorageProvider struct {
+	// Name is the name of the StorageCluster.
+	Name string `json:"name"`
+	// Namespace is the namespace of the StorageCluster.
+	Namespace string `json:"namespace"`
+	// Version is the ODF version of the StorageCluster.
+	Version string `json:"version,omitempty"`
+	// DeploymentType is the deployment mode of the StorageCluster, for example internal or external.
+	DeploymentType string `json:"deploymentType,omitempty"`
+	// CephClusterFSID is the FSID of the Ceph cluster backing the StorageCluster.
+	CephClusterFSID string `json:"cephClusterFSID,omitempty"`
+	// StorageProviderEndpoint is the endpoint of the storage provider API server.
+	StorageProviderEndpoint string `json:"storageProviderEndpoint,omitempty"`
+	// StorageProviderPublicEndpoint is the endpoint of the storage provider API server exported for other clusters.
+	StorageProviderPublicEndpoint string `json:"storageProviderPublicEndpoint,omitempty"`
+	// ResourceVersion is the resourceVersion of the odf-info ConfigMap the provider was read from.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// LastRefreshTime is the last time the token exchange agent refreshed the odf-info of the provider.
+	// +optional
+	LastRefreshTime *metav1.Time `json:"lastRefreshTime,omitempty"`
+}
+
+// StorageClient describes a StorageClient consuming a StorageProvider of the ManagedCluster
+type StorageClient struct {
+	// Name is the name of the StorageClient.
+	Name string `json:"name"`
+	// ClusterID is the ID of the cluster the StorageClient runs on.
+	ClusterID string `json:"clusterID"`
+	// ClientID is the ID of the StorageClient.
+	ClientID string `json:"clientID,omitempty"`
+	// ManagedClusterName is the name of the ManagedCluster the StorageClient runs on.
+	ManagedClusterName string `json:"managedClusterName"`
+	// ProviderName is the name of the StorageProvider serving the StorageClient.
+	ProviderName string `json:"providerName"`
+}
+
+// StorageInventoryStatus defines the observed storage of the ManagedCluster
+type StorageInventoryStatus struct {
+	// Providers is the list of StorageClusters running on the ManagedCluster.
+	// +optional
+	Providers []StorageProvider `json:"providers,omitempty"`
+	// Clients is the list of StorageClients consuming the StorageClusters of the ManagedCluster.
+	// +optional
+	Clients []StorageClient `json:"clients,omitempty"`
+	// LastUpdateTime is the last time the inventory was refreshed from the ManagedCluster.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Conditions describe the state of the inventory.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:selectablefield:JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Last Update",type=date,JSONPath=`.status.lastUpdateTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// StorageInventory is the Schema for the storageinventories API. It describes the ODF
+// StorageClusters and StorageClients reported by a ManagedCluster.
+type StorageInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StorageInventorySpec   `json:"spec,omitempty"`
+	Status StorageInventoryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StorageInventoryList contains a list of StorageInventory
+type StorageInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StorageInventory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StorageInventory{}, &StorageInventoryList{})
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getOutdatedAgents returns the version published by the agents of the ManagedClusters which do not run the version
+// stamped on their ManagedClusterAddOn by the orchestrator yet. Agents which never published a version report an
+// empty version.
+func getOutdatedAgents(ctx context.Context, c client.Client, clusterNames []string) (map[string]string, error) {
+	outdated := make(map[string]string)
+	for _, clusterName := range clusterNames {
+		var addon addonapiv1alpha1.ManagedClusterAddOn
+		err := c.Get(ctx, types.NamespacedName{Name: setup.TokenExchangeName, Namespace: clusterName}, &addon)
+		if k8serrors.IsNotFound(err) {
+			outdated[clusterName] = ""
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ManagedClusterAddOn of ManagedCluster %q: %w", clusterName, err)
+		}
+		var managedCluster clusterv1.ManagedCluster
+		err = c.Get(ctx, types.NamespacedName{Name: clusterName}, &managedCluster)
+		if k8serrors.IsNotFound(err) {
+			outdated[clusterName] = ""
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ManagedCluster %q: %w", clusterName, err)
+		}
+		agentVersion, _ := setup.GetAgentVersion(&managedCluster)
+		if agentVersion == "" || agentVersion != addon.Annotations[AddonVersionAnnotationKey] {
+			outdated[clusterName] = agentVersion
+		}
+	}
+	return outdated, nil
+}
+
+// agentVersionChanged returns true when the version published by the agent of the ManagedCluster changed
+func agentVersionChanged(oldMC, newMC *clusterv1.ManagedCluster) bool {
+	oldVersion, _ := setup.GetAgentVersion(oldMC)
+	newVersion, _ := setup.GetAgentVersion(newMC)
+	return oldVersion != newVersion
+}
+
+// setAgentsUpgradedCondition reports on the MirrorPeer whether the agents of both peers completed their upgrade to
+// the version of the orchestrator. It returns true when the condition changed.
+func setAgentsUpgradedCondition(mirrorPeer *multiclusterv1alpha1.MirrorPeer, outdated map[string]string, orchestratorVersion string) bool {
+	condition := metav1.Condition{
+		Type:               multiclusterv1alpha1.MirrorPeerConditionAgentsUpgraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             multiclusterv1alpha1.MirrorPeerReasonAgentsUpgraded,
+		Message:            fmt.Sprintf("The agents of both peers run version %s", orchestratorVersion),
+		ObservedGeneration: mirrorPeer.Generation,
+	}
+	if len(outdated) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = multiclusterv1alpha1.MirrorPeerReasonAgentUpgradePending
+		var agents []string
+		for _, clusterName := range slices.Sorted(maps.Keys(outdated)) {
+			agentVersion := outdated[clusterName]
+			if agentVersion == "" {
+				condition.Reason = multiclusterv1alpha1.MirrorPeerReasonAgentVersionUnknown
+				agentVersion = "unknown"
+			}
+			agents = append(agents, fmt.Sprintf("%s=%s", clusterName, agentVersion))
+		}
+		condition.Message = fmt.Sprintf("Waiting for the agents %s to complete their upgrade to version %s", strings.Join(agents, ", "), orchestratorVersion)
+	}
+	return meta.SetStatusCondition(&mirrorPeer.Status.Conditions, condition)
+}
@@ -0,0 +1,91 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func agentAddon(clusterName, stampedVersion string) *addonapiv1alpha1.ManagedClusterAddOn {
+	return &addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        setup.TokenExchangeName,
+			Namespace:   clusterName,
+			Annotations: map[string]string{AddonVersionAnnotationKey: stampedVersion},
+		},
+	}
+}
+
+func agentCluster(clusterName, publishedVersion string) *clusterv1.ManagedCluster {
+	mc := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterName}}
+	if publishedVersion != "" {
+		mc.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{{Name: setup.AgentVersionClusterClaimName, Value: publishedVersion}}
+	}
+	return mc
+}
+
+func TestGetOutdatedAgents(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(mgrScheme).WithObjects(
+		agentAddon("upgraded", "4.18.0"), agentCluster("upgraded", "4.18.0"),
+		agentAddon("previous", "4.18.0"), agentCluster("previous", "4.17.0"),
+		agentAddon("unreported", "4.18.0"), agentCluster("unreported", ""),
+		agentAddon("detached", "4.18.0"),
+		agentCluster("missing", "4.18.0"),
+	).Build()
+
+	outdated, err := getOutdatedAgents(context.TODO(), fakeClient, []string{"upgraded", "previous", "unreported", "detached", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"previous":   "4.17.0",
+		"unreported": "",
+		"detached":   "",
+		"missing":    "",
+	}, outdated)
+
+	outdated, err = getOutdatedAgents(context.TODO(), fakeClient, []string{"upgraded"})
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+}
+
+func TestAgentVersionChanged(t *testing.T) {
+	assert.False(t, agentVersionChanged(agentCluster("cluster1", "4.17.0"), agentCluster("cluster1", "4.17.0")))
+	assert.True(t, agentVersionChanged(agentCluster("cluster1", "4.17.0"), agentCluster("cluster1", "4.18.0")))
+	assert.True(t, agentVersionChanged(agentCluster("cluster1", ""), agentCluster("cluster1", "4.18.0")))
+}
+
+func TestSetAgentsUpgradedCondition(t *testing.T) {
+	mirrorPeer := &multiclusterv1alpha1.MirrorPeer{}
+
+	assert.True(t, setAgentsUpgradedCondition(mirrorPeer, map[string]string{"cluster2": "4.17.0"}, "4.18.0"))
+	cond := meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionAgentsUpgraded)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, multiclusterv1alpha1.MirrorPeerReasonAgentUpgradePending, cond.Reason)
+		assert.Contains(t, cond.Message, "cluster2=4.17.0")
+	}
+
+	assert.True(t, setAgentsUpgradedCondition(mirrorPeer, map[string]string{"cluster1": "", "cluster2": "4.17.0"}, "4.18.0"))
+	cond = meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionAgentsUpgraded)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, multiclusterv1alpha1.MirrorPeerReasonAgentVersionUnknown, cond.Reason)
+		assert.Contains(t, cond.Message, "cluster1=unknown, cluster2=4.17.0")
+	}
+
+	assert.True(t, setAgentsUpgradedCondition(mirrorPeer, nil, "4.18.0"))
+	cond = meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionAgentsUpgraded)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	}
+	assert.False(t, setAgentsUpgradedCondition(mirrorPeer, nil, "4.18.0"))
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// getMirrorPeerRequiredCapabilities returns the capabilities both peers of the MirrorPeer must support
+func getMirrorPeerRequiredCapabilities(mirrorPeer multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) []utils.Capability {
+	if isStorageClusterPeering(mirrorPeer, peerRefTypes) {
+		return []utils.Capability{utils.CapabilityStorageClusterPeer, utils.CapabilityRadosNamespaceMirroring}
+	}
+	return nil
+}
+
+// getDRPolicyRequiredCapabilities returns the capabilities both peers of the DRPolicy must support for the requested
+// replication classes
+func getDRPolicyRequiredCapabilities(dp *ramenv1alpha1.DRPolicy) []utils.Capability {
+	var required []utils.Capability
+	if dp.Spec.ReplicationClassSelector.MatchLabels[RBDFlattenVolumeReplicationClassLabelKey] == RBDFlattenVolumeReplicationClassLabelValue {
+		required = append(required, utils.CapabilityFlattenMode)
+	}
+	if isConsistencyGroupEnabled(dp) {
+		required = append(required, utils.CapabilityVolumeGroupReplication)
+	}
+	return required
+}
+
+// getPeerProviderClusters returns the ManagedClusters running the StorageClusters of the MirrorPeer. StorageClients
+// rely on the capabilities of the ManagedCluster of their provider.
+func getPeerProviderClusters(mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo) []string {
+	var clusterNames []string
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		clusterName := peerRef.ClusterName
+		if clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef); err == nil && clientInfo.ProviderInfo.ProviderManagedClusterName != "" {
+			clusterName = clientInfo.ProviderInfo.ProviderManagedClusterName
+		}
+		if !slices.Contains(clusterNames, clusterName) {
+			clusterNames = append(clusterNames, clusterName)
+		}
+	}
+	return clusterNames
+}
+
+// getProviderOnlyClusters returns the sorted ManagedClusters of the providers of the StorageClient peers of the
+// MirrorPeer which are not peers of the MirrorPeer themselves
+func getProviderOnlyClusters(mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo) []string {
+	var clusterNames []string
+	for _, clusterName := range getPeerProviderClusters(mirrorPeer, clientInfoMap) {
+		if !slices.ContainsFunc(mirrorPeer.Spec.Items, func(pr multiclusterv1alpha1.PeerRef) bool { return pr.ClusterName == clusterName }) {
+			clusterNames = append(clusterNames, clusterName)
+		}
+	}
+	slices.Sort(clusterNames)
+	return clusterNames
+}
+
+// getServedClusters returns the ManagedCluster together with the ManagedClusters of the StorageClients consuming
+// the StorageClusters running on it
+func getServedClusters(clusterName string, clientInfoMap map[string]utils.ClientInfo) []string {
+	clusterNames := []string{clusterName}
+	for _, clientInfo := range clientInfoMap {
+		if clientInfo.ProviderInfo.ProviderManagedClusterName != clusterName || clientInfo.ClientManagedClusterName == "" {
+			continue
+		}
+		if !slices.Contains(clusterNames, clientInfo.ClientManagedClusterName) {
+			clusterNames = append(clusterNames, clientInfo.ClientManagedClusterName)
+		}
+	}
+	return clusterNames
+}
+
+// hasMissingCapability returns true when any ManagedCluster misses the capability
+func hasMissingCapability(missing map[string][]utils.Capability, capability utils.Capability) bool {
+	for _, capabilities := range missing {
+		if slices.Contains(capabilities, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// setCapabilitiesSupportedCondition reports on the MirrorPeer whether both peers support the capabilities the
+// MirrorPeer relies on. It returns true when the condition changed.
+func setCapabilitiesSupportedCondition(mirrorPeer *multiclusterv1alpha1.MirrorPeer, required []utils.Capability, missing map[string][]utils.Capability) bool {
+	condition := metav1.Condition{
+		Type:               multiclusterv1alpha1.MirrorPeerConditionCapabilitiesSupported,
+		Status:             metav1.ConditionTrue,
+		Reason:             multiclusterv1alpha1.MirrorPeerReasonCapabilitiesSupported,
+		Message:            fmt.Sprintf("Both peers support the required capabilities %v", required),
+		ObservedGeneration: mirrorPeer.Generation,
+	}
+	if len(missing) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = multiclusterv1alpha1.MirrorPeerReasonCapabilitiesMissing
+		condition.Message = utils.FormatMissingCapabilities(missing)
+	}
+	return meta.SetStatusCondition(&mirrorPeer.Status.Conditions, condition)
+}
+
+// odfCapabilitiesChanged returns true when the capabilities advertised by the ManagedCluster changed
+func odfCapabilitiesChanged(oldMC, newMC *clusterv1.ManagedCluster) bool {
+	oldCapabilities, oldAdvertised := utils.GetODFCapabilities(oldMC)
+	newCapabilities, newAdvertised := utils.GetODFCapabilities(newMC)
+	return oldAdvertised != newAdvertised || !slices.Equal(oldCapabilities, newCapabilities)
+}
+
+// odfCapabilitiesChangedPredicate passes ManagedCluster updates which change the advertised capabilities
+func odfCapabilitiesChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldMC, ok := e.ObjectOld.(*clusterv1.ManagedCluster)
+			if !ok {
+				return false
+			}
+			newMC, ok := e.ObjectNew.(*clusterv1.ManagedCluster)
+			if !ok {
+				return false
+			}
+			return odfCapabilitiesChanged(oldMC, newMC)
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
@@ -0,0 +1,65 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"testing"
+
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDRPolicyRequiredCapabilities(t *testing.T) {
+	dp := &ramenv1alpha1.DRPolicy{}
+	assert.Empty(t, getDRPolicyRequiredCapabilities(dp))
+
+	dp.Spec.ReplicationClassSelector.MatchLabels = map[string]string{
+		RBDFlattenVolumeReplicationClassLabelKey: RBDFlattenVolumeReplicationClassLabelValue,
+	}
+	assert.Equal(t, []utils.Capability{utils.CapabilityFlattenMode}, getDRPolicyRequiredCapabilities(dp))
+}
+
+func TestSetCapabilitiesSupportedCondition(t *testing.T) {
+	mirrorPeer := &multiclusterv1alpha1.MirrorPeer{Spec: multiclusterv1alpha1.MirrorPeerSpec{Type: multiclusterv1alpha1.Async}}
+	required := getMirrorPeerRequiredCapabilities(*mirrorPeer, []utils.PeerRefType{utils.PeerRefTypeStorageCluster, utils.PeerRefTypeStorageClient})
+	assert.Equal(t, []utils.Capability{utils.CapabilityStorageClusterPeer, utils.CapabilityRadosNamespaceMirroring}, required)
+
+	assert.True(t, setCapabilitiesSupportedCondition(mirrorPeer, required, nil))
+	cond := meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionCapabilitiesSupported)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	}
+	assert.False(t, setCapabilitiesSupportedCondition(mirrorPeer, required, nil))
+
+	missing := map[string][]utils.Capability{"cluster2": {utils.CapabilityStorageClusterPeer}}
+	assert.True(t, setCapabilitiesSupportedCondition(mirrorPeer, required, missing))
+	cond = meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionCapabilitiesSupported)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, multiclusterv1alpha1.MirrorPeerReasonCapabilitiesMissing, cond.Reason)
+		assert.Contains(t, cond.Message, "cluster2")
+	}
+}
+
+func TestGetProviderOnlyClusters(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{
+				{ClusterName: "client1", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "client"}},
+				{ClusterName: "provider2", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "client"}},
+			},
+		},
+	}
+	clientInfoMap := map[string]utils.ClientInfo{
+		utils.GetKey("client1", "client"):   {ProviderInfo: utils.ProviderInfo{ProviderManagedClusterName: "provider1"}},
+		utils.GetKey("provider2", "client"): {ProviderInfo: utils.ProviderInfo{ProviderManagedClusterName: "provider2"}},
+	}
+	// The provider of a StorageClient running on a peer is a peer itself
+	assert.Equal(t, []string{"provider1"}, getProviderOnlyClusters(mirrorPeer, clientInfoMap))
+	assert.Empty(t, getProviderOnlyClusters(mirrorPeer, nil))
+}
@@ -3,8 +3,13 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	replicationv1alpha1 "github.com/csi-addons/kubernetes-csi-addons/apis/replication.storage/v1alpha1"
@@ -13,15 +18,22 @@ import (
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -37,12 +49,61 @@ const (
 	RBDFlattenVolumeReplicationClassLabelKey     = "replication.storage.openshift.io/flatten-mode"
 	RBDFlattenVolumeReplicationClassLabelValue   = "force"
 	RBDVolumeReplicationClassDefaultAnnotation   = "replication.storage.openshift.io/is-default-class"
+
+	VolumeGroupReplicationClassKind               = "VolumeGroupReplicationClass"
+	RBDVolumeGroupReplicationClassNameTemplate    = "rbd-volumegroupreplicationclass-%v"
+	CephFSVolumeGroupReplicationClassNameTemplate = "cephfs-volumegroupreplicationclass-%v"
+	CephFSProvisionerName                         = "openshift-storage.cephfs.csi.ceph.com"
+	ConsistencyGroupKey                           = "multicluster.odf.openshift.io/consistency-group"
+	ConsistencyGroupEnabledValue                  = "enabled"
+
+	// ReplicationParametersAnnotationKey holds a JSON object of VolumeReplicationClass parameters on a DRPolicy.
+	ReplicationParametersAnnotationKey = "multicluster.odf.openshift.io/replication-parameters"
+	// ReplicationParametersConfigMapAnnotationKey holds the name of a ConfigMap in the orchestrator namespace
+	// whose data is used as VolumeReplicationClass parameters. Parameters from ReplicationParametersAnnotationKey
+	// take precedence over the ConfigMap data.
+	ReplicationParametersConfigMapAnnotationKey = "multicluster.odf.openshift.io/replication-parameters-configmap"
+	// ReplicationParametersPoolPrefix scopes a replication parameter to the RBD block pool named between the prefix
+	// and the parameter, as in pools.replicapool.mirroringMode. The parameters of a pool are applied on top of the
+	// others to VolumeReplicationClasses of their own, labelled with BlockPoolLabelKey.
+	ReplicationParametersPoolPrefix = "pools."
+	BlockPoolLabelKey               = "multicluster.odf.openshift.io/block-pool"
+	SchedulingStartTimeKey          = "schedulingStartTime"
+	JournalMirroringMode            = "journal"
+	ReplicationSecretNameKey        = "replication.storage.openshift.io/replication-secret-name"
+	ReplicationSecretNamespaceKey   = "replication.storage.openshift.io/replication-secret-namespace"
+)
+
+// Conditions set by the orchestrator on DRPolicy status
+const (
+	DRPolicyConditionMirrorPeerFound = "MirrorPeerFound"
+	DRPolicyConditionMirrorPeerReady = "MirrorPeerReady"
+	DRPolicyConditionVRCDistributed  = "VRCDistributed"
+	// DRPolicyConditionCapabilitiesSupported reports whether both peers support the capabilities of the requested
+	// replication classes
+	DRPolicyConditionCapabilitiesSupported = "CapabilitiesSupported"
+	// DRPolicyConditionReplicationParametersValid reports whether the custom replication parameters of the DRPolicy
+	// are valid. Invalid parameters have to be fixed, the replication classes are not updated until then.
+	DRPolicyConditionReplicationParametersValid = "ReplicationParametersValid"
+
+	DRPolicyReasonMirrorPeerFound              = "MirrorPeerFound"
+	DRPolicyReasonMirrorPeerNotFound           = "MirrorPeerNotFound"
+	DRPolicyReasonMirrorPeerReady              = "MirrorPeerReady"
+	DRPolicyReasonMirrorPeerNotReady           = "MirrorPeerNotReady"
+	DRPolicyReasonManifestWorkApplied          = "ManifestWorkApplied"
+	DRPolicyReasonManifestWorkNotApplied       = "ManifestWorkNotApplied"
+	DRPolicyReasonManifestWorkFailed           = "ManifestWorkFailed"
+	DRPolicyReasonCapabilitiesSupported        = "CapabilitiesSupported"
+	DRPolicyReasonCapabilitiesMissing          = "CapabilitiesMissing"
+	DRPolicyReasonReplicationParametersValid   = "ReplicationParametersValid"
+	DRPolicyReasonReplicationParametersInvalid = "ReplicationParametersInvalid"
 )
 
 type DRPolicyReconciler struct {
 	HubClient client.Client
 	Scheme    *runtime.Scheme
 	Logger    *slog.Logger
+	Recorder  record.EventRecorder
 
 	testEnvFile      string
 	CurrentNamespace string
@@ -67,25 +128,115 @@ func (r *DRPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return reqs
 		}
 		for _, mp := range drpolicyList.Items {
+			// Changes to a replication parameters ConfigMap only affect the DRPolicies referencing it
+			if mp.Annotations[ReplicationParametersConfigMapAnnotationKey] != object.GetName() {
+				continue
+			}
 			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
 		}
 		r.Logger.Info("DRPolicy reconcile requests generated based on ConfigMap change.", "RequestCount", len(reqs), "Requests", reqs)
 		return reqs
 	}
 
+	mirrorPeerToDRPolicyMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		mp, ok := object.(*multiclusterv1alpha1.MirrorPeer)
+		if !ok {
+			r.Logger.Debug("Unable to cast object into a MirrorPeer. Not requeing any requests.")
+			return reqs
+		}
+		var drpolicyList ramenv1alpha1.DRPolicyList
+		err := r.HubClient.List(ctx, &drpolicyList)
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of all DRPolicies. Not requeing any requests.")
+			return reqs
+		}
+		for _, dp := range drpolicyList.Items {
+			if isMirrorPeerForClusters(mp, dp.Spec.DRClusters) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: dp.Name}})
+			}
+		}
+		return reqs
+	}
+
+	storageInventoryToDRPolicyMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		var drpolicyList ramenv1alpha1.DRPolicyList
+		err := r.HubClient.List(ctx, &drpolicyList)
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of all DRPolicies. Not requeing any requests.")
+			return reqs
+		}
+		for _, dp := range drpolicyList.Items {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: dp.Name}})
+		}
+		r.Logger.Info("DRPolicy reconcile requests generated based on StorageInventory change.", "RequestCount", len(reqs), "Requests", reqs)
+		return reqs
+	}
+
+	managedClusterToDRPolicyMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		// The replication classes are distributed to the providers of the DRClusters, hence the DRPolicies of the
+		// clusters of the StorageClients of the ManagedCluster are requeued as well
+		clientInfoMap, err := utils.FetchClientInfo(ctx, r.HubClient)
+		if err != nil {
+			r.Logger.Debug("Unable to fetch client info. Mapping ManagedCluster to DRPolicies by cluster name only.", "error", err)
+		}
+		drpolicies, err := utils.ListDRPoliciesForClusters(ctx, r.HubClient, getServedClusters(object.GetName(), clientInfoMap))
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of DRPolicies. Not requeing any requests.", "error", err)
+			return reqs
+		}
+		for _, dp := range drpolicies {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: dp.Name}})
+		}
+		r.Logger.Info("DRPolicy reconcile requests generated based on ManagedCluster capabilities change.", "ManagedCluster", object.GetName(), "RequestCount", len(reqs))
+		return reqs
+	}
+
+	manifestWorkToDRPolicyMapFunc := func(_ context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		for _, ref := range object.GetOwnerReferences() {
+			if ref.Kind == "DRPolicy" {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: ref.Name}})
+			}
+		}
+		return reqs
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ramenv1alpha1.DRPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(cmToDRPolicyMapFunc),
+		For(&ramenv1alpha1.DRPolicy{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&multiclusterv1alpha1.StorageInventory{}, handler.EnqueueRequestsFromMapFunc(storageInventoryToDRPolicyMapFunc),
+			builder.WithPredicates(storageInventoryEntriesChangedPredicate())).
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(managedClusterToDRPolicyMapFunc),
+			builder.WithPredicates(odfCapabilitiesChangedPredicate())).
+		Watches(&multiclusterv1alpha1.MirrorPeer{}, handler.EnqueueRequestsFromMapFunc(mirrorPeerToDRPolicyMapFunc),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, mirrorPeerPhaseChangedPredicate()))).
+		Watches(&workv1.ManifestWork{}, handler.EnqueueRequestsFromMapFunc(manifestWorkToDRPolicyMapFunc),
 			builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
-				cm, ok := object.(*corev1.ConfigMap)
-				if !ok || cm.Name != utils.ClientInfoConfigMapName || cm.Namespace != r.CurrentNamespace {
-					return false
-				}
-				return true
+				return strings.HasPrefix(object.GetName(), "vrc-") || strings.HasPrefix(object.GetName(), "vgrc-")
 			}))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(cmToDRPolicyMapFunc),
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.isReplicationParametersConfigMap))).
 		Complete(r)
 }
 
+// isReplicationParametersConfigMap returns true for the ConfigMaps referenced by the
+// ReplicationParametersConfigMapAnnotationKey annotation of a DRPolicy
+func (r *DRPolicyReconciler) isReplicationParametersConfigMap(object client.Object) bool {
+	if object.GetNamespace() != r.CurrentNamespace {
+		return false
+	}
+	var drpolicyList ramenv1alpha1.DRPolicyList
+	if err := r.HubClient.List(context.TODO(), &drpolicyList); err != nil {
+		r.Logger.Error("Failed to list DRPolicies", "error", err)
+		return false
+	}
+	return slices.ContainsFunc(drpolicyList.Items, func(dp ramenv1alpha1.DRPolicy) bool {
+		return dp.Annotations[ReplicationParametersConfigMapAnnotationKey] == object.GetName()
+	})
+}
+
 func (r *DRPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Logger.With("Request", req.NamespacedName.String())
 	logger.Info("Running DRPolicy reconciler on hub cluster")
@@ -96,6 +247,7 @@ func (r *DRPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("DRPolicy not found. Ignoring since the object must have been deleted")
+			deleteDRPolicyMetrics(req.Name)
 			return ctrl.Result{}, nil
 		}
 		logger.Error("Failed to get DRPolicy", "error", err)
@@ -107,29 +259,213 @@ func (r *DRPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("MirrorPeer not found. Requeuing", "DRClusters", drpolicy.Spec.DRClusters)
+			message := fmt.Sprintf("No MirrorPeer found for clusters %v. Create a MirrorPeer for these clusters to use this DRPolicy", drpolicy.Spec.DRClusters)
+			r.setDRPolicyCondition(&drpolicy, DRPolicyConditionMirrorPeerFound, metav1.ConditionFalse, DRPolicyReasonMirrorPeerNotFound, message)
+			r.setDRPolicyCondition(&drpolicy, DRPolicyConditionMirrorPeerReady, metav1.ConditionFalse, DRPolicyReasonMirrorPeerNotFound, message)
+			if err := r.updateDRPolicyStatus(ctx, &drpolicy); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 		}
 		logger.Error("Error occurred while trying to fetch MirrorPeer for given DRPolicy", "error", err)
 		return ctrl.Result{}, err
 	}
 
+	r.setDRPolicyCondition(&drpolicy, DRPolicyConditionMirrorPeerFound, metav1.ConditionTrue, DRPolicyReasonMirrorPeerFound,
+		fmt.Sprintf("DRPolicy is using MirrorPeer %q", mirrorPeer.Name))
+	if isMirrorPeerReady(mirrorPeer) {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionMirrorPeerReady, metav1.ConditionTrue, DRPolicyReasonMirrorPeerReady,
+			fmt.Sprintf("MirrorPeer %q is in phase %q", mirrorPeer.Name, mirrorPeer.Status.Phase))
+	} else {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionMirrorPeerReady, metav1.ConditionFalse, DRPolicyReasonMirrorPeerNotReady,
+			fmt.Sprintf("MirrorPeer %q is in phase %q: %s", mirrorPeer.Name, mirrorPeer.Status.Phase, mirrorPeer.Status.Message))
+	}
+
 	if mirrorPeer.Spec.Type != multiclusterv1alpha1.Async {
-		return ctrl.Result{}, nil
+		// VolumeReplicationClasses are only required for async replication
+		meta.RemoveStatusCondition(&drpolicy.Status.Conditions, DRPolicyConditionVRCDistributed)
+		meta.RemoveStatusCondition(&drpolicy.Status.Conditions, DRPolicyConditionCapabilitiesSupported)
+		meta.RemoveStatusCondition(&drpolicy.Status.Conditions, DRPolicyConditionReplicationParametersValid)
+		return ctrl.Result{}, r.updateDRPolicyStatus(ctx, &drpolicy)
 	}
 
-	err = r.createOrUpdateManifestWorkForVRC(ctx, mirrorPeer, &drpolicy)
+	parameters, err := r.getReplicationParameters(ctx, &drpolicy)
+	var invalidParametersErr *invalidReplicationParametersError
+	if errors.As(err, &invalidParametersErr) {
+		// Retrying does not help, the DRPolicy is reconciled again once its annotations or ConfigMap change
+		logger.Error("Invalid replication parameters", "error", err)
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionReplicationParametersValid, metav1.ConditionFalse,
+			DRPolicyReasonReplicationParametersInvalid, err.Error())
+		return ctrl.Result{}, r.updateDRPolicyStatus(ctx, &drpolicy)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.setDRPolicyCondition(&drpolicy, DRPolicyConditionReplicationParametersValid, metav1.ConditionTrue,
+		DRPolicyReasonReplicationParametersValid, "The replication parameters of the DRPolicy are valid")
+
+	manifestWorks, missingCapabilities, err := r.createOrUpdateManifestWorkForVRC(ctx, mirrorPeer, &drpolicy, parameters)
 	if err != nil {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionVRCDistributed, metav1.ConditionFalse, DRPolicyReasonManifestWorkFailed,
+			fmt.Sprintf("Failed to create VolumeReplicationClass via ManifestWork: %v", err))
+		if statusErr := r.updateDRPolicyStatus(ctx, &drpolicy); statusErr != nil {
+			logger.Error("Failed to update DRPolicy status", "error", statusErr)
+		}
 		return ctrl.Result{}, fmt.Errorf("failed to create VolumeReplicationClass via ManifestWork: %v", err)
 	}
 
+	if len(missingCapabilities) > 0 {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionCapabilitiesSupported, metav1.ConditionFalse, DRPolicyReasonCapabilitiesMissing,
+			fmt.Sprintf("Replication classes requiring missing capabilities are not distributed: %s", utils.FormatMissingCapabilities(missingCapabilities)))
+	} else {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionCapabilitiesSupported, metav1.ConditionTrue, DRPolicyReasonCapabilitiesSupported,
+			fmt.Sprintf("Both peers support the required capabilities %v", getDRPolicyRequiredCapabilities(&drpolicy)))
+	}
+
+	notApplied, err := r.getManifestWorksNotApplied(ctx, manifestWorks)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(notApplied) > 0 {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionVRCDistributed, metav1.ConditionFalse, DRPolicyReasonManifestWorkNotApplied,
+			fmt.Sprintf("VolumeReplicationClass ManifestWorks are not yet applied: %v", notApplied))
+	} else {
+		r.setDRPolicyCondition(&drpolicy, DRPolicyConditionVRCDistributed, metav1.ConditionTrue, DRPolicyReasonManifestWorkApplied,
+			fmt.Sprintf("VolumeReplicationClass ManifestWorks are applied: %v", manifestWorks))
+	}
+
+	if err := r.updateDRPolicyStatus(ctx, &drpolicy); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	logger.Info("Successfully reconciled DRPolicy")
 	return ctrl.Result{}, nil
 }
 
-func (r *DRPolicyReconciler) createOrUpdateManifestWorkForVRC(ctx context.Context, mp *multiclusterv1alpha1.MirrorPeer, dp *ramenv1alpha1.DRPolicy) error {
+// setDRPolicyCondition sets an orchestrator owned condition on the DRPolicy and records an Event when the status
+// or the reason of the condition changes.
+func (r *DRPolicyReconciler) setDRPolicyCondition(dp *ramenv1alpha1.DRPolicy, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	existing := meta.FindStatusCondition(dp.Status.Conditions, conditionType)
+	if r.Recorder != nil && (existing == nil || existing.Status != status || existing.Reason != reason) {
+		eventType := corev1.EventTypeNormal
+		if status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Event(dp, eventType, reason, message)
+	}
+	meta.SetStatusCondition(&dp.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: dp.Generation,
+	})
+}
+
+// drPolicyConditionTypes are the conditions of the DRPolicy status owned by the orchestrator. The other conditions are
+// owned by Ramen.
+var drPolicyConditionTypes = []string{
+	DRPolicyConditionMirrorPeerFound,
+	DRPolicyConditionMirrorPeerReady,
+	DRPolicyConditionVRCDistributed,
+	DRPolicyConditionCapabilitiesSupported,
+	DRPolicyConditionReplicationParametersValid,
+}
+
+// updateDRPolicyStatus patches the orchestrator owned conditions of the DRPolicy onto its latest status, leaving the
+// conditions of Ramen untouched. The patch fails with a conflict when the status changed since it was read.
+func (r *DRPolicyReconciler) updateDRPolicyStatus(ctx context.Context, dp *ramenv1alpha1.DRPolicy) error {
+	var latest ramenv1alpha1.DRPolicy
+	if err := r.HubClient.Get(ctx, client.ObjectKeyFromObject(dp), &latest); err != nil {
+		return fmt.Errorf("failed to get DRPolicy %q: %w", dp.Name, err)
+	}
+	original := latest.DeepCopy()
+	for _, conditionType := range drPolicyConditionTypes {
+		if condition := meta.FindStatusCondition(dp.Status.Conditions, conditionType); condition != nil {
+			meta.SetStatusCondition(&latest.Status.Conditions, *condition)
+		} else {
+			meta.RemoveStatusCondition(&latest.Status.Conditions, conditionType)
+		}
+	}
+	if !equality.Semantic.DeepEqual(original.Status, latest.Status) {
+		if err := r.HubClient.Status().Patch(ctx, &latest, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return fmt.Errorf("failed to update status of DRPolicy %q: %w", dp.Name, err)
+		}
+	}
+	recordDRPolicyMetrics(&latest)
+	return nil
+}
+
+// getManifestWorksNotApplied returns the ManifestWorks which have not been applied on the managed clusters yet.
+func (r *DRPolicyReconciler) getManifestWorksNotApplied(ctx context.Context, manifestWorks []types.NamespacedName) ([]types.NamespacedName, error) {
+	var notApplied []types.NamespacedName
+	for _, key := range manifestWorks {
+		var mw workv1.ManifestWork
+		if err := r.HubClient.Get(ctx, key, &mw); err != nil {
+			return nil, fmt.Errorf("failed to get ManifestWork %q: %w", key.String(), err)
+		}
+		if !meta.IsStatusConditionTrue(mw.Status.Conditions, workv1.WorkApplied) {
+			notApplied = append(notApplied, key)
+		}
+	}
+	return notApplied, nil
+}
+
+// isMirrorPeerReady returns true once the MirrorPeer has finished exchanging secrets or syncing S3 profiles.
+func isMirrorPeerReady(mp *multiclusterv1alpha1.MirrorPeer) bool {
+	return mp.Status.Phase == multiclusterv1alpha1.ExchangedSecret || mp.Status.Phase == multiclusterv1alpha1.S3ProfileSynced
+}
+
+// isMirrorPeerForClusters returns true if the MirrorPeer peers exactly the given clusters.
+func isMirrorPeerForClusters(mp *multiclusterv1alpha1.MirrorPeer, clusters []string) bool {
+	if len(mp.Spec.Items) != len(clusters) {
+		return false
+	}
+	for _, pr := range mp.Spec.Items {
+		if !slices.Contains(clusters, pr.ClusterName) {
+			return false
+		}
+	}
+	return true
+}
+
+// mirrorPeerPhaseChangedPredicate passes MirrorPeer updates which change the phase of the MirrorPeer.
+func mirrorPeerPhaseChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldMP, ok := e.ObjectOld.(*multiclusterv1alpha1.MirrorPeer)
+			if !ok {
+				return false
+			}
+			newMP, ok := e.ObjectNew.(*multiclusterv1alpha1.MirrorPeer)
+			if !ok {
+				return false
+			}
+			return oldMP.Status.Phase != newMP.Status.Phase
+		},
+	}
+}
+
+// createOrUpdateManifestWorkForVRC distributes the replication classes of the DRPolicy to the peers of the MirrorPeer.
+// Replication classes relying on capabilities which are missing on a peer are left out and the missing capabilities
+// are returned.
+func (r *DRPolicyReconciler) createOrUpdateManifestWorkForVRC(ctx context.Context, mp *multiclusterv1alpha1.MirrorPeer, dp *ramenv1alpha1.DRPolicy, customParameters *replicationParameters) ([]types.NamespacedName, map[string][]utils.Capability, error) {
 	logger := r.Logger.With("DRPolicy", dp.Name, "MirrorPeer", mp.Name)
 
-	var vrcList []replicationv1alpha1.VolumeReplicationClass
+	clientInfoMap, err := utils.FetchClientInfo(ctx, r.HubClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missingCapabilities, err := utils.GetMissingCapabilities(ctx, r.HubClient, getPeerProviderClusters(*mp, clientInfoMap), getDRPolicyRequiredCapabilities(dp))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parameters := getReplicationClassParameters(dp.Spec.SchedulingInterval, customParameters.common)
+	classNameSuffix := getReplicationClassNameSuffix(dp.Spec.SchedulingInterval, customParameters.common, "")
+
+	var vrcList []client.Object
 
 	vrc := replicationv1alpha1.VolumeReplicationClass{
 		TypeMeta: metav1.TypeMeta{
@@ -137,49 +473,73 @@ func (r *DRPolicyReconciler) createOrUpdateManifestWorkForVRC(ctx context.Contex
 			APIVersion: "replication.storage.openshift.io/v1alpha1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: fmt.Sprintf(RBDVolumeReplicationClassNameTemplate, utils.FnvHash(dp.Spec.SchedulingInterval)),
+			Name: fmt.Sprintf(RBDVolumeReplicationClassNameTemplate, classNameSuffix),
 			Annotations: map[string]string{
 				RBDVolumeReplicationClassDefaultAnnotation: "true",
 			},
 		},
 		Spec: replicationv1alpha1.VolumeReplicationClassSpec{
-			Parameters: map[string]string{
-				MirroringModeKey:      DefaultMirroringMode,
-				SchedulingIntervalKey: dp.Spec.SchedulingInterval,
-			},
+			Parameters:  parameters,
 			Provisioner: RBDProvisionerName,
 		},
 	}
-	vrcList = append(vrcList, vrc)
+	// The classes of the pools with parameters of their own are not the default classes of the provisioner
+	rbdClasses := []*replicationv1alpha1.VolumeReplicationClass{&vrc}
+	rbdClassNameSuffixes := []string{classNameSuffix}
+	for _, pool := range slices.Sorted(maps.Keys(customParameters.pools)) {
+		poolParameters := maps.Clone(customParameters.common)
+		maps.Copy(poolParameters, customParameters.pools[pool])
+		poolClassNameSuffix := getReplicationClassNameSuffix(dp.Spec.SchedulingInterval, poolParameters, pool)
 
-	if dp.Spec.ReplicationClassSelector.MatchLabels[RBDFlattenVolumeReplicationClassLabelKey] == RBDFlattenVolumeReplicationClassLabelValue {
-		vrcFlatten := *vrc.DeepCopy()
-		vrcFlatten.Name = fmt.Sprintf(RBDFlattenVolumeReplicationClassNameTemplate, utils.FnvHash(dp.Spec.SchedulingInterval))
-		vrcFlatten.Labels = map[string]string{
-			RBDFlattenVolumeReplicationClassLabelKey: RBDFlattenVolumeReplicationClassLabelValue,
+		poolVRC := vrc.DeepCopy()
+		poolVRC.Name = fmt.Sprintf(RBDVolumeReplicationClassNameTemplate, poolClassNameSuffix)
+		poolVRC.Labels = map[string]string{BlockPoolLabelKey: pool}
+		poolVRC.Annotations = map[string]string{}
+		poolVRC.Spec.Parameters = getReplicationClassParameters(dp.Spec.SchedulingInterval, poolParameters)
+		rbdClasses = append(rbdClasses, poolVRC)
+		rbdClassNameSuffixes = append(rbdClassNameSuffixes, poolClassNameSuffix)
+	}
+	for _, rbdClass := range rbdClasses {
+		vrcList = append(vrcList, rbdClass)
+	}
+
+	if dp.Spec.ReplicationClassSelector.MatchLabels[RBDFlattenVolumeReplicationClassLabelKey] == RBDFlattenVolumeReplicationClassLabelValue &&
+		!hasMissingCapability(missingCapabilities, utils.CapabilityFlattenMode) {
+		for i, rbdClass := range rbdClasses {
+			vrcFlatten := *rbdClass.DeepCopy()
+			vrcFlatten.Name = fmt.Sprintf(RBDFlattenVolumeReplicationClassNameTemplate, rbdClassNameSuffixes[i])
+			if vrcFlatten.Labels == nil {
+				vrcFlatten.Labels = map[string]string{}
+			}
+			vrcFlatten.Labels[RBDFlattenVolumeReplicationClassLabelKey] = RBDFlattenVolumeReplicationClassLabelValue
+			vrcFlatten.Annotations = map[string]string{}
+			vrcFlatten.Spec.Parameters["flattenMode"] = "force"
+			vrcList = append(vrcList, &vrcFlatten)
 		}
-		vrcFlatten.Annotations = map[string]string{}
-		vrcFlatten.Spec.Parameters["flattenMode"] = "force"
-		vrcList = append(vrcList, vrcFlatten)
 	}
 
-	cm, err := utils.FetchClientInfoConfigMap(ctx, r.HubClient, r.CurrentNamespace)
-	if err != nil {
-		return err
+	// VolumeGroupReplicationClasses can not be distributed through StorageConsumers, they are applied directly on
+	// the clusters of the peers by a ManifestWork of their own
+	var vgrcList []client.Object
+	if isConsistencyGroupEnabled(dp) && !hasMissingCapability(missingCapabilities, utils.CapabilityVolumeGroupReplication) {
+		logger.Info("Consistency groups are enabled for DRPolicy. Generating VolumeGroupReplicationClasses")
+		vgrcList = getVolumeGroupReplicationClasses(dp, classNameSuffix, parameters)
 	}
 
 	manifestWorkName := fmt.Sprintf("vrc-%v", utils.FnvHash(dp.Name))
+	vgrcManifestWorkName := fmt.Sprintf("vgrc-%v", utils.FnvHash(dp.Name))
+	var manifestWorks []types.NamespacedName
 	for _, pr := range mp.Spec.Items {
-		cInfo, err := utils.GetClientInfoFromConfigMap(cm.Data, utils.GetKey(pr.ClusterName, pr.StorageClusterRef.Name))
+		cInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, pr)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		var manifestList []workv1.Manifest
 		for _, vrc := range vrcList {
 			vrcTemplateJson, err := getTemplateForVRC(vrc, cInfo.ProviderInfo.NamespacedName.Namespace)
 			if err != nil {
-				return fmt.Errorf("failed to get template for VRC %q, error %w", vrc.Name, err)
+				return nil, nil, fmt.Errorf("failed to get template for %s %q, error %w", vrc.GetObjectKind().GroupVersionKind().Kind, vrc.GetName(), err)
 			}
 			manifestList = append(manifestList, workv1.Manifest{
 				RawExtension: runtime.RawExtension{
@@ -188,42 +548,264 @@ func (r *DRPolicyReconciler) createOrUpdateManifestWorkForVRC(ctx context.Contex
 			})
 		}
 
-		mw := workv1.ManifestWork{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      manifestWorkName,
-				Namespace: cInfo.ProviderInfo.ProviderManagedClusterName,
-				OwnerReferences: []metav1.OwnerReference{
-					{
-						Kind:       dp.Kind,
-						Name:       dp.Name,
-						UID:        dp.UID,
-						APIVersion: dp.APIVersion,
-					},
+		key := types.NamespacedName{Name: manifestWorkName, Namespace: cInfo.ProviderInfo.ProviderManagedClusterName}
+		if err := r.createOrUpdateManifestWork(ctx, dp, key, manifestList); err != nil {
+			logger.Error("Failed to create/update ManifestWork", "ManifestWorkName", manifestWorkName, "error", err)
+			return nil, nil, err
+		}
+		logger.Info("ManifestWork created/updated successfully", "ManifestWorkName", manifestWorkName, "ReplicationClassCount", len(vrcList))
+		manifestWorks = append(manifestWorks, key)
+
+		vgrcKey := types.NamespacedName{Name: vgrcManifestWorkName, Namespace: pr.ClusterName}
+		if len(vgrcList) == 0 {
+			if err := r.deleteManifestWork(ctx, vgrcKey); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		var vgrcManifestList []workv1.Manifest
+		for _, vgrc := range vgrcList {
+			vgrcJson, err := json.Marshal(vgrc)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal %s %q to JSON, error %w", VolumeGroupReplicationClassKind, vgrc.GetName(), err)
+			}
+			vgrcManifestList = append(vgrcManifestList, workv1.Manifest{
+				RawExtension: runtime.RawExtension{
+					Raw: vgrcJson,
 				},
-			},
+			})
 		}
+		if err := r.createOrUpdateManifestWork(ctx, dp, vgrcKey, vgrcManifestList); err != nil {
+			logger.Error("Failed to create/update ManifestWork", "ManifestWorkName", vgrcManifestWorkName, "error", err)
+			return nil, nil, err
+		}
+		logger.Info("ManifestWork created/updated successfully", "ManifestWorkName", vgrcManifestWorkName, "ReplicationClassCount", len(vgrcList))
+		manifestWorks = append(manifestWorks, vgrcKey)
+	}
 
-		_, err = controllerutil.CreateOrUpdate(ctx, r.HubClient, &mw, func() error {
-			mw.Spec = workv1.ManifestWorkSpec{
-				Workload: workv1.ManifestsTemplate{
-					Manifests: manifestList,
+	return manifestWorks, missingCapabilities, nil
+}
+
+// createOrUpdateManifestWork creates or updates the ManifestWork of the DRPolicy with the given manifests
+func (r *DRPolicyReconciler) createOrUpdateManifestWork(ctx context.Context, dp *ramenv1alpha1.DRPolicy, key types.NamespacedName, manifests []workv1.Manifest) error {
+	mw := workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       dp.Kind,
+					Name:       dp.Name,
+					UID:        dp.UID,
+					APIVersion: dp.APIVersion,
 				},
-			}
-			return nil
-		})
+			},
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.HubClient, &mw, func() error {
+		mw.Spec = workv1.ManifestWorkSpec{
+			Workload: workv1.ManifestsTemplate{
+				Manifests: manifests,
+			},
+		}
+		return nil
+	})
+	return err
+}
+
+// deleteManifestWork deletes the ManifestWork if it exists
+func (r *DRPolicyReconciler) deleteManifestWork(ctx context.Context, key types.NamespacedName) error {
+	mw := workv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	if err := r.HubClient.Delete(ctx, &mw); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete ManifestWork %q: %w", key.String(), err)
+	}
+	return nil
+}
+
+// replicationParameters are the custom VolumeReplicationClass parameters requested on a DRPolicy
+type replicationParameters struct {
+	// common parameters apply to all the replication classes of the DRPolicy
+	common map[string]string
+	// pools holds the parameters scoped to an RBD block pool by ReplicationParametersPoolPrefix
+	pools map[string]map[string]string
+}
+
+// invalidReplicationParametersError reports replication parameters which have to be fixed on the DRPolicy or its
+// ConfigMap, retrying does not help
+type invalidReplicationParametersError struct {
+	err error
+}
+
+func (e *invalidReplicationParametersError) Error() string {
+	return e.err.Error()
+}
 
+func (e *invalidReplicationParametersError) Unwrap() error {
+	return e.err
+}
+
+// getReplicationParameters returns the custom VolumeReplicationClass parameters requested on the DRPolicy.
+// Parameters are read from the ConfigMap referenced by ReplicationParametersConfigMapAnnotationKey and then
+// from ReplicationParametersAnnotationKey. The merged parameters are validated before being returned, an
+// invalidReplicationParametersError is returned for invalid parameters and a missing ConfigMap.
+func (r *DRPolicyReconciler) getReplicationParameters(ctx context.Context, dp *ramenv1alpha1.DRPolicy) (*replicationParameters, error) {
+	parameters := make(map[string]string)
+
+	if cmName, ok := dp.Annotations[ReplicationParametersConfigMapAnnotationKey]; ok {
+		cm, err := utils.FetchConfigMap(ctx, r.HubClient, cmName, r.CurrentNamespace)
+		if k8serrors.IsNotFound(err) {
+			return nil, &invalidReplicationParametersError{
+				err: fmt.Errorf("replication parameters ConfigMap %q referenced by DRPolicy %q not found in namespace %q", cmName, dp.Name, r.CurrentNamespace),
+			}
+		}
 		if err != nil {
-			logger.Error("Failed to create/update ManifestWork", "ManifestWorkName", manifestWorkName, "error", err)
-			return err
+			return nil, fmt.Errorf("failed to fetch replication parameters ConfigMap %q referenced by DRPolicy %q: %w", cmName, dp.Name, err)
 		}
+		for k, v := range cm.Data {
+			parameters[k] = v
+		}
+	}
 
-		logger.Info("ManifestWork created/updated successfully", "ManifestWorkName", manifestWorkName, "VolumeReplicationClassName", vrc.Name)
+	if value, ok := dp.Annotations[ReplicationParametersAnnotationKey]; ok {
+		var annotationParameters map[string]string
+		if err := json.Unmarshal([]byte(value), &annotationParameters); err != nil {
+			return nil, &invalidReplicationParametersError{
+				err: fmt.Errorf("validation: annotation %q on DRPolicy %q must be a JSON object of strings: %w", ReplicationParametersAnnotationKey, dp.Name, err),
+			}
+		}
+		for k, v := range annotationParameters {
+			parameters[k] = v
+		}
 	}
 
-	return nil
+	result := &replicationParameters{common: map[string]string{}, pools: map[string]map[string]string{}}
+	for k, v := range parameters {
+		poolParameter, ok := strings.CutPrefix(k, ReplicationParametersPoolPrefix)
+		if !ok {
+			result.common[k] = v
+			continue
+		}
+		// Pool names may contain dots, parameter names do not
+		i := strings.LastIndex(poolParameter, ".")
+		if i < 0 {
+			return nil, &invalidReplicationParametersError{
+				err: fmt.Errorf("validation: parameter %q must be in the %s<pool>.<parameter> format", k, ReplicationParametersPoolPrefix),
+			}
+		}
+		pool, name := poolParameter[:i], poolParameter[i+1:]
+		errs := validation.IsValidLabelValue(pool)
+		if pool == "" {
+			errs = append(errs, "must not be empty")
+		}
+		if len(errs) > 0 {
+			return nil, &invalidReplicationParametersError{
+				err: fmt.Errorf("validation: invalid pool name %q in parameter %q: %s", pool, k, strings.Join(errs, ", ")),
+			}
+		}
+		if result.pools[pool] == nil {
+			result.pools[pool] = map[string]string{}
+		}
+		result.pools[pool][name] = v
+	}
+
+	if err := validateReplicationParameters(result.common); err != nil {
+		return nil, &invalidReplicationParametersError{err: err}
+	}
+	for pool, poolParameters := range result.pools {
+		if err := validateReplicationParameters(poolParameters); err != nil {
+			return nil, &invalidReplicationParametersError{err: fmt.Errorf("pool %q: %w", pool, err)}
+		}
+	}
+
+	return result, nil
+}
+
+// getReplicationClassParameters returns the parameters of a VolumeReplicationClass of the DRPolicy. The scheduling
+// interval only applies to snapshot mirroring, journal mirroring replicates every write.
+func getReplicationClassParameters(schedulingInterval string, customParameters map[string]string) map[string]string {
+	parameters := map[string]string{
+		MirroringModeKey:      DefaultMirroringMode,
+		SchedulingIntervalKey: schedulingInterval,
+	}
+	maps.Copy(parameters, customParameters)
+	if parameters[MirroringModeKey] == JournalMirroringMode {
+		delete(parameters, SchedulingIntervalKey)
+	}
+	return parameters
+}
+
+// getReplicationClassNameSuffix returns the suffix used in the names of the generated replication classes.
+// Without custom parameters the suffix only depends on the scheduling interval, which keeps the names of
+// existing classes unchanged. The classes of a pool also depend on its name, it is empty for the other classes.
+func getReplicationClassNameSuffix(schedulingInterval string, customParameters map[string]string, pool string) string {
+	if len(customParameters) == 0 && pool == "" {
+		return fmt.Sprint(utils.FnvHash(schedulingInterval))
+	}
+	keys := make([]string, 0, len(customParameters))
+	for k := range customParameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(schedulingInterval)
+	for _, k := range keys {
+		fmt.Fprintf(&b, ";%s=%s", k, customParameters[k])
+	}
+	if pool != "" {
+		fmt.Fprintf(&b, ";%s%s", ReplicationParametersPoolPrefix, pool)
+	}
+	return fmt.Sprint(utils.FnvHash(b.String()))
+}
+
+// isConsistencyGroupEnabled returns true if the DRPolicy asks for consistency group replication, either through
+// the ReplicationClassSelector or through the consistency group annotation on the DRPolicy.
+func isConsistencyGroupEnabled(dp *ramenv1alpha1.DRPolicy) bool {
+	if dp.Spec.ReplicationClassSelector.MatchLabels[ConsistencyGroupKey] == ConsistencyGroupEnabledValue {
+		return true
+	}
+	return dp.Annotations[ConsistencyGroupKey] == ConsistencyGroupEnabledValue
+}
+
+// getVolumeGroupReplicationClasses returns the RBD and CephFS VolumeGroupReplicationClasses for the DRPolicy.
+// The classes carry the labels of the ReplicationClassSelector so that Ramen can select them. Every DRPolicy
+// generates classes for the same provisioners, so none of them is marked as the default class of its provisioner.
+// VolumeGroupReplicationClass is not part of the csi-addons API version in use, hence the unstructured objects.
+func getVolumeGroupReplicationClasses(dp *ramenv1alpha1.DRPolicy, nameSuffix string, parameters map[string]string) []client.Object {
+	labels := map[string]string{
+		ConsistencyGroupKey: ConsistencyGroupEnabledValue,
+	}
+	for k, v := range dp.Spec.ReplicationClassSelector.MatchLabels {
+		labels[k] = v
+	}
+
+	provisioners := map[string]string{
+		RBDVolumeGroupReplicationClassNameTemplate:    RBDProvisionerName,
+		CephFSVolumeGroupReplicationClassNameTemplate: CephFSProvisionerName,
+	}
+
+	var vgrcList []client.Object
+	for _, nameTemplate := range []string{RBDVolumeGroupReplicationClassNameTemplate, CephFSVolumeGroupReplicationClassNameTemplate} {
+		vgrc := &unstructured.Unstructured{}
+		vgrc.SetAPIVersion(replicationv1alpha1.GroupVersion.String())
+		vgrc.SetKind(VolumeGroupReplicationClassKind)
+		vgrc.SetName(fmt.Sprintf(nameTemplate, nameSuffix))
+		vgrc.SetLabels(labels)
+		vgrcParameters := make(map[string]interface{}, len(parameters))
+		for k, v := range parameters {
+			vgrcParameters[k] = v
+		}
+		vgrc.Object["spec"] = map[string]interface{}{
+			"provisioner": provisioners[nameTemplate],
+			"parameters":  vgrcParameters,
+		}
+		vgrcList = append(vgrcList, vgrc)
+	}
+
+	return vgrcList
 }
 
-func getTemplateForVRC(vrc replicationv1alpha1.VolumeReplicationClass, templateNamespace string) ([]byte, error) {
+func getTemplateForVRC(vrc client.Object, templateNamespace string) ([]byte, error) {
 	vrcJson, err := json.Marshal(vrc)
 	if err != nil {
 		return []byte{}, fmt.Errorf("failed to marshal %v to JSON, error %w", vrc, err)
@@ -235,7 +817,7 @@ func getTemplateForVRC(vrc replicationv1alpha1.VolumeReplicationClass, templateN
 			APIVersion: "template.openshift.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      vrc.Name,
+			Name:      vrc.GetName(),
 			Namespace: templateNamespace,
 			Labels: map[string]string{
 				utils.CreatedByLabelKey: utils.CreatorMulticlusterOrchestrator,
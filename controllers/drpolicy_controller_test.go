@@ -2,17 +2,26 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
 	"testing"
 
+	replicationv1alpha1 "github.com/csi-addons/kubernetes-csi-addons/apis/replication.storage/v1alpha1"
+	templatev1 "github.com/openshift/api/template/v1"
 	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
-	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -83,46 +92,557 @@ func TestDRPolicyReconcile(t *testing.T) {
 
 }
 
-func getFakeDRPolicyReconciler(drpolicy *ramenv1alpha1.DRPolicy, mp *multiclusterv1alpha1.MirrorPeer) DRPolicyReconciler {
-	scheme := mgrScheme
-	os.Setenv("POD_NAMESPACE", "openshift-operators")
-	ns1 := &corev1.Namespace{
+func TestDRPolicyReconcileWithConsistencyGroups(t *testing.T) {
+	mirrorpeer := multiclusterv1alpha1.MirrorPeer{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: cName1,
+			Name: mpName,
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Async,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       cName1,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
+				},
+				{
+					ClusterName:       cName2,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
+				},
+			},
 		},
 	}
-	ns2 := &corev1.Namespace{
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		matchLabels map[string]string
+		wantVGRCs   bool
+	}{
+		{
+			name: "consistency groups disabled",
+		},
+		{
+			name:        "consistency groups enabled through ReplicationClassSelector",
+			matchLabels: map[string]string{ConsistencyGroupKey: ConsistencyGroupEnabledValue},
+			wantVGRCs:   true,
+		},
+		{
+			name:        "consistency groups enabled through annotation",
+			annotations: map[string]string{ConsistencyGroupKey: ConsistencyGroupEnabledValue},
+			wantVGRCs:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			drpolicy := ramenv1alpha1.DRPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        drpName,
+					Annotations: c.annotations,
+				},
+				Spec: ramenv1alpha1.DRPolicySpec{
+					SchedulingInterval: "5m",
+					DRClusters:         []string{cName1, cName2},
+					ReplicationClassSelector: metav1.LabelSelector{
+						MatchLabels: c.matchLabels,
+					},
+				},
+			}
+
+			r := getFakeDRPolicyReconciler(&drpolicy, mirrorpeer.DeepCopy())
+			ctx := context.TODO()
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: drpName}})
+			if err != nil {
+				t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+			}
+
+			for _, clusterName := range []string{cName1, cName2} {
+				// Only the VolumeReplicationClass is distributed to the StorageConsumers through Templates
+				var mw workv1.ManifestWork
+				err = r.HubClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("vrc-%v", utils.FnvHash(drpName)), Namespace: clusterName}, &mw)
+				if err != nil {
+					t.Fatalf("failed to get ManifestWork. Error: %s", err)
+				}
+				if len(mw.Spec.Workload.Manifests) != 1 {
+					t.Fatalf("expected 1 manifest, got %d", len(mw.Spec.Workload.Manifests))
+				}
+				var template templatev1.Template
+				if err := json.Unmarshal(mw.Spec.Workload.Manifests[0].Raw, &template); err != nil {
+					t.Fatalf("failed to unmarshal Template. Error: %s", err)
+				}
+				var vrc replicationv1alpha1.VolumeReplicationClass
+				if err := json.Unmarshal(template.Objects[0].Raw, &vrc); err != nil {
+					t.Fatalf("failed to unmarshal VolumeReplicationClass. Error: %s", err)
+				}
+				if vrc.Kind != "VolumeReplicationClass" {
+					t.Errorf("expected a VolumeReplicationClass Template, got %q", vrc.Kind)
+				}
+
+				// The VolumeGroupReplicationClasses are applied on the cluster of the peer
+				var vgrcWork workv1.ManifestWork
+				err = r.HubClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("vgrc-%v", utils.FnvHash(drpName)), Namespace: clusterName}, &vgrcWork)
+				if !c.wantVGRCs {
+					if !k8serrors.IsNotFound(err) {
+						t.Fatalf("expected no VolumeGroupReplicationClass ManifestWork, got error %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("failed to get ManifestWork. Error: %s", err)
+				}
+
+				provisioners := map[string]bool{}
+				for _, manifest := range vgrcWork.Spec.Workload.Manifests {
+					var vgrc struct {
+						Kind     string            `json:"kind"`
+						Metadata metav1.ObjectMeta `json:"metadata"`
+						Spec     struct {
+							Provisioner string            `json:"provisioner"`
+							Parameters  map[string]string `json:"parameters"`
+						} `json:"spec"`
+					}
+					if err := json.Unmarshal(manifest.Raw, &vgrc); err != nil {
+						t.Fatalf("failed to unmarshal VolumeGroupReplicationClass. Error: %s", err)
+					}
+					if vgrc.Kind != VolumeGroupReplicationClassKind {
+						t.Errorf("expected kind %q, got %q", VolumeGroupReplicationClassKind, vgrc.Kind)
+					}
+					provisioners[vgrc.Spec.Provisioner] = true
+					expectedParameters := map[string]string{MirroringModeKey: DefaultMirroringMode, SchedulingIntervalKey: "5m"}
+					if !reflect.DeepEqual(vgrc.Spec.Parameters, expectedParameters) {
+						t.Errorf("expected parameters %v on %q, got %v", expectedParameters, vgrc.Metadata.Name, vgrc.Spec.Parameters)
+					}
+					if vgrc.Metadata.Labels[ConsistencyGroupKey] != ConsistencyGroupEnabledValue {
+						t.Errorf("expected %q to be labelled for consistency groups, got %v", vgrc.Metadata.Name, vgrc.Metadata.Labels)
+					}
+					if _, ok := vgrc.Metadata.Annotations[RBDVolumeReplicationClassDefaultAnnotation]; ok {
+						t.Errorf("expected %q not to be a default class", vgrc.Metadata.Name)
+					}
+				}
+				if !reflect.DeepEqual(provisioners, map[string]bool{RBDProvisionerName: true, CephFSProvisionerName: true}) {
+					t.Errorf("expected one VolumeGroupReplicationClass per provisioner, got %v", provisioners)
+				}
+			}
+
+			if !c.wantVGRCs {
+				return
+			}
+			// The VolumeGroupReplicationClasses are removed once consistency groups are disabled
+			var latest ramenv1alpha1.DRPolicy
+			if err := r.HubClient.Get(ctx, types.NamespacedName{Name: drpName}, &latest); err != nil {
+				t.Fatal(err)
+			}
+			delete(latest.Annotations, ConsistencyGroupKey)
+			delete(latest.Spec.ReplicationClassSelector.MatchLabels, ConsistencyGroupKey)
+			if err := r.HubClient.Update(ctx, &latest); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: drpName}}); err != nil {
+				t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+			}
+			var vgrcWork workv1.ManifestWork
+			err = r.HubClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("vgrc-%v", utils.FnvHash(drpName)), Namespace: cName1}, &vgrcWork)
+			if !k8serrors.IsNotFound(err) {
+				t.Errorf("expected the VolumeGroupReplicationClass ManifestWork to be deleted, got error %v", err)
+			}
+		})
+	}
+}
+
+func TestDRPolicyReconcileWithReplicationParameters(t *testing.T) {
+	mirrorpeer := multiclusterv1alpha1.MirrorPeer{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: cName2,
+			Name: mpName,
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Async,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       cName1,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
+				},
+				{
+					ClusterName:       cName2,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantInvalid bool
+		// wantParameters holds the parameters of the generated VolumeReplicationClasses, in the order of the manifests
+		wantParameters []map[string]string
+		wantPoolLabels []string
+	}{
+		{
+			name: "parameters from ConfigMap and annotation",
+			annotations: map[string]string{
+				ReplicationParametersConfigMapAnnotationKey: "replication-parameters",
+				ReplicationParametersAnnotationKey:          `{"schedulingStartTime":"14:00:00"}`,
+			},
+			wantParameters: []map[string]string{{
+				MirroringModeKey:         JournalMirroringMode,
+				SchedulingStartTimeKey:   "14:00:00",
+				ReplicationSecretNameKey: "rook-csi-rbd-provisioner",
+			}},
+			wantPoolLabels: []string{""},
+		},
+		{
+			name: "journal mirroring for a pool",
+			annotations: map[string]string{
+				ReplicationParametersAnnotationKey: `{"schedulingStartTime":"14:00:00","pools.journal-pool.mirroringMode":"journal"}`,
+			},
+			wantParameters: []map[string]string{
+				{
+					MirroringModeKey:       DefaultMirroringMode,
+					SchedulingIntervalKey:  "5m",
+					SchedulingStartTimeKey: "14:00:00",
+				},
+				{
+					MirroringModeKey:       JournalMirroringMode,
+					SchedulingStartTimeKey: "14:00:00",
+				},
+			},
+			wantPoolLabels: []string{"", "journal-pool"},
+		},
+		{
+			name: "unsupported parameter in annotation",
+			annotations: map[string]string{
+				ReplicationParametersAnnotationKey: `{"pool":"replicapool"}`,
+			},
+			wantInvalid: true,
+		},
+		{
+			name: "invalid parameter of a pool",
+			annotations: map[string]string{
+				ReplicationParametersAnnotationKey: `{"pools.journal-pool.mirroringMode":"pool"}`,
+			},
+			wantInvalid: true,
+		},
+		{
+			name: "missing ConfigMap",
+			annotations: map[string]string{
+				ReplicationParametersConfigMapAnnotationKey: "missing",
+			},
+			wantInvalid: true,
 		},
 	}
-	odfClientInfoConfigMap := &corev1.ConfigMap{
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			drpolicy := ramenv1alpha1.DRPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        drpName,
+					Annotations: c.annotations,
+				},
+				Spec: ramenv1alpha1.DRPolicySpec{
+					SchedulingInterval: "5m",
+					DRClusters:         []string{cName1, cName2},
+				},
+			}
+
+			r := getFakeDRPolicyReconciler(&drpolicy, mirrorpeer.DeepCopy())
+			ctx := context.TODO()
+			parametersConfigMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "replication-parameters",
+					Namespace: r.CurrentNamespace,
+				},
+				Data: map[string]string{
+					MirroringModeKey:         JournalMirroringMode,
+					ReplicationSecretNameKey: "rook-csi-rbd-provisioner",
+				},
+			}
+			if err := r.HubClient.Create(ctx, parametersConfigMap); err != nil {
+				t.Fatal(err)
+			}
+
+			// Invalid parameters are reported on the DRPolicy and not retried
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: drpName}})
+			if err != nil {
+				t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+			}
+			if !result.IsZero() {
+				t.Errorf("expected no requeue, got %v", result)
+			}
+			var gotDRPolicy ramenv1alpha1.DRPolicy
+			if err := r.HubClient.Get(ctx, types.NamespacedName{Name: drpName}, &gotDRPolicy); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(gotDRPolicy.Status.Conditions, DRPolicyConditionReplicationParametersValid)
+			if condition == nil || (condition.Status == metav1.ConditionFalse) != c.wantInvalid {
+				t.Fatalf("unexpected %s condition %v", DRPolicyConditionReplicationParametersValid, condition)
+			}
+
+			var mw workv1.ManifestWork
+			err = r.HubClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("vrc-%v", utils.FnvHash(drpName)), Namespace: cName1}, &mw)
+			if c.wantInvalid {
+				if !k8serrors.IsNotFound(err) {
+					t.Errorf("expected no ManifestWork for invalid parameters, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get ManifestWork. Error: %s", err)
+			}
+			if len(mw.Spec.Workload.Manifests) != len(c.wantParameters) {
+				t.Fatalf("expected %d VolumeReplicationClasses, got %d", len(c.wantParameters), len(mw.Spec.Workload.Manifests))
+			}
+			for i, manifest := range mw.Spec.Workload.Manifests {
+				var template templatev1.Template
+				if err := json.Unmarshal(manifest.Raw, &template); err != nil {
+					t.Fatalf("failed to unmarshal Template. Error: %s", err)
+				}
+				var vrc replicationv1alpha1.VolumeReplicationClass
+				if err := json.Unmarshal(template.Objects[0].Raw, &vrc); err != nil {
+					t.Fatalf("failed to unmarshal VolumeReplicationClass. Error: %s", err)
+				}
+				if !reflect.DeepEqual(vrc.Spec.Parameters, c.wantParameters[i]) {
+					t.Errorf("expected parameters %v, got %v", c.wantParameters[i], vrc.Spec.Parameters)
+				}
+				if vrc.Labels[BlockPoolLabelKey] != c.wantPoolLabels[i] {
+					t.Errorf("expected pool label %q on VolumeReplicationClass %q, got %v", c.wantPoolLabels[i], vrc.Name, vrc.Labels)
+				}
+				if vrc.Name == fmt.Sprintf(RBDVolumeReplicationClassNameTemplate, utils.FnvHash(drpolicy.Spec.SchedulingInterval)) {
+					t.Errorf("expected VolumeReplicationClass with custom parameters to have a distinct name, got %q", vrc.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDRPolicyReconcileConditions(t *testing.T) {
+	mirrorpeer := multiclusterv1alpha1.MirrorPeer{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "odf-client-info",
-			Namespace: utils.GetEnv("POD_NAMESPACE"),
-			OwnerReferences: []metav1.OwnerReference{
+			Name: mpName,
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Async,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       cName1,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
+				},
 				{
-					APIVersion: viewv1beta1.GroupVersion.String(),
-					Kind:       "ManagedClusterView",
-					Name:       "mcv-1",
-					UID:        "mcv-uid",
+					ClusterName:       cName2,
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: scName, Namespace: scNamespace},
 				},
 			},
 		},
-		Data: map[string]string{
-			"cluster-1_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\"}}",
-			"cluster-2_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\"}}",
+	}
+
+	drpolicy := ramenv1alpha1.DRPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: drpName,
+		},
+		Spec: ramenv1alpha1.DRPolicySpec{
+			SchedulingInterval: "5m",
+			DRClusters:         []string{cName1, cName2},
+		},
+	}
+
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: drpName}}
+
+	// Without a MirrorPeer the DRPolicy is requeued and reports MirrorPeerFound=False
+	r := getFakeDRPolicyReconciler(drpolicy.DeepCopy(), nil)
+	res, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Errorf("expected DRPolicy to be requeued when MirrorPeer is missing")
+	}
+	var got ramenv1alpha1.DRPolicy
+	if err := r.HubClient.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	cond := meta.FindStatusCondition(got.Status.Conditions, DRPolicyConditionMirrorPeerFound)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != DRPolicyReasonMirrorPeerNotFound {
+		t.Errorf("expected condition %s to be False with reason %s, got %v", DRPolicyConditionMirrorPeerFound, DRPolicyReasonMirrorPeerNotFound, cond)
+	}
+
+	// With a ready MirrorPeer and a ManifestWork which is not applied yet
+	mp := mirrorpeer.DeepCopy()
+	mp.Status.Phase = multiclusterv1alpha1.ExchangedSecret
+	r = getFakeDRPolicyReconciler(drpolicy.DeepCopy(), mp)
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+	}
+	if err := r.HubClient.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, DRPolicyConditionMirrorPeerFound) {
+		t.Errorf("expected condition %s to be True", DRPolicyConditionMirrorPeerFound)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, DRPolicyConditionMirrorPeerReady) {
+		t.Errorf("expected condition %s to be True", DRPolicyConditionMirrorPeerReady)
+	}
+	cond = meta.FindStatusCondition(got.Status.Conditions, DRPolicyConditionVRCDistributed)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != DRPolicyReasonManifestWorkNotApplied {
+		t.Errorf("expected condition %s to be False with reason %s, got %v", DRPolicyConditionVRCDistributed, DRPolicyReasonManifestWorkNotApplied, cond)
+	}
+
+	// Once the ManifestWorks are applied, VRCDistributed turns True
+	for _, cName := range []string{cName1, cName2} {
+		var mw workv1.ManifestWork
+		if err := r.HubClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("vrc-%v", utils.FnvHash(drpName)), Namespace: cName}, &mw); err != nil {
+			t.Fatalf("failed to get ManifestWork. Error: %s", err)
+		}
+		meta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{Type: workv1.WorkApplied, Status: metav1.ConditionTrue, Reason: "AppliedManifestWorkComplete"})
+		if err := r.HubClient.Update(ctx, &mw); err != nil {
+			t.Fatalf("failed to update ManifestWork status. Error: %s", err)
+		}
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("DRPolicyReconciler Reconcile() failed. Error: %s", err)
+	}
+	if err := r.HubClient.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, DRPolicyConditionVRCDistributed) {
+		t.Errorf("expected condition %s to be True", DRPolicyConditionVRCDistributed)
+	}
+}
+
+func TestUpdateDRPolicyStatusKeepsRamenConditions(t *testing.T) {
+	drpolicy := ramenv1alpha1.DRPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: drpName,
+		},
+		Spec: ramenv1alpha1.DRPolicySpec{
+			SchedulingInterval: "5m",
+			DRClusters:         []string{cName1, cName2},
 		},
 	}
+	ctx := context.TODO()
+	r := getFakeDRPolicyReconciler(drpolicy.DeepCopy(), nil)
+
+	// The DRPolicy read by the reconciler does not hold the condition Ramen sets afterwards
+	stale := drpolicy.DeepCopy()
+	if err := r.HubClient.Get(ctx, client.ObjectKeyFromObject(stale), stale); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	var ramen ramenv1alpha1.DRPolicy
+	if err := r.HubClient.Get(ctx, client.ObjectKeyFromObject(stale), &ramen); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	meta.SetStatusCondition(&ramen.Status.Conditions, metav1.Condition{Type: "Validated", Status: metav1.ConditionTrue, Reason: "Succeeded"})
+	if err := r.HubClient.Status().Update(ctx, &ramen); err != nil {
+		t.Fatalf("failed to update DRPolicy status. Error: %s", err)
+	}
+
+	r.setDRPolicyCondition(stale, DRPolicyConditionMirrorPeerFound, metav1.ConditionTrue, DRPolicyReasonMirrorPeerFound, "found")
+	if err := r.updateDRPolicyStatus(ctx, stale); err != nil {
+		t.Fatalf("updateDRPolicyStatus() failed. Error: %s", err)
+	}
 
-	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(drpolicy, mp, ns1, ns2, odfClientInfoConfigMap).Build()
+	var got ramenv1alpha1.DRPolicy
+	if err := r.HubClient.Get(ctx, client.ObjectKeyFromObject(stale), &got); err != nil {
+		t.Fatalf("failed to get DRPolicy. Error: %s", err)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, "Validated") {
+		t.Errorf("expected the Validated condition of Ramen to be kept, got %v", got.Status.Conditions)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, DRPolicyConditionMirrorPeerFound) {
+		t.Errorf("expected condition %s to be True, got %v", DRPolicyConditionMirrorPeerFound, got.Status.Conditions)
+	}
+}
+
+func getFakeDRPolicyReconciler(drpolicy *ramenv1alpha1.DRPolicy, mp *multiclusterv1alpha1.MirrorPeer) DRPolicyReconciler {
+	scheme := mgrScheme
+	os.Setenv("POD_NAMESPACE", "openshift-operators")
+	ns1 := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cName1,
+		},
+	}
+	ns2 := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cName2,
+		},
+	}
+	objects := []client.Object{
+		drpolicy, ns1, ns2,
+		getFakeStorageInventory(cName1, ""),
+		getFakeStorageInventory(cName2, ""),
+	}
+	if mp != nil {
+		objects = append(objects, mp)
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithStatusSubresource(drpolicy).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerClusterNameIndexKey, utils.MirrorPeerClusterNameIndexFunc).
+		WithIndex(&ramenv1alpha1.DRPolicy{}, utils.DRPolicyClusterNameIndexKey, utils.DRPolicyClusterNameIndexFunc).
+		Build()
 
 	r := DRPolicyReconciler{
 		HubClient:        fakeClient,
 		Scheme:           scheme,
 		Logger:           utils.GetLogger(utils.GetZapLogger(true)),
+		Recorder:         record.NewFakeRecorder(10),
 		CurrentNamespace: utils.GetEnv("POD_NAMESPACE"),
 	}
 
 	return r
 }
+
+// getFakeStorageInventory returns a StorageInventory reporting a single "test-storagecluster" StorageCluster without StorageClients
+func getFakeStorageInventory(clusterName, deploymentType string) *multiclusterv1alpha1.StorageInventory {
+	return &multiclusterv1alpha1.StorageInventory{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterName,
+		},
+		Spec: multiclusterv1alpha1.StorageInventorySpec{
+			ClusterName: clusterName,
+		},
+		Status: multiclusterv1alpha1.StorageInventoryStatus{
+			Providers: []multiclusterv1alpha1.StorageProvider{
+				{
+					Name:           "test-storagecluster",
+					Version:        "4.19.0",
+					DeploymentType: deploymentType,
+				},
+			},
+		},
+	}
+}
+
+func TestIsReplicationParametersConfigMap(t *testing.T) {
+	drpolicy := ramenv1alpha1.DRPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        drpName,
+			Annotations: map[string]string{ReplicationParametersConfigMapAnnotationKey: "replication-parameters"},
+		},
+	}
+	r := getFakeDRPolicyReconciler(&drpolicy, nil)
+
+	cases := []struct {
+		name      string
+		configMap *corev1.ConfigMap
+		want      bool
+	}{
+		{
+			name:      "referenced ConfigMap",
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "replication-parameters", Namespace: r.CurrentNamespace}},
+			want:      true,
+		},
+		{
+			name:      "ConfigMap not referenced by a DRPolicy",
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: r.CurrentNamespace}},
+		},
+		{
+			name:      "ConfigMap of another namespace",
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "replication-parameters", Namespace: "other"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := r.isReplicationParametersConfigMap(c.configMap); got != c.want {
+				t.Errorf("isReplicationParametersConfigMap() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -4,15 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -33,8 +44,13 @@ func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req reconcile.
 	if err := r.Client.Get(ctx, req.NamespacedName, &managedCluster); err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			logger.Error("Failed to get ManagedCluster", "error", err)
+			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		return ctrl.Result{}, r.detachManagedCluster(ctx, req.Name, logger)
+	}
+
+	if !managedCluster.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, r.detachManagedCluster(ctx, managedCluster.Name, logger)
 	}
 
 	if err := r.processManagedClusterViews(ctx, managedCluster); err != nil {
@@ -42,6 +58,11 @@ func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req reconcile.
 		return ctrl.Result{}, err
 	}
 
+	if err := r.updateClusterDetachedConditions(ctx, managedCluster.Name, logger); err != nil {
+		logger.Error("Failed to update ClusterDetached condition of MirrorPeers", "error", err)
+		return ctrl.Result{}, err
+	}
+
 	logger.Info("Successfully reconciled ManagedCluster")
 
 	return ctrl.Result{}, nil
@@ -64,6 +85,13 @@ func (r *ManagedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}
 			return utils.HasRequiredODFKey(obj)
 		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			obj, ok := e.Object.(*clusterv1.ManagedCluster)
+			if !ok {
+				return false
+			}
+			return utils.HasRequiredODFKey(obj)
+		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -73,9 +101,11 @@ func (r *ManagedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
+// processManagedClusterViews creates a ManagedClusterView for every odf-info ClusterClaim of the ManagedCluster and
+// removes the ManagedClusterViews and StorageInventories of odf-info ClusterClaims which are no longer published.
 func (r *ManagedClusterReconciler) processManagedClusterViews(ctx context.Context, managedCluster clusterv1.ManagedCluster) error {
 	resourceType := "ConfigMap"
-	odfInfoConfigMapNamespacedName, err := utils.GetNamespacedNameForClusterInfo(managedCluster)
+	odfInfoConfigMaps, err := utils.GetNamespacedNamesForClusterInfo(managedCluster)
 	if err != nil {
 		return fmt.Errorf("error while getting NamespacedName of the %s. %w", resourceType, err)
 	}
@@ -91,12 +121,226 @@ func (r *ManagedClusterReconciler) processManagedClusterViews(ctx context.Contex
 		BlockOwnerDeletion: &disabled,
 	}
 
-	mcv, operationResult, err := utils.CreateOrUpdateManagedClusterView(ctx, r.Client, odfInfoConfigMapNamespacedName.Name, odfInfoConfigMapNamespacedName.Namespace, resourceType, managedCluster.Name, mcvOwnerRef)
-	if err != nil {
-		return fmt.Errorf("failed to create or update ManagedClusterView. %w", err)
+	desiredMCVs := make([]string, 0, len(odfInfoConfigMaps))
+	for _, claimName := range slices.Sorted(maps.Keys(odfInfoConfigMaps)) {
+		odfInfoConfigMapNamespacedName := odfInfoConfigMaps[claimName]
+		mcvName := utils.GetODFInfoManagedClusterViewName(managedCluster.Name, claimName)
+		mcv, operationResult, err := utils.CreateOrUpdateManagedClusterView(ctx, r.Client, mcvName, odfInfoConfigMapNamespacedName.Name, odfInfoConfigMapNamespacedName.Namespace, resourceType, managedCluster.Name, mcvOwnerRef)
+		if err != nil {
+			return fmt.Errorf("failed to create or update ManagedClusterView %q. %w", mcvName, err)
+		}
+		r.Logger.Info(fmt.Sprintf("ManagedClusterView was %s", operationResult), "ManagedClusterView", mcv.Name, "ClusterClaim", claimName)
+		desiredMCVs = append(desiredMCVs, mcvName)
+	}
+
+	return r.deleteManagedClusterViews(ctx, managedCluster.Name, desiredMCVs)
+}
+
+// deleteManagedClusterViews deletes the odf-info ManagedClusterViews created by the orchestrator in the namespace of the
+// ManagedCluster which are not listed in keep, together with the StorageInventories populated from them.
+func (r *ManagedClusterReconciler) deleteManagedClusterViews(ctx context.Context, clusterName string, keep []string) error {
+	var mcvList viewv1beta1.ManagedClusterViewList
+	if err := r.Client.List(ctx, &mcvList, client.InNamespace(clusterName), client.MatchingLabels{utils.CreatedByLabelKey: utils.MCVCreatedByValue}); err != nil {
+		return fmt.Errorf("failed to list ManagedClusterViews in namespace %q: %w", clusterName, err)
+	}
+	for i := range mcvList.Items {
+		mcv := &mcvList.Items[i]
+		if slices.Contains(keep, mcv.Name) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, mcv); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ManagedClusterView %q: %w", mcv.Name, err)
+		}
+		r.Logger.Info("Deleted ManagedClusterView", "ManagedClusterView", mcv.Name, "Namespace", clusterName)
+
+		inventory := &multiclusterv1alpha1.StorageInventory{ObjectMeta: metav1.ObjectMeta{Name: utils.GetStorageInventoryName(*mcv)}}
+		if err := r.Client.Delete(ctx, inventory); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete StorageInventory %q: %w", inventory.Name, err)
+		}
+	}
+	return nil
+}
+
+// detachManagedCluster cleans up the hub resources created by the orchestrator for a ManagedCluster which is being
+// detached from the hub. MirrorPeers referencing the ManagedCluster are marked with the ClusterDetached condition.
+func (r *ManagedClusterReconciler) detachManagedCluster(ctx context.Context, clusterName string, logger *slog.Logger) error {
+	logger.Info("ManagedCluster is detached. Cleaning up orchestrator resources")
+
+	if err := r.updateClusterDetachedConditions(ctx, clusterName, logger); err != nil {
+		return fmt.Errorf("failed to mark MirrorPeers of ManagedCluster %q as detached: %w", clusterName, err)
+	}
+
+	if err := r.removeAgentFinalizers(ctx, clusterName, logger); err != nil {
+		return err
+	}
+
+	if err := r.deleteManagedClusterViews(ctx, clusterName, nil); err != nil {
+		return err
+	}
+
+	if err := r.deleteStorageInventoryEntries(ctx, clusterName, logger); err != nil {
+		return err
+	}
+	deleteStorageInventoryMetrics(clusterName)
 
+	var drCluster ramenv1alpha1.DRCluster
+	err := r.Client.Get(ctx, types.NamespacedName{Name: clusterName}, &drCluster)
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get DRCluster %q: %w", clusterName, err)
+	}
+	if err == nil && isOwnedByOrchestrator(&drCluster) {
+		if err := r.Client.Delete(ctx, &drCluster); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete DRCluster %q: %w", clusterName, err)
+		}
+		logger.Info("Deleted DRCluster", "DRCluster", clusterName)
 	}
-	r.Logger.Info(fmt.Sprintf("ManagedClusterView was %s", operationResult), "ManagedClusterView", mcv.Name)
 
+	var manifestWorkList workv1.ManifestWorkList
+	if err := r.Client.List(ctx, &manifestWorkList, client.InNamespace(clusterName)); err != nil {
+		return fmt.Errorf("failed to list ManifestWorks in namespace %q: %w", clusterName, err)
+	}
+	for i := range manifestWorkList.Items {
+		manifestWork := &manifestWorkList.Items[i]
+		if !isOwnedByOrchestrator(manifestWork) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, manifestWork); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ManifestWork %q: %w", manifestWork.Name, err)
+		}
+		logger.Info("Deleted ManifestWork", "ManifestWork", manifestWork.Name, "Namespace", manifestWork.Namespace)
+	}
+
+	logger.Info("Successfully cleaned up orchestrator resources of detached ManagedCluster")
 	return nil
 }
+
+// removeAgentFinalizers removes the finalizer of the addon agent running on the ManagedCluster from all MirrorPeers.
+// The agent can not clean up after the ManagedCluster has been detached and would otherwise block MirrorPeer deletion.
+// The legacy finalizer of an agent that was not upgraded before the detach is removed as well.
+func (r *ManagedClusterReconciler) removeAgentFinalizers(ctx context.Context, clusterName string, logger *slog.Logger) error {
+	agentFinalizers := []string{addons.GetAgentFinalizer(clusterName)}
+	if legacyAgentFinalizer := addons.GetLegacyAgentFinalizer(clusterName); legacyAgentFinalizer != "" {
+		agentFinalizers = append(agentFinalizers, legacyAgentFinalizer)
+	}
+
+	for _, agentFinalizer := range agentFinalizers {
+		var mirrorPeerList multiclusterv1alpha1.MirrorPeerList
+		if err := r.Client.List(ctx, &mirrorPeerList, client.MatchingFields{utils.MirrorPeerFinalizerIndexKey: agentFinalizer}); err != nil {
+			return fmt.Errorf("failed to list MirrorPeers with finalizer %q: %w", agentFinalizer, err)
+		}
+		for i := range mirrorPeerList.Items {
+			mirrorPeer := &mirrorPeerList.Items[i]
+			if !controllerutil.RemoveFinalizer(mirrorPeer, agentFinalizer) {
+				continue
+			}
+			if err := r.Client.Update(ctx, mirrorPeer); err != nil {
+				return fmt.Errorf("failed to remove finalizer %q from MirrorPeer %q: %w", agentFinalizer, mirrorPeer.Name, err)
+			}
+			logger.Info("Removed agent finalizer from MirrorPeer", "MirrorPeer", mirrorPeer.Name, "Finalizer", agentFinalizer)
+		}
+	}
+	return nil
+}
+
+// deleteStorageInventoryEntries deletes the StorageInventory of the ManagedCluster and removes the StorageClients
+// running on the ManagedCluster from the StorageInventories of other providers
+func (r *ManagedClusterReconciler) deleteStorageInventoryEntries(ctx context.Context, clusterName string, logger *slog.Logger) error {
+	var inventoryList multiclusterv1alpha1.StorageInventoryList
+	if err := r.Client.List(ctx, &inventoryList); err != nil {
+		return fmt.Errorf("failed to list StorageInventories: %w", err)
+	}
+	for i := range inventoryList.Items {
+		inventory := &inventoryList.Items[i]
+		if inventory.Spec.ClusterName == clusterName {
+			if err := r.Client.Delete(ctx, inventory); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete StorageInventory %q: %w", inventory.Name, err)
+			}
+			logger.Info("Deleted StorageInventory", "StorageInventory", inventory.Name)
+			continue
+		}
+
+		clients := slices.DeleteFunc(slices.Clone(inventory.Status.Clients), func(c multiclusterv1alpha1.StorageClient) bool {
+			return c.ManagedClusterName == clusterName
+		})
+		if len(clients) == len(inventory.Status.Clients) {
+			continue
+		}
+		inventory.Status.Clients = clients
+		if err := r.Client.Status().Update(ctx, inventory); err != nil {
+			return fmt.Errorf("failed to remove StorageClients from StorageInventory %q: %w", inventory.Name, err)
+		}
+		logger.Info("Removed StorageClients of detached ManagedCluster from StorageInventory", "StorageInventory", inventory.Name)
+	}
+	return nil
+}
+
+// updateClusterDetachedConditions updates the ClusterDetached condition of all MirrorPeers referencing the ManagedCluster.
+// The condition is only added once a ManagedCluster of the MirrorPeer is detached and turns False once all of them are attached again.
+func (r *ManagedClusterReconciler) updateClusterDetachedConditions(ctx context.Context, clusterName string, logger *slog.Logger) error {
+	mirrorPeers, err := utils.ListMirrorPeersForCluster(ctx, r.Client, clusterName)
+	if err != nil {
+		return err
+	}
+
+	for i := range mirrorPeers {
+		mirrorPeer := &mirrorPeers[i]
+
+		var detachedClusters []string
+		for _, peerRef := range mirrorPeer.Spec.Items {
+			detached, err := isManagedClusterDetached(ctx, r.Client, peerRef.ClusterName)
+			if err != nil {
+				return err
+			}
+			if detached {
+				detachedClusters = append(detachedClusters, peerRef.ClusterName)
+			}
+		}
+
+		condition := metav1.Condition{
+			Type:    multiclusterv1alpha1.MirrorPeerConditionClusterDetached,
+			Status:  metav1.ConditionFalse,
+			Reason:  multiclusterv1alpha1.MirrorPeerReasonManagedClusterAttached,
+			Message: "All ManagedClusters are attached to the hub",
+		}
+		if len(detachedClusters) > 0 {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = multiclusterv1alpha1.MirrorPeerReasonManagedClusterDetached
+			condition.Message = fmt.Sprintf("ManagedClusters %v have been detached from the hub. The MirrorPeer is degraded", detachedClusters)
+		} else if meta.FindStatusCondition(mirrorPeer.Status.Conditions, condition.Type) == nil {
+			continue
+		}
+		if !meta.SetStatusCondition(&mirrorPeer.Status.Conditions, condition) {
+			continue
+		}
+		if err := r.Client.Status().Update(ctx, mirrorPeer); err != nil {
+			return fmt.Errorf("failed to update status of MirrorPeer %q: %w", mirrorPeer.Name, err)
+		}
+		logger.Info("Updated ClusterDetached condition of MirrorPeer", "MirrorPeer", mirrorPeer.Name, "DetachedClusters", detachedClusters)
+	}
+	return nil
+}
+
+// isManagedClusterDetached returns true when the ManagedCluster does not exist or is being deleted
+func isManagedClusterDetached(ctx context.Context, c client.Client, clusterName string) (bool, error) {
+	var managedCluster clusterv1.ManagedCluster
+	if err := c.Get(ctx, types.NamespacedName{Name: clusterName}, &managedCluster); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get ManagedCluster %q: %w", clusterName, err)
+	}
+	return !managedCluster.GetDeletionTimestamp().IsZero(), nil
+}
+
+// isOwnedByOrchestrator returns true when the object is owned by a MirrorPeer or a DRPolicy
+func isOwnedByOrchestrator(obj metav1.Object) bool {
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind == "MirrorPeer" && strings.HasPrefix(ownerRef.APIVersion, multiclusterv1alpha1.GroupVersion.Group+"/") {
+			return true
+		}
+		if ownerRef.Kind == "DRPolicy" && strings.HasPrefix(ownerRef.APIVersion, ramenv1alpha1.GroupVersion.Group+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -7,14 +7,22 @@ import (
 	"context"
 	"testing"
 
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -22,8 +30,14 @@ func TestManagedClusterReconcile(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = clusterv1.AddToScheme(scheme)
 	_ = viewv1beta1.AddToScheme(scheme)
+	_ = multiclusterv1alpha1.AddToScheme(scheme)
+	_ = ramenv1alpha1.AddToScheme(scheme)
+	_ = workv1.AddToScheme(scheme)
 
-	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	client := fake.NewClientBuilder().WithScheme(scheme).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerClusterNameIndexKey, utils.MirrorPeerClusterNameIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerFinalizerIndexKey, utils.MirrorPeerFinalizerIndexFunc).
+		Build()
 	logger := utils.GetLogger(utils.GetZapLogger(true))
 
 	reconciler := &ManagedClusterReconciler{
@@ -64,12 +78,131 @@ func TestManagedClusterReconcile(t *testing.T) {
 	})
 }
 
+func TestDetachManagedCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(scheme)
+	_ = viewv1beta1.AddToScheme(scheme)
+	_ = multiclusterv1alpha1.AddToScheme(scheme)
+	_ = ramenv1alpha1.AddToScheme(scheme)
+	_ = workv1.AddToScheme(scheme)
+
+	mirrorPeerOwnerRef := metav1.OwnerReference{
+		APIVersion: multiclusterv1alpha1.GroupVersion.String(),
+		Kind:       "MirrorPeer",
+		Name:       "mirrorpeer",
+		UID:        "mirrorpeer-uid",
+		Controller: ptr.To(true),
+	}
+	mirrorPeer := &multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "mirrorpeer",
+			Finalizers: []string{addons.GetAgentFinalizer("cluster1"), addons.GetAgentFinalizer("cluster2")},
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{
+				{ClusterName: "cluster1", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"}},
+				{ClusterName: "cluster2", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"}},
+			},
+		},
+	}
+	cluster2 := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster2"},
+	}
+	mcvLabels := map[string]string{utils.CreatedByLabelKey: utils.MCVCreatedByValue}
+	mcv := &viewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.GetManagedClusterViewName("cluster1"), Namespace: "cluster1", Labels: mcvLabels},
+	}
+	externalMCV := &viewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.GetODFInfoManagedClusterViewName("cluster1", "external."+utils.OdfInfoClusterClaimNamespacedName), Namespace: "cluster1", Labels: mcvLabels},
+	}
+	inventory1 := &multiclusterv1alpha1.StorageInventory{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Spec:       multiclusterv1alpha1.StorageInventorySpec{ClusterName: "cluster1"},
+	}
+	inventory2 := &multiclusterv1alpha1.StorageInventory{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster2"},
+		Spec:       multiclusterv1alpha1.StorageInventorySpec{ClusterName: "cluster2"},
+		Status: multiclusterv1alpha1.StorageInventoryStatus{
+			Providers: []multiclusterv1alpha1.StorageProvider{{Name: "ocs-storagecluster", Namespace: "openshift-storage"}},
+			Clients: []multiclusterv1alpha1.StorageClient{
+				{Name: "client1", ManagedClusterName: "cluster1", ProviderName: "ocs-storagecluster"},
+				{Name: "client2", ManagedClusterName: "cluster2", ProviderName: "ocs-storagecluster"},
+			},
+		},
+	}
+	drCluster := &ramenv1alpha1.DRCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", OwnerReferences: []metav1.OwnerReference{mirrorPeerOwnerRef}},
+	}
+	orchestratorManifestWork := &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: "storageclusterpeer-cluster1", Namespace: "cluster1", OwnerReferences: []metav1.OwnerReference{mirrorPeerOwnerRef}},
+	}
+	foreignManifestWork := &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: "addon-manifests", Namespace: "cluster1"},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(mirrorPeer, cluster2, mcv, externalMCV, inventory1, inventory2, drCluster, orchestratorManifestWork, foreignManifestWork).
+		WithStatusSubresource(mirrorPeer, inventory2).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerClusterNameIndexKey, utils.MirrorPeerClusterNameIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerFinalizerIndexKey, utils.MirrorPeerFinalizerIndexFunc).
+		Build()
+	reconciler := &ManagedClusterReconciler{
+		Client: c,
+		Logger: utils.GetLogger(utils.GetZapLogger(true)),
+	}
+	ctx := context.TODO()
+
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster1"}})
+	assert.NoError(t, err)
+
+	var gotMirrorPeer multiclusterv1alpha1.MirrorPeer
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "mirrorpeer"}, &gotMirrorPeer))
+	cond := meta.FindStatusCondition(gotMirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionClusterDetached)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, multiclusterv1alpha1.MirrorPeerReasonManagedClusterDetached, cond.Reason)
+	}
+	assert.Equal(t, []string{addons.GetAgentFinalizer("cluster2")}, gotMirrorPeer.Finalizers)
+
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(mcv), &viewv1beta1.ManagedClusterView{})))
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(externalMCV), &viewv1beta1.ManagedClusterView{})))
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(inventory1), &multiclusterv1alpha1.StorageInventory{})))
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(drCluster), &ramenv1alpha1.DRCluster{})))
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(orchestratorManifestWork), &workv1.ManifestWork{})))
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(foreignManifestWork), &workv1.ManifestWork{}))
+
+	var gotInventory multiclusterv1alpha1.StorageInventory
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(inventory2), &gotInventory))
+	assert.Equal(t, []multiclusterv1alpha1.StorageClient{
+		{Name: "client2", ManagedClusterName: "cluster2", ProviderName: "ocs-storagecluster"},
+	}, gotInventory.Status.Clients)
+
+	// Attaching the cluster again clears the condition
+	cluster1 := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: utils.OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+			},
+		},
+	}
+	assert.NoError(t, c.Create(ctx, cluster1))
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster1"}})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "mirrorpeer"}, &gotMirrorPeer))
+	assert.True(t, meta.IsStatusConditionFalse(gotMirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionClusterDetached))
+}
+
 func TestProcessManagedClusterViews(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = clusterv1.AddToScheme(scheme)
 	_ = viewv1beta1.AddToScheme(scheme)
+	_ = multiclusterv1alpha1.AddToScheme(scheme)
 
-	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	client := fake.NewClientBuilder().WithScheme(scheme).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerClusterNameIndexKey, utils.MirrorPeerClusterNameIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerFinalizerIndexKey, utils.MirrorPeerFinalizerIndexFunc).
+		Build()
 	logger := utils.GetLogger(utils.GetZapLogger(true))
 
 	reconciler := &ManagedClusterReconciler{
@@ -174,4 +307,53 @@ func TestProcessManagedClusterViews(t *testing.T) {
 		err := reconciler.processManagedClusterViews(context.TODO(), managedCluster)
 		assert.NoError(t, err)
 	})
+
+	t.Run("ManagedCluster publishes several odf-info ClusterClaims. One ManagedClusterView per claim should exist", func(t *testing.T) {
+		externalClaim := "external." + utils.OdfInfoClusterClaimNamespacedName
+		externalMCVName := utils.GetODFInfoManagedClusterViewName("test-cluster", externalClaim)
+		managedCluster := clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-cluster",
+			},
+			Status: clusterv1.ManagedClusterStatus{
+				ClusterClaims: []clusterv1.ManagedClusterClaim{
+					{
+						Name:  utils.OdfInfoClusterClaimNamespacedName,
+						Value: "openshift-storage/odf-info",
+					},
+					{
+						Name:  externalClaim,
+						Value: "openshift-storage-extended/odf-info-external",
+					},
+				},
+			},
+		}
+		err := reconciler.processManagedClusterViews(context.TODO(), managedCluster)
+		assert.NoError(t, err)
+
+		externalMCV := &viewv1beta1.ManagedClusterView{}
+		err = client.Get(context.TODO(), types.NamespacedName{Name: externalMCVName, Namespace: "test-cluster"}, externalMCV)
+		assert.NoError(t, err)
+		assert.Equal(t, "odf-info-external", externalMCV.Spec.Scope.Name)
+		assert.Equal(t, "openshift-storage-extended", externalMCV.Spec.Scope.Namespace)
+		assert.Equal(t, "test-cluster.external", utils.GetStorageInventoryName(*externalMCV))
+
+		externalInventory := &multiclusterv1alpha1.StorageInventory{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.GetStorageInventoryName(*externalMCV)},
+			Spec:       multiclusterv1alpha1.StorageInventorySpec{ClusterName: "test-cluster"},
+		}
+		assert.NoError(t, client.Create(context.TODO(), externalInventory))
+
+		// Withdrawing the claim removes its ManagedClusterView and StorageInventory
+		managedCluster.Status.ClusterClaims = managedCluster.Status.ClusterClaims[:1]
+		err = reconciler.processManagedClusterViews(context.TODO(), managedCluster)
+		assert.NoError(t, err)
+
+		err = client.Get(context.TODO(), types.NamespacedName{Name: externalMCVName, Namespace: "test-cluster"}, &viewv1beta1.ManagedClusterView{})
+		assert.True(t, k8serrors.IsNotFound(err))
+		err = client.Get(context.TODO(), types.NamespacedName{Name: externalInventory.Name}, &multiclusterv1alpha1.StorageInventory{})
+		assert.True(t, k8serrors.IsNotFound(err))
+		err = client.Get(context.TODO(), types.NamespacedName{Name: utils.GetManagedClusterViewName("test-cluster"), Namespace: "test-cluster"}, &viewv1beta1.ManagedClusterView{})
+		assert.NoError(t, err)
+	})
 }
@@ -5,14 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -29,11 +35,14 @@ type ManagedClusterViewReconciler struct {
 	Logger           *slog.Logger
 	testEnvFile      string
 	CurrentNamespace string
+	// StaleThreshold is the age after which the odf-info of a provider is considered stale. Zero disables the check.
+	StaleThreshold time.Duration
 }
 
 const (
-	ODFInfoConfigMapName    = "odf-info"
-	ConfigMapResourceType   = "ConfigMap"
+	ODFInfoConfigMapName  = "odf-info"
+	ConfigMapResourceType = "ConfigMap"
+	// ClientInfoConfigMapName is the ConfigMap which held the client info before StorageInventories
 	ClientInfoConfigMapName = "odf-client-info"
 )
 
@@ -61,11 +70,34 @@ func (r *ManagedClusterViewReconciler) SetupWithManager(mgr ctrl.Manager) error
 		Complete(r)
 }
 
+// storageInventoryEntriesChangedPredicate passes StorageInventory events which change the providers or clients of the
+// inventory or whether the inventory is stale
+func storageInventoryEntriesChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldInventory, ok := e.ObjectOld.(*multiclusterv1alpha1.StorageInventory)
+			if !ok {
+				return false
+			}
+			newInventory, ok := e.ObjectNew.(*multiclusterv1alpha1.StorageInventory)
+			if !ok {
+				return false
+			}
+			return !reflect.DeepEqual(oldInventory.Status.Providers, newInventory.Status.Providers) ||
+				!reflect.DeepEqual(oldInventory.Status.Clients, newInventory.Status.Clients) ||
+				meta.IsStatusConditionTrue(oldInventory.Status.Conditions, multiclusterv1alpha1.StorageInventoryStale) !=
+					meta.IsStatusConditionTrue(newInventory.Status.Conditions, multiclusterv1alpha1.StorageInventoryStale)
+		},
+	}
+}
+
+// hasODFInfoInScope returns true for ManagedClusterViews of the odf-info ConfigMaps. Additional odf-info ConfigMaps
+// may be named differently, their ManagedClusterViews are recognized by the label set by the orchestrator.
 func hasODFInfoInScope(mc *viewv1beta1.ManagedClusterView) bool {
-	if mc.Spec.Scope.Name == utils.ODFInfoConfigMapName && mc.Spec.Scope.Resource == ConfigMapResourceType {
-		return true
+	if mc.Spec.Scope.Resource != ConfigMapResourceType {
+		return false
 	}
-	return false
+	return mc.Spec.Scope.Name == utils.ODFInfoConfigMapName || mc.Labels[utils.CreatedByLabelKey] == utils.MCVCreatedByValue
 }
 
 func (r *ManagedClusterViewReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
@@ -80,32 +112,254 @@ func (r *ManagedClusterViewReconciler) Reconcile(ctx context.Context, req reconc
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if err := createOrUpdateConfigMap(ctx, r.Client, r.CurrentNamespace, managedClusterView, r.Logger); err != nil {
-		logger.Error("Failed to create or update ConfigMap for ManagedClusterView", "error", err)
+	if err := createOrUpdateStorageInventory(ctx, r.Client, managedClusterView, r.StaleThreshold, r.Logger); err != nil {
+		logger.Error("Failed to create or update StorageInventory for ManagedClusterView", "error", err)
 		return ctrl.Result{}, err
 	}
 
+	var inventory multiclusterv1alpha1.StorageInventory
+	inventoryName := utils.GetStorageInventoryName(managedClusterView)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: inventoryName}, &inventory); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get StorageInventory %q: %w", inventoryName, err)
+	}
+	now := time.Now()
+	if err := r.recordClusterStorageInventoryMetrics(ctx, managedClusterView.Namespace, now); err != nil {
+		logger.Error("Failed to record StorageInventory metrics", "error", err)
+	}
+
 	logger.Info("Successfully reconciled ManagedClusterView")
 
-	return ctrl.Result{}, nil
+	// Requeue to notice when the odf-info turns stale without any further update of the ManagedClusterView
+	return ctrl.Result{RequeueAfter: getStaleRequeueAfter(inventory, r.StaleThreshold, now)}, nil
+}
+
+// recordClusterStorageInventoryMetrics records the odf-info freshness metrics of all StorageInventories of the ManagedCluster
+func (r *ManagedClusterViewReconciler) recordClusterStorageInventoryMetrics(ctx context.Context, clusterName string, now time.Time) error {
+	var inventoryList multiclusterv1alpha1.StorageInventoryList
+	if err := r.Client.List(ctx, &inventoryList); err != nil {
+		return fmt.Errorf("failed to list StorageInventories: %w", err)
+	}
+	var inventories []multiclusterv1alpha1.StorageInventory
+	var staleProviders []string
+	for _, inventory := range inventoryList.Items {
+		if inventory.Spec.ClusterName != clusterName {
+			continue
+		}
+		inventories = append(inventories, inventory)
+		staleProviders = append(staleProviders, getStaleProviders(inventory, r.StaleThreshold, now)...)
+	}
+	recordStorageInventoryMetrics(clusterName, inventories, staleProviders)
+	return nil
+}
+
+// getStaleProviders returns the names of the providers of the inventory whose odf-info has not been refreshed within the threshold
+func getStaleProviders(inventory multiclusterv1alpha1.StorageInventory, threshold time.Duration, now time.Time) []string {
+	var staleProviders []string
+	for _, clientInfo := range utils.GetClientInfoFromStorageInventories([]multiclusterv1alpha1.StorageInventory{inventory}) {
+		providerName := clientInfo.ProviderInfo.NamespacedName.Name
+		if clientInfo.ProviderInfo.IsStale(threshold, now) && !slices.Contains(staleProviders, providerName) {
+			staleProviders = append(staleProviders, providerName)
+		}
+	}
+	slices.Sort(staleProviders)
+	return staleProviders
 }
 
-func createOrUpdateConfigMap(ctx context.Context, c client.Client, operatorNamespace string, managedClusterView viewv1beta1.ManagedClusterView, logger *slog.Logger) error {
+// getStaleRequeueAfter returns the duration after which the next provider of the inventory turns stale
+func getStaleRequeueAfter(inventory multiclusterv1alpha1.StorageInventory, threshold time.Duration, now time.Time) time.Duration {
+	if threshold <= 0 {
+		return 0
+	}
+	requeueAfter := threshold
+	for _, provider := range inventory.Status.Providers {
+		if provider.LastRefreshTime == nil {
+			continue
+		}
+		if untilStale := provider.LastRefreshTime.Add(threshold).Sub(now); untilStale > 0 && untilStale < requeueAfter {
+			requeueAfter = untilStale + time.Second
+		}
+	}
+	return requeueAfter
+}
+
+// setStaleCondition sets the Stale condition of the inventory from the refresh times of its providers
+func setStaleCondition(inventory *multiclusterv1alpha1.StorageInventory, threshold time.Duration, now time.Time) {
+	condition := metav1.Condition{
+		Type:               multiclusterv1alpha1.StorageInventoryStale,
+		Status:             metav1.ConditionFalse,
+		Reason:             "ODFInfoRefreshed",
+		Message:            "The odf-info of all providers has been refreshed within the staleness threshold",
+		ObservedGeneration: inventory.Generation,
+	}
+	if staleProviders := getStaleProviders(*inventory, threshold, now); len(staleProviders) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ODFInfoRefreshOverdue"
+		condition.Message = fmt.Sprintf("The odf-info of providers %v has not been refreshed within %s", staleProviders, threshold)
+	}
+	meta.SetStatusCondition(&inventory.Status.Conditions, condition)
+}
+
+func createOrUpdateStorageInventory(ctx context.Context, c client.Client, managedClusterView viewv1beta1.ManagedClusterView, staleThreshold time.Duration, logger *slog.Logger) error {
 	logger = logger.With("ManagedClusterView", managedClusterView.Name, "Namespace", managedClusterView.Namespace)
+	clusterName := managedClusterView.Namespace
+	inventoryName := utils.GetStorageInventoryName(managedClusterView)
+
+	inventory := &multiclusterv1alpha1.StorageInventory{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: inventoryName,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, c, inventory, func() error {
+		inventory.Spec.ClusterName = clusterName
+		for _, mcvOwnerRef := range managedClusterView.GetOwnerReferences() {
+			exists := false
+			for _, existingOwnerRef := range inventory.OwnerReferences {
+				if existingOwnerRef.UID == mcvOwnerRef.UID {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				falseValue := false
+				mcvOwnerRef.Controller = &falseValue
+				inventory.OwnerReferences = append(inventory.OwnerReferences, mcvOwnerRef)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update StorageInventory %q: %w", inventoryName, err)
+	}
+	logger.Info(fmt.Sprintf("StorageInventory %s has been %s", inventoryName, op))
+
+	providers, clients, err := getStorageInventoryEntries(ctx, c, managedClusterView, logger)
+	if err != nil {
+		meta.SetStatusCondition(&inventory.Status.Conditions, metav1.Condition{
+			Type:               multiclusterv1alpha1.StorageInventorySynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ODFInfoInvalid",
+			Message:            err.Error(),
+			ObservedGeneration: inventory.Generation,
+		})
+		setStaleCondition(inventory, staleThreshold, time.Now())
+		if statusErr := c.Status().Update(ctx, inventory); statusErr != nil {
+			logger.Error("Failed to update StorageInventory status", "error", statusErr)
+		}
+		return err
+	}
+
+	now := metav1.Now()
+	setProviderRefreshTimes(providers, inventory.Status.Providers, getODFInfoRefreshTime(managedClusterView, logger))
+
+	// The MirrorPeer controller moves the MirrorPeers depending on pruned entries to the PeerStorageNotFound phase once
+	// it is notified about the StorageInventory change
+	if removedKeys := getRemovedStorageInventoryKeys(*inventory, providers, clients); len(removedKeys) > 0 {
+		logger.Info("Pruning stale StorageInventory entries", "StorageInventory", inventoryName, "Entries", removedKeys)
+	}
+
+	inventory.Status.Providers = providers
+	inventory.Status.Clients = clients
+	inventory.Status.LastUpdateTime = &now
+	meta.SetStatusCondition(&inventory.Status.Conditions, metav1.Condition{
+		Type:               multiclusterv1alpha1.StorageInventorySynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ODFInfoProcessed",
+		Message:            fmt.Sprintf("Processed odf-info of ManagedClusterView %s/%s", managedClusterView.Namespace, managedClusterView.Name),
+		ObservedGeneration: inventory.Generation,
+	})
+	setStaleCondition(inventory, staleThreshold, now.Time)
+	if err := c.Status().Update(ctx, inventory); err != nil {
+		return fmt.Errorf("failed to update status of StorageInventory %q: %w", inventoryName, err)
+	}
+	logger.Info("StorageInventory status has been updated", "StorageInventory", inventoryName, "ProviderCount", len(providers), "ClientCount", len(clients))
+
+	return nil
+}
+
+// setProviderRefreshTimes records when the odf-info of each provider was last refreshed. The token exchange agent
+// stamps the odf-info with its refresh time periodically, an odf-info without a stamp keeps the previous refresh time
+// of its provider. Providers whose odf-info was never stamped, by agents older than the stamp, have no refresh time.
+func setProviderRefreshTimes(providers, previousProviders []multiclusterv1alpha1.StorageProvider, refreshTime *metav1.Time) {
+	for i := range providers {
+		if refreshTime != nil {
+			providers[i].LastRefreshTime = refreshTime.DeepCopy()
+			continue
+		}
+		for _, previous := range previousProviders {
+			if previous.Namespace == providers[i].Namespace && previous.Name == providers[i].Name {
+				providers[i].LastRefreshTime = previous.LastRefreshTime.DeepCopy()
+				break
+			}
+		}
+	}
+}
+
+// getODFInfoRefreshTime returns the refresh time stamped by the token exchange agent on the odf-info reported by the
+// ManagedClusterView, or nil when the odf-info has no valid stamp
+func getODFInfoRefreshTime(managedClusterView viewv1beta1.ManagedClusterView, logger *slog.Logger) *metav1.Time {
+	var odfInfo metav1.PartialObjectMetadata
+	if err := json.Unmarshal(managedClusterView.Status.Result.Raw, &odfInfo); err != nil {
+		return nil
+	}
+	stamp, ok := odfInfo.Annotations[utils.ODFInfoRefreshTimeAnnotationKey]
+	if !ok {
+		return nil
+	}
+	refreshTime, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		logger.Error("Ignoring invalid odf-info refresh time", "Annotation", utils.ODFInfoRefreshTimeAnnotationKey, "Value", stamp, "error", err)
+		return nil
+	}
+	return &metav1.Time{Time: refreshTime}
+}
+
+// getRemovedStorageInventoryKeys returns the client info keys of the inventory which are no longer reported by the providers and clients
+func getRemovedStorageInventoryKeys(inventory multiclusterv1alpha1.StorageInventory, providers []multiclusterv1alpha1.StorageProvider, clients []multiclusterv1alpha1.StorageClient) []string {
+	updated := inventory.DeepCopy()
+	updated.Status.Providers = providers
+	updated.Status.Clients = clients
+	currentClientInfo := utils.GetClientInfoFromStorageInventories([]multiclusterv1alpha1.StorageInventory{*updated})
+
+	var removedKeys []string
+	for key := range utils.GetClientInfoFromStorageInventories([]multiclusterv1alpha1.StorageInventory{inventory}) {
+		if _, ok := currentClientInfo[key]; !ok {
+			removedKeys = append(removedKeys, key)
+		}
+	}
+	slices.Sort(removedKeys)
+	return removedKeys
+}
+
+// isODFInfoNotFound returns true when the ManagedClusterView reports that the odf-info ConfigMap does not exist on
+// the ManagedCluster anymore, which is the case once ODF has been uninstalled.
+func isODFInfoNotFound(managedClusterView viewv1beta1.ManagedClusterView) bool {
+	cond := meta.FindStatusCondition(managedClusterView.Status.Conditions, viewv1beta1.ConditionViewProcessing)
+	return cond != nil && cond.Status == metav1.ConditionFalse && cond.Reason == viewv1beta1.ReasonGetResourceFailed &&
+		strings.Contains(cond.Message, "not found")
+}
+
+// getStorageInventoryEntries returns the StorageClusters and StorageClients found in the odf-info of the ManagedClusterView result
+func getStorageInventoryEntries(ctx context.Context, c client.Client, managedClusterView viewv1beta1.ManagedClusterView, logger *slog.Logger) ([]multiclusterv1alpha1.StorageProvider, []multiclusterv1alpha1.StorageClient, error) {
+	if isODFInfoNotFound(managedClusterView) {
+		logger.Info("odf-info ConfigMap no longer exists on the ManagedCluster")
+		return nil, nil, nil
+	}
 
 	// Initialize an empty map to hold the result data.
 	var resultData map[string]interface{}
 	err := json.Unmarshal(managedClusterView.Status.Result.Raw, &resultData)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal result data. %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal result data. %w", err)
 	}
 
 	data, ok := resultData["data"].(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("unexpected data format in result: %v", resultData["data"])
+		return nil, nil, fmt.Errorf("unexpected data format in result: %v", resultData["data"])
 	}
+	resourceVersion, _, _ := unstructured.NestedString(resultData, "metadata", "resourceVersion")
 
-	clientInfoMap := make(map[string]string)
+	var providers []multiclusterv1alpha1.StorageProvider
+	var clients []multiclusterv1alpha1.StorageClient
 
 	for key, value := range data {
 		if !strings.Contains(key, ".yaml") {
@@ -114,113 +368,72 @@ func createOrUpdateConfigMap(ctx context.Context, c client.Client, operatorNames
 
 		yamlContent, ok := value.(string)
 		if !ok {
-			return fmt.Errorf("unexpected value format in data for key %s: expected string, got %T", key, value)
+			return nil, nil, fmt.Errorf("unexpected value format in data for key %s: expected string, got %T", key, value)
 		}
 		var odfInfo ocsv1alpha1.OdfInfoData
 		err := yaml.Unmarshal([]byte(yamlContent), &odfInfo)
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal ODF info data for key %s: %w", key, err)
+			return nil, nil, fmt.Errorf("failed to unmarshal ODF info data for key %s: %w", key, err)
 		}
 
 		providerPublicEndpoint := odfInfo.StorageCluster.Annotations[ocsv1alpha1.ApiServerExportedAddressAnnotationName]
 		if providerPublicEndpoint == "" {
 			logger.Info("StorageProviderPublicEndpoint is not available.")
 		}
-		providerInfo := utils.ProviderInfo{
+		providers = append(providers, multiclusterv1alpha1.StorageProvider{
+			Name:                          odfInfo.StorageCluster.NamespacedName.Name,
+			Namespace:                     odfInfo.StorageCluster.NamespacedName.Namespace,
 			Version:                       odfInfo.Version,
 			DeploymentType:                odfInfo.DeploymentType,
 			CephClusterFSID:               odfInfo.StorageCluster.CephClusterFSID,
 			StorageProviderEndpoint:       odfInfo.StorageCluster.StorageProviderEndpoint,
-			NamespacedName:                odfInfo.StorageCluster.NamespacedName,
-			ProviderManagedClusterName:    managedClusterView.Namespace,
 			StorageProviderPublicEndpoint: providerPublicEndpoint,
-		}
+			ResourceVersion:               resourceVersion,
+		})
 
-		if len(odfInfo.Clients) == 0 {
-			clientInfo := utils.ClientInfo{
-				ClusterID:                "",
-				Name:                     "",
-				ProviderInfo:             providerInfo,
-				ClientManagedClusterName: "",
-				ClientID:                 "",
-			}
-			clientInfoJSON, err := json.Marshal(clientInfo)
+		for _, client := range odfInfo.Clients {
+			managedCluster, err := utils.GetManagedClusterById(ctx, c, client.ClusterID)
 			if err != nil {
-				return fmt.Errorf("failed to marshal client info for key %s: %w", key, err)
-			}
-
-			clientInfoMap[utils.GetKey(managedClusterView.Namespace, odfInfo.StorageCluster.NamespacedName.Name)] = string(clientInfoJSON)
-		} else {
-			for _, client := range odfInfo.Clients {
-				managedCluster, err := utils.GetManagedClusterById(ctx, c, client.ClusterID)
-				if err != nil {
-					return err
-				}
-				clientInfo := utils.ClientInfo{
-					ClusterID:                client.ClusterID,
-					Name:                     client.Name,
-					ProviderInfo:             providerInfo,
-					ClientManagedClusterName: managedCluster.Name,
-					ClientID:                 client.ClientID,
-				}
-				clientInfoJSON, err := json.Marshal(clientInfo)
-				if err != nil {
-					return fmt.Errorf("failed to marshal client info for key %s: %w", key, err)
+				// StorageClients of detached ManagedClusters are left out until they are offboarded
+				if errors.IsNotFound(err) {
+					logger.Info("Skipping StorageClient of a cluster which is not a ManagedCluster", "StorageClient", client.Name, "ClusterID", client.ClusterID)
+					continue
 				}
-
-				clientInfoMap[utils.GetKey(managedCluster.Name, client.Name)] = string(clientInfoJSON)
+				return nil, nil, err
 			}
+			clients = append(clients, multiclusterv1alpha1.StorageClient{
+				Name:               client.Name,
+				ClusterID:          client.ClusterID,
+				ClientID:           client.ClientID,
+				ManagedClusterName: managedCluster.Name,
+				ProviderName:       odfInfo.StorageCluster.NamespacedName.Name,
+			})
 		}
 	}
 
-	configMap := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      ClientInfoConfigMapName,
-			Namespace: operatorNamespace,
-		},
-	}
-	err = c.Get(ctx, types.NamespacedName{Name: ClientInfoConfigMapName, Namespace: operatorNamespace}, configMap)
-	if err != nil && !errors.IsNotFound(err) {
-		return fmt.Errorf("failed to get ConfigMap. %w", err)
-	}
-
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
-
-	op, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
-
-		if configMap.Data == nil {
-			configMap.Data = make(map[string]string)
-		}
+	// Keep the order stable so that unchanged odf-info does not change the status
+	slices.SortFunc(providers, func(a, b multiclusterv1alpha1.StorageProvider) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+	slices.SortFunc(clients, func(a, b multiclusterv1alpha1.StorageClient) int {
+		return strings.Compare(utils.GetKey(a.ManagedClusterName, a.Name), utils.GetKey(b.ManagedClusterName, b.Name))
+	})
 
-		for clientKey, clientInfo := range clientInfoMap {
-			configMap.Data[clientKey] = clientInfo
-		}
+	return providers, clients, nil
+}
 
-		mcvOwnerRefs := managedClusterView.GetOwnerReferences()
-		for _, mcvOwnerRef := range mcvOwnerRefs {
-			exists := false
-			for _, existingOwnerRef := range configMap.OwnerReferences {
-				if existingOwnerRef.UID == mcvOwnerRef.UID {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				falseValue := false
-				mcvOwnerRef.Controller = &falseValue
-				configMap.OwnerReferences = append(configMap.OwnerReferences, mcvOwnerRef)
-			}
+// deleteLegacyClientInfoConfigMap removes the odf-client-info ConfigMap which has been replaced by StorageInventories.
+// The hub runs it once on startup, the ConfigMap is deleted directly to avoid caching the ConfigMaps of the namespace.
+func deleteLegacyClientInfoConfigMap(ctx context.Context, c client.Client, operatorNamespace string, logger *slog.Logger) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ClientInfoConfigMapName, Namespace: operatorNamespace},
+	}
+	if err := c.Delete(ctx, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
 		}
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to create or update ConfigMap. %w", err)
+		return fmt.Errorf("failed to delete ConfigMap %q: %w", ClientInfoConfigMapName, err)
 	}
-
-	logger.Info(fmt.Sprintf("ConfigMap %s in namespace %s has been %s", ClientInfoConfigMapName, operatorNamespace, op))
-
+	logger.Info("Deleted ConfigMap replaced by StorageInventories", "ConfigMap", ClientInfoConfigMapName, "Namespace", operatorNamespace)
 	return nil
 }
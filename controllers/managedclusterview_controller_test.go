@@ -8,12 +8,16 @@ import (
 	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -22,19 +26,27 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-func TestCreateOrUpdateConfigMap(t *testing.T) {
+func TestCreateOrUpdateStorageInventory(t *testing.T) {
 
 	s := scheme.Scheme
+	_ = multiclusterv1alpha1.AddToScheme(s)
 	_ = viewv1beta1.AddToScheme(s)
 	_ = corev1.AddToScheme(s)
 	_ = clusterv1.AddToScheme(s)
 
-	c := fake.NewClientBuilder().WithScheme(s).Build()
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&multiclusterv1alpha1.StorageInventory{}).
+		WithIndex(&clusterv1.ManagedCluster{}, utils.ManagedClusterIDIndexKey, utils.ManagedClusterIDIndexFunc).
+		Build()
 	os.Setenv("POD_NAMESPACE", "openshift-operators")
 	logger := utils.GetLogger(utils.GetZapLogger(true))
 
+	// The odf-info of the ManagedClusterViews is stamped by the agent
+	refreshed := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
 	createManagedClusterView := func(name, namespace string, data map[string]string, ownerRefs []metav1.OwnerReference) *viewv1beta1.ManagedClusterView {
 		raw, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{utils.ODFInfoRefreshTimeAnnotationKey: refreshed.Format(time.RFC3339)},
+			},
 			"data": data,
 		})
 		return &viewv1beta1.ManagedClusterView{
@@ -62,7 +74,7 @@ func TestCreateOrUpdateConfigMap(t *testing.T) {
 		}
 	}
 
-	t.Run("Create ConfigMap with MCV in cluster1", func(t *testing.T) {
+	t.Run("Create StorageInventory with MCV in cluster1", func(t *testing.T) {
 		mc1 := createManagedCluster("cluster1-name", "cluster1")
 		mc2 := createManagedCluster("cluster2-name", "cluster2")
 		err := c.Create(context.TODO(), mc1)
@@ -91,32 +103,47 @@ storageCluster:
 		ownerRefs := []metav1.OwnerReference{
 			*metav1.NewControllerRef(mc1, clusterv1.SchemeGroupVersion.WithKind("ManagedCluster")),
 		}
-		mcv := createManagedClusterView("test-view", "cluster1", data, ownerRefs)
+		mcv := createManagedClusterView(utils.GetManagedClusterViewName("cluster1"), "cluster1", data, ownerRefs)
 
 		ctx := context.TODO()
 		err = c.Create(ctx, mcv)
 		assert.NoError(t, err)
 
-		err = createOrUpdateConfigMap(ctx, c, "openshift-operators", *mcv, logger)
+		err = createOrUpdateStorageInventory(ctx, c, *mcv, time.Hour, logger)
 		assert.NoError(t, err)
 
-		cm := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: ClientInfoConfigMapName, Namespace: utils.GetEnv("POD_NAMESPACE")}, cm)
+		inventory := &multiclusterv1alpha1.StorageInventory{}
+		err = c.Get(ctx, types.NamespacedName{Name: "cluster1"}, inventory)
 		assert.NoError(t, err)
-		assert.NotNil(t, cm)
 
-		expectedData := map[string]string{
-			"cluster1-name_client1": `{"clusterId":"cluster1","name":"client1","providerInfo":{"version":"4.Y.Z","deploymentType":"internal","providerManagedClusterName":"cluster1","namespacedName":{"Namespace":"openshift-storage","Name":"ocs-storagecluster"},"storageProviderEndpoint":"","cephClusterFSID":"7a3d6b81-a55d-44fe-84d0-46c67cd395ca","storageProviderPublicEndpoint":""},"clientManagedClusterName":"cluster1-name","clientId":"client1"}`,
+		assert.Equal(t, "cluster1", inventory.Spec.ClusterName)
+		if assert.Len(t, inventory.Status.Providers, 1) {
+			assert.True(t, refreshed.Equal(inventory.Status.Providers[0].LastRefreshTime))
+			inventory.Status.Providers[0].LastRefreshTime = nil
 		}
-
-		assert.Equal(t, expectedData, cm.Data)
-		assert.Equal(t, 1, len(cm.OwnerReferences))
-		assert.Equal(t, mc1.Name, cm.OwnerReferences[0].Name)
-		assert.Equal(t, "ManagedCluster", cm.OwnerReferences[0].Kind)
-		assert.Equal(t, clusterv1.GroupVersion.String(), cm.OwnerReferences[0].APIVersion)
+		assert.Equal(t, []multiclusterv1alpha1.StorageProvider{{
+			Name:            "ocs-storagecluster",
+			Namespace:       "openshift-storage",
+			Version:         "4.Y.Z",
+			DeploymentType:  "internal",
+			CephClusterFSID: "7a3d6b81-a55d-44fe-84d0-46c67cd395ca",
+		}}, inventory.Status.Providers)
+		assert.Equal(t, []multiclusterv1alpha1.StorageClient{{
+			Name:               "client1",
+			ClusterID:          "cluster1",
+			ClientID:           "client1",
+			ManagedClusterName: "cluster1-name",
+			ProviderName:       "ocs-storagecluster",
+		}}, inventory.Status.Clients)
+		assert.NotNil(t, inventory.Status.LastUpdateTime)
+		assert.True(t, meta.IsStatusConditionTrue(inventory.Status.Conditions, multiclusterv1alpha1.StorageInventorySynced))
+		assert.Equal(t, 1, len(inventory.OwnerReferences))
+		assert.Equal(t, mc1.Name, inventory.OwnerReferences[0].Name)
+		assert.Equal(t, "ManagedCluster", inventory.OwnerReferences[0].Kind)
+		assert.Equal(t, clusterv1.GroupVersion.String(), inventory.OwnerReferences[0].APIVersion)
 	})
 
-	t.Run("Update ConfigMap with MCV in cluster2", func(t *testing.T) {
+	t.Run("Create StorageInventory with MCV in cluster2", func(t *testing.T) {
 		mc2 := createManagedCluster("cluster2-name", "cluster2")
 		ctx := context.TODO()
 		data := map[string]string{
@@ -140,25 +167,205 @@ storageCluster:
 		ownerRefs := []metav1.OwnerReference{
 			*metav1.NewControllerRef(mc2, clusterv1.SchemeGroupVersion.WithKind("ManagedCluster")),
 		}
-		mcv := createManagedClusterView("new-view", "cluster2", data, ownerRefs)
+		mcv := createManagedClusterView(utils.GetManagedClusterViewName("cluster2"), "cluster2", data, ownerRefs)
 
 		err := c.Create(ctx, mcv)
 		assert.NoError(t, err)
 
-		err = createOrUpdateConfigMap(ctx, c, "openshift-operators", *mcv, logger)
+		err = createOrUpdateStorageInventory(ctx, c, *mcv, time.Hour, logger)
+		assert.NoError(t, err)
+
+		var inventoryList multiclusterv1alpha1.StorageInventoryList
+		err = c.List(ctx, &inventoryList)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(inventoryList.Items))
+
+		clientInfoMap, err := utils.FetchClientInfo(ctx, c)
+		assert.NoError(t, err)
+		for key, clientInfo := range clientInfoMap {
+			assert.True(t, refreshed.Equal(clientInfo.ProviderInfo.LastRefreshTime))
+			clientInfo.ProviderInfo.LastRefreshTime = nil
+			clientInfoMap[key] = clientInfo
+		}
+
+		expectedClientInfoMap := map[string]utils.ClientInfo{
+			"cluster1-name_client1": {
+				ClusterID: "cluster1",
+				Name:      "client1",
+				ProviderInfo: utils.ProviderInfo{
+					Version:                    "4.Y.Z",
+					DeploymentType:             "internal",
+					ProviderManagedClusterName: "cluster1",
+					NamespacedName:             types.NamespacedName{Namespace: "openshift-storage", Name: "ocs-storagecluster"},
+					CephClusterFSID:            "7a3d6b81-a55d-44fe-84d0-46c67cd395ca",
+				},
+				ClientManagedClusterName: "cluster1-name",
+				ClientID:                 "client1",
+			},
+			"cluster2-name_client2": {
+				ClusterID: "cluster2",
+				Name:      "client2",
+				ProviderInfo: utils.ProviderInfo{
+					Version:                    "4.Y.Z",
+					DeploymentType:             "internal",
+					ProviderManagedClusterName: "cluster2",
+					NamespacedName:             types.NamespacedName{Namespace: "openshift-storage", Name: "ocs-storagecluster"},
+					CephClusterFSID:            "8b3d6b81-b55d-55fe-94d0-56c67cd495ca",
+				},
+				ClientManagedClusterName: "cluster2-name",
+				ClientID:                 "client2",
+			},
+		}
+		assert.Equal(t, expectedClientInfoMap, clientInfoMap)
+	})
+
+	t.Run("Prune entries which are no longer reported", func(t *testing.T) {
+		ctx := context.TODO()
+		mcv := &viewv1beta1.ManagedClusterView{}
+		err := c.Get(ctx, types.NamespacedName{Name: utils.GetManagedClusterViewName("cluster1"), Namespace: "cluster1"}, mcv)
+		assert.NoError(t, err)
+
+		// client1 has been offboarded
+		raw, _ := json.Marshal(map[string]interface{}{
+			"data": map[string]string{
+				"openshift-storage_ocs-storagecluster.config.yaml": `
+version: "4.Y.Z"
+deploymentType: "internal"
+storageCluster:
+  namespacedName:
+    name: "ocs-storagecluster"
+    namespace: "openshift-storage"
+  cephClusterFSID: "7a3d6b81-a55d-44fe-84d0-46c67cd395ca"
+`,
+			},
+		})
+		mcv.Status.Result = runtime.RawExtension{Raw: raw}
+		err = createOrUpdateStorageInventory(ctx, c, *mcv, time.Hour, logger)
+		assert.NoError(t, err)
+
+		clientInfoMap, err := utils.FetchClientInfo(ctx, c)
 		assert.NoError(t, err)
+		assert.NotContains(t, clientInfoMap, "cluster1-name_client1")
+		assert.Contains(t, clientInfoMap, "cluster1_ocs-storagecluster")
+		assert.Contains(t, clientInfoMap, "cluster2-name_client2")
 
-		cm := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: ClientInfoConfigMapName, Namespace: utils.GetEnv("POD_NAMESPACE")}, cm)
+		// ODF has been uninstalled
+		mcv.Status.Result = runtime.RawExtension{}
+		mcv.Status.Conditions = []metav1.Condition{{
+			Type:    viewv1beta1.ConditionViewProcessing,
+			Status:  metav1.ConditionFalse,
+			Reason:  viewv1beta1.ReasonGetResourceFailed,
+			Message: `configmaps "odf-info" not found`,
+		}}
+		err = createOrUpdateStorageInventory(ctx, c, *mcv, time.Hour, logger)
 		assert.NoError(t, err)
-		assert.NotNil(t, cm)
 
-		expectedData := map[string]string{
-			"cluster1-name_client1": `{"clusterId":"cluster1","name":"client1","providerInfo":{"version":"4.Y.Z","deploymentType":"internal","providerManagedClusterName":"cluster1","namespacedName":{"Namespace":"openshift-storage","Name":"ocs-storagecluster"},"storageProviderEndpoint":"","cephClusterFSID":"7a3d6b81-a55d-44fe-84d0-46c67cd395ca","storageProviderPublicEndpoint":""},"clientManagedClusterName":"cluster1-name","clientId":"client1"}`,
-			"cluster2-name_client2": `{"clusterId":"cluster2","name":"client2","providerInfo":{"version":"4.Y.Z","deploymentType":"internal","providerManagedClusterName":"cluster2","namespacedName":{"Namespace":"openshift-storage","Name":"ocs-storagecluster"},"storageProviderEndpoint":"","cephClusterFSID":"8b3d6b81-b55d-55fe-94d0-56c67cd495ca","storageProviderPublicEndpoint":""},"clientManagedClusterName":"cluster2-name","clientId":"client2"}`,
+		inventory := &multiclusterv1alpha1.StorageInventory{}
+		err = c.Get(ctx, types.NamespacedName{Name: "cluster1"}, inventory)
+		assert.NoError(t, err)
+		assert.Empty(t, inventory.Status.Providers)
+		assert.Empty(t, inventory.Status.Clients)
+		assert.True(t, meta.IsStatusConditionTrue(inventory.Status.Conditions, multiclusterv1alpha1.StorageInventorySynced))
+	})
+
+	t.Run("Delete legacy client info ConfigMap", func(t *testing.T) {
+		ctx := context.TODO()
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ClientInfoConfigMapName, Namespace: "openshift-operators"},
 		}
+		err := c.Create(ctx, cm)
+		assert.NoError(t, err)
+
+		err = deleteLegacyClientInfoConfigMap(ctx, c, "openshift-operators", logger)
+		assert.NoError(t, err)
+
+		err = c.Get(ctx, types.NamespacedName{Name: ClientInfoConfigMapName, Namespace: "openshift-operators"}, cm)
+		assert.True(t, k8serrors.IsNotFound(err))
 
-		assert.Equal(t, expectedData, cm.Data)
-		assert.Equal(t, 2, len(cm.OwnerReferences))
+		// Once deleted, the migration is a no-op
+		err = deleteLegacyClientInfoConfigMap(ctx, c, "openshift-operators", logger)
+		assert.NoError(t, err)
 	})
 }
+
+func TestSetProviderRefreshTimes(t *testing.T) {
+	previousRefresh := metav1.NewTime(time.Now().Add(-2 * time.Hour).Truncate(time.Second))
+	stamped := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+
+	previousProviders := []multiclusterv1alpha1.StorageProvider{
+		{Name: "ocs-storagecluster", Namespace: "openshift-storage", ResourceVersion: "1", LastRefreshTime: &previousRefresh},
+	}
+
+	tests := []struct {
+		name        string
+		provider    string
+		refreshTime *metav1.Time
+		want        *metav1.Time
+	}{
+		{
+			name:        "odf-info stamped by the agent",
+			provider:    "ocs-storagecluster",
+			refreshTime: &stamped,
+			want:        &stamped,
+		},
+		{
+			name:     "odf-info without stamp keeps the previous refresh time",
+			provider: "ocs-storagecluster",
+			want:     &previousRefresh,
+		},
+		{
+			name:     "odf-info never stamped has no refresh time",
+			provider: "new-storagecluster",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := []multiclusterv1alpha1.StorageProvider{
+				{Name: tt.provider, Namespace: "openshift-storage", ResourceVersion: "1"},
+			}
+			setProviderRefreshTimes(providers, previousProviders, tt.refreshTime)
+			assert.Equal(t, tt.want, providers[0].LastRefreshTime)
+		})
+	}
+}
+
+func TestGetODFInfoRefreshTime(t *testing.T) {
+	logger := utils.GetLogger(utils.GetZapLogger(true))
+	mcv := func(annotations map[string]string) viewv1beta1.ManagedClusterView {
+		raw, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": utils.ODFInfoConfigMapName, "annotations": annotations},
+		})
+		return viewv1beta1.ManagedClusterView{Status: viewv1beta1.ViewStatus{Result: runtime.RawExtension{Raw: raw}}}
+	}
+
+	refreshTime := getODFInfoRefreshTime(mcv(map[string]string{utils.ODFInfoRefreshTimeAnnotationKey: "2025-04-01T12:00:00Z"}), logger)
+	if assert.NotNil(t, refreshTime) {
+		assert.True(t, refreshTime.Equal(&metav1.Time{Time: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)}))
+	}
+	assert.Nil(t, getODFInfoRefreshTime(mcv(nil), logger))
+	assert.Nil(t, getODFInfoRefreshTime(mcv(map[string]string{utils.ODFInfoRefreshTimeAnnotationKey: "yesterday"}), logger))
+}
+
+func TestSetStaleCondition(t *testing.T) {
+	now := time.Now()
+	lastRefresh := metav1.NewTime(now.Add(-2 * time.Hour))
+	inventory := &multiclusterv1alpha1.StorageInventory{
+		Spec: multiclusterv1alpha1.StorageInventorySpec{ClusterName: "cluster1"},
+		Status: multiclusterv1alpha1.StorageInventoryStatus{
+			Providers: []multiclusterv1alpha1.StorageProvider{
+				{Name: "ocs-storagecluster", Namespace: "openshift-storage", LastRefreshTime: &lastRefresh},
+			},
+		},
+	}
+
+	setStaleCondition(inventory, 3*time.Hour, now)
+	assert.True(t, meta.IsStatusConditionFalse(inventory.Status.Conditions, multiclusterv1alpha1.StorageInventoryStale))
+	assert.Equal(t, time.Hour+time.Second, getStaleRequeueAfter(*inventory, 3*time.Hour, now).Round(time.Second))
+
+	setStaleCondition(inventory, time.Hour, now)
+	assert.True(t, meta.IsStatusConditionTrue(inventory.Status.Conditions, multiclusterv1alpha1.StorageInventoryStale))
+	assert.Equal(t, []string{"ocs-storagecluster"}, getStaleProviders(*inventory, time.Hour, now))
+
+	setStaleCondition(inventory, 0, now)
+	assert.True(t, meta.IsStatusConditionFalse(inventory.Status.Conditions, multiclusterv1alpha1.StorageInventoryStale))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"os"
+	"time"
 
 	"github.com/go-logr/zapr"
 	consolev1 "github.com/openshift/api/console/v1"
@@ -18,6 +19,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"open-cluster-management.io/addon-framework/pkg/addonmanager"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
@@ -32,6 +34,16 @@ import (
 
 var (
 	mgrScheme = runtime.NewScheme()
+
+	// startupTaskBackoff delays the retries of the tasks run once on startup, such as the registration of the token
+	// exchange addon configuration
+	startupTaskBackoff = wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    8,
+		Cap:      2 * time.Minute,
+	}
 )
 
 func init() {
@@ -56,6 +68,7 @@ type ManagerOptions struct {
 	MulticlusterConsolePort int
 	DevMode                 bool
 	KubeconfigFile          string
+	ODFInfoStaleThreshold   time.Duration
 
 	testEnvFile string
 }
@@ -78,6 +91,8 @@ func (o *ManagerOptions) AddFlags(cmd *cobra.Command) {
 	flags.BoolVar(&o.DevMode, "dev", false, "Set to true for dev environment (Text logging)")
 	flags.StringVar(&o.KubeconfigFile, "kubeconfig", "", "Paths to a kubeconfig. Only required if out-of-cluster.")
 	flags.StringVar(&o.testEnvFile, "test-dotenv", "", "Path to a dotenv file for testing purpose only.")
+	flags.DurationVar(&o.ODFInfoStaleThreshold, "odf-info-stale-threshold", 24*time.Hour,
+		"The age after which the odf-info reported by a managed cluster is considered stale. Use 0 to disable the check.")
 }
 
 func NewManagerCommand() *cobra.Command {
@@ -139,12 +154,18 @@ func (o *ManagerOptions) runManager(ctx context.Context) {
 		os.Exit(1)
 	}
 
+	if err := utils.AddHubIndexers(ctx, mgr.GetFieldIndexer()); err != nil {
+		logger.Error("Failed to register field indexers", "error", err)
+		os.Exit(1)
+	}
+
 	if err = (&MirrorPeerReconciler{
 		Client:           mgr.GetClient(),
 		Scheme:           mgr.GetScheme(),
 		Logger:           logger.With("controller", "MirrorPeerReconciler"),
 		testEnvFile:      o.testEnvFile,
 		CurrentNamespace: currentNamespace,
+		StaleThreshold:   o.ODFInfoStaleThreshold,
 	}).SetupWithManager(mgr); err != nil {
 		logger.Error("Failed to create MirrorPeer controller", "error", err)
 		os.Exit(1)
@@ -166,11 +187,34 @@ func (o *ManagerOptions) runManager(ctx context.Context) {
 		Logger:           logger.With("controller", "ManagedClusterViewReconciler"),
 		testEnvFile:      o.testEnvFile,
 		CurrentNamespace: currentNamespace,
+		StaleThreshold:   o.ODFInfoStaleThreshold,
 	}).SetupWithManager(mgr); err != nil {
 		logger.Error("Failed to create ManagedClusterView controller", "error", err)
 		os.Exit(1)
 	}
 
+	// The odf-client-info ConfigMap of earlier releases is replaced by StorageInventories, it is deleted once on
+	// startup
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		delay := startupTaskBackoff.DelayFunc()
+		for {
+			err := deleteLegacyClientInfoConfigMap(ctx, mgr.GetClient(), currentNamespace, logger)
+			if err == nil {
+				return nil
+			}
+			retryIn := delay()
+			logger.Error("Failed to delete the legacy client info ConfigMap, retrying", "RetryIn", retryIn, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(retryIn):
+			}
+		}
+	})); err != nil {
+		logger.Error("Failed to add legacy client info ConfigMap removal to manager", "error", err)
+		os.Exit(1)
+	}
+
 	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
 		err = console.InitConsole(ctx, mgr.GetClient(), o.MulticlusterConsolePort, currentNamespace)
 		if err != nil {
@@ -198,6 +242,28 @@ func (o *ManagerOptions) runManager(ctx context.Context) {
 		Client:     mgr.GetClient(),
 		AgentImage: utils.GetEnv("TOKEN_EXCHANGE_IMAGE", o.testEnvFile),
 		AddonName:  setup.TokenExchangeName,
+		Logger:     logger.With("component", "TokenExchangeAddon"),
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		// A failed registration is retried instead of returned, which would stop the manager
+		delay := startupTaskBackoff.DelayFunc()
+		for {
+			err := tokenExchangeAddon.EnsureClusterManagementAddOn(ctx)
+			if err == nil {
+				return nil
+			}
+			retryIn := delay()
+			logger.Error("Failed to register the token exchange addon configuration, retrying", "RetryIn", retryIn, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(retryIn):
+			}
+		}
+	})); err != nil {
+		logger.Error("Failed to add token exchange addon registration to manager", "error", err)
+		os.Exit(1)
 	}
 
 	logger.Info("Creating addon manager")
@@ -214,6 +280,7 @@ func (o *ManagerOptions) runManager(ctx context.Context) {
 		HubClient:        mgr.GetClient(),
 		Scheme:           mgr.GetScheme(),
 		Logger:           logger.With("controller", "DRPolicyReconciler"),
+		Recorder:         mgr.GetEventRecorderFor("drpolicy-controller"),
 		testEnvFile:      o.testEnvFile,
 		CurrentNamespace: currentNamespace,
 	}).SetupWithManager(mgr); err != nil {
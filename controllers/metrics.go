@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Stages of the MirrorPeer reconciliation whose durations are recorded
+const (
+	mirrorPeerStageAddon              = "addon"
+	mirrorPeerStageS3Sync             = "s3_sync"
+	mirrorPeerStageStorageClusterPeer = "storageclusterpeer"
+	mirrorPeerStageClientMapping      = "client_mapping"
+)
+
+var (
+	odfInfoLastRefreshTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_odf_info_last_refresh_timestamp_seconds",
+			Help: "Unix timestamp of the last refresh of the odf-info of a StorageCluster reported by a ManagedCluster",
+		},
+		[]string{"managed_cluster", "storage_cluster"},
+	)
+	odfInfoStale = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_odf_info_stale",
+			Help: "1 if the odf-info of a StorageCluster has not been refreshed within the staleness threshold, 0 otherwise",
+		},
+		[]string{"managed_cluster", "storage_cluster"},
+	)
+	staleClientInfoEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_stale_client_info_entries",
+			Help: "Number of StorageClients, and of StorageClusters without StorageClients, of a ManagedCluster whose odf-info is stale",
+		},
+		[]string{"managed_cluster"},
+	)
+	mirrorPeerPhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_mirrorpeer_phase",
+			Help: "1 for the current phase of a MirrorPeer",
+		},
+		[]string{"mirror_peer", "peers", "phase"},
+	)
+	mirrorPeerCondition = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_mirrorpeer_condition",
+			Help: "1 for the current status of a condition of a MirrorPeer",
+		},
+		[]string{"mirror_peer", "peers", "condition", "status"},
+	)
+	mirrorPeerReadyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "odf_multicluster_orchestrator_mirrorpeer_ready_duration_seconds",
+			Help:    "Time from the creation of a MirrorPeer until it first reached a ready phase",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 10),
+		},
+		[]string{"type"},
+	)
+	mirrorPeerStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "odf_multicluster_orchestrator_mirrorpeer_reconcile_stage_duration_seconds",
+			Help:    "Duration of a stage of the reconciliation of a MirrorPeer",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"stage", "result"},
+	)
+	ramenConfigUpdateFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "odf_multicluster_orchestrator_ramen_config_update_failures_total",
+			Help: "Number of failures to update the S3 profiles of a MirrorPeer in the Ramen hub operator config",
+		},
+		[]string{"mirror_peer"},
+	)
+	drPolicyCondition = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "odf_multicluster_orchestrator_drpolicy_condition",
+			Help: "1 for the current status of a condition set by the orchestrator on a DRPolicy",
+		},
+		[]string{"drpolicy", "condition", "status"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(odfInfoLastRefreshTimestamp, odfInfoStale, staleClientInfoEntries, mirrorPeerPhase,
+		mirrorPeerCondition, mirrorPeerReadyDuration, mirrorPeerStageDuration, ramenConfigUpdateFailures, drPolicyCondition)
+}
+
+// recordStorageInventoryMetrics replaces the odf-info freshness metrics of the ManagedCluster with the providers of its inventories
+func recordStorageInventoryMetrics(clusterName string, inventories []multiclusterv1alpha1.StorageInventory, staleProviders []string) {
+	labels := prometheus.Labels{"managed_cluster": clusterName}
+	odfInfoLastRefreshTimestamp.DeletePartialMatch(labels)
+	odfInfoStale.DeletePartialMatch(labels)
+
+	staleEntries := 0
+	for _, inventory := range inventories {
+		for _, provider := range inventory.Status.Providers {
+			if provider.LastRefreshTime == nil {
+				continue
+			}
+			odfInfoLastRefreshTimestamp.WithLabelValues(clusterName, provider.Name).Set(float64(provider.LastRefreshTime.Unix()))
+			stale := 0.0
+			if slices.Contains(staleProviders, provider.Name) {
+				stale = 1
+				// The client info holds an entry for each StorageClient of the provider, or one for the provider
+				// without StorageClients
+				clients := 0
+				for _, storageClient := range inventory.Status.Clients {
+					if storageClient.ProviderName == provider.Name {
+						clients++
+					}
+				}
+				staleEntries += max(clients, 1)
+			}
+			odfInfoStale.WithLabelValues(clusterName, provider.Name).Set(stale)
+		}
+	}
+	staleClientInfoEntries.WithLabelValues(clusterName).Set(float64(staleEntries))
+}
+
+// deleteStorageInventoryMetrics removes the odf-info freshness metrics of a ManagedCluster
+func deleteStorageInventoryMetrics(clusterName string) {
+	labels := prometheus.Labels{"managed_cluster": clusterName}
+	odfInfoLastRefreshTimestamp.DeletePartialMatch(labels)
+	odfInfoStale.DeletePartialMatch(labels)
+	staleClientInfoEntries.DeletePartialMatch(labels)
+}
+
+// getPeersLabel returns the ManagedClusters peered by the MirrorPeer as a sorted, comma separated list
+func getPeersLabel(mp *multiclusterv1alpha1.MirrorPeer) string {
+	peers := make([]string, 0, len(mp.Spec.Items))
+	for _, peerRef := range mp.Spec.Items {
+		peers = append(peers, peerRef.ClusterName)
+	}
+	slices.Sort(peers)
+	return strings.Join(peers, ",")
+}
+
+// recordMirrorPeerMetrics replaces the phase and condition metrics of the MirrorPeer with its current status
+func recordMirrorPeerMetrics(mp *multiclusterv1alpha1.MirrorPeer) {
+	labels := prometheus.Labels{"mirror_peer": mp.Name}
+	mirrorPeerPhase.DeletePartialMatch(labels)
+	mirrorPeerCondition.DeletePartialMatch(labels)
+	peers := getPeersLabel(mp)
+	if mp.Status.Phase != "" {
+		mirrorPeerPhase.WithLabelValues(mp.Name, peers, string(mp.Status.Phase)).Set(1)
+	}
+	for _, condition := range mp.Status.Conditions {
+		mirrorPeerCondition.WithLabelValues(mp.Name, peers, condition.Type, string(condition.Status)).Set(1)
+	}
+}
+
+// deleteMirrorPeerMetrics removes the metrics of a deleted MirrorPeer
+func deleteMirrorPeerMetrics(name string) {
+	labels := prometheus.Labels{"mirror_peer": name}
+	mirrorPeerPhase.DeletePartialMatch(labels)
+	mirrorPeerCondition.DeletePartialMatch(labels)
+	ramenConfigUpdateFailures.DeletePartialMatch(labels)
+}
+
+// observeMirrorPeerReady records the time the MirrorPeer took to reach a ready phase
+func observeMirrorPeerReady(mp *multiclusterv1alpha1.MirrorPeer, now time.Time) {
+	mirrorPeerReadyDuration.WithLabelValues(string(mp.Spec.Type)).Observe(now.Sub(mp.CreationTimestamp.Time).Seconds())
+}
+
+// observeMirrorPeerStage records the duration of a stage of the MirrorPeer reconciliation started at the given time
+func observeMirrorPeerStage(stage string, started time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	mirrorPeerStageDuration.WithLabelValues(stage, result).Observe(time.Since(started).Seconds())
+}
+
+// recordDRPolicyMetrics replaces the condition metrics of the DRPolicy with the conditions set by the orchestrator
+func recordDRPolicyMetrics(dp *ramenv1alpha1.DRPolicy) {
+	deleteDRPolicyMetrics(dp.Name)
+	for _, conditionType := range drPolicyConditionTypes {
+		if condition := meta.FindStatusCondition(dp.Status.Conditions, conditionType); condition != nil {
+			drPolicyCondition.WithLabelValues(dp.Name, condition.Type, string(condition.Status)).Set(1)
+		}
+	}
+}
+
+// deleteDRPolicyMetrics removes the condition metrics of a DRPolicy
+func deleteDRPolicyMetrics(name string) {
+	drPolicyCondition.DeletePartialMatch(prometheus.Labels{"drpolicy": name})
+}
@@ -0,0 +1,122 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// getGaugeValues returns the values of the series of a gauge served by the metrics registry, keyed by their labels
+// formatted as name=value pairs separated by commas
+func getGaugeValues(t *testing.T, name string) map[string]float64 {
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			values[strings.Join(labels, ",")] = metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestRecordStorageInventoryMetricsStaleClientInfoEntries(t *testing.T) {
+	refreshed := metav1.NewTime(time.Now())
+	inventories := []multiclusterv1alpha1.StorageInventory{{
+		Spec: multiclusterv1alpha1.StorageInventorySpec{ClusterName: "metrics-cluster"},
+		Status: multiclusterv1alpha1.StorageInventoryStatus{
+			Providers: []multiclusterv1alpha1.StorageProvider{
+				{Name: "provider-with-clients", LastRefreshTime: &refreshed},
+				{Name: "provider-without-clients", LastRefreshTime: &refreshed},
+				{Name: "fresh-provider", LastRefreshTime: &refreshed},
+			},
+			Clients: []multiclusterv1alpha1.StorageClient{
+				{Name: "client1", ProviderName: "provider-with-clients"},
+				{Name: "client2", ProviderName: "provider-with-clients"},
+				{Name: "client3", ProviderName: "fresh-provider"},
+			},
+		},
+	}}
+	const metric = "odf_multicluster_orchestrator_stale_client_info_entries"
+
+	recordStorageInventoryMetrics("metrics-cluster", inventories, []string{"provider-with-clients", "provider-without-clients"})
+	// Both StorageClients of the first provider and the second provider without StorageClients are stale
+	assert.Equal(t, 3.0, getGaugeValues(t, metric)["managed_cluster=metrics-cluster"])
+
+	deleteStorageInventoryMetrics("metrics-cluster")
+	assert.NotContains(t, getGaugeValues(t, metric), "managed_cluster=metrics-cluster")
+}
+
+func TestRecordMirrorPeerMetrics(t *testing.T) {
+	mirrorPeer := &multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-mirrorpeer"},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{{ClusterName: "west"}, {ClusterName: "east"}},
+		},
+		Status: multiclusterv1alpha1.MirrorPeerStatus{
+			Phase: multiclusterv1alpha1.ExchangingSecret,
+			Conditions: []metav1.Condition{
+				{Type: multiclusterv1alpha1.MirrorPeerConditionAgentsUpgraded, Status: metav1.ConditionFalse},
+			},
+		},
+	}
+	const phaseMetric = "odf_multicluster_orchestrator_mirrorpeer_phase"
+	const conditionMetric = "odf_multicluster_orchestrator_mirrorpeer_condition"
+
+	recordMirrorPeerMetrics(mirrorPeer)
+	assert.Equal(t, 1.0, getGaugeValues(t, phaseMetric)["mirror_peer=metrics-mirrorpeer,peers=east,west,phase=ExchangingSecret"])
+	assert.Equal(t, 1.0, getGaugeValues(t, conditionMetric)["condition=AgentsUpgraded,mirror_peer=metrics-mirrorpeer,peers=east,west,status=False"])
+
+	// Only the current phase and condition statuses are reported
+	mirrorPeer.Status.Phase = multiclusterv1alpha1.ExchangedSecret
+	mirrorPeer.Status.Conditions[0].Status = metav1.ConditionTrue
+	recordMirrorPeerMetrics(mirrorPeer)
+	phases := getGaugeValues(t, phaseMetric)
+	assert.NotContains(t, phases, "mirror_peer=metrics-mirrorpeer,peers=east,west,phase=ExchangingSecret")
+	assert.Equal(t, 1.0, phases["mirror_peer=metrics-mirrorpeer,peers=east,west,phase=ExchangedSecret"])
+	conditions := getGaugeValues(t, conditionMetric)
+	assert.NotContains(t, conditions, "condition=AgentsUpgraded,mirror_peer=metrics-mirrorpeer,peers=east,west,status=False")
+	assert.Equal(t, 1.0, conditions["condition=AgentsUpgraded,mirror_peer=metrics-mirrorpeer,peers=east,west,status=True"])
+
+	deleteMirrorPeerMetrics(mirrorPeer.Name)
+	assert.NotContains(t, getGaugeValues(t, phaseMetric), "mirror_peer=metrics-mirrorpeer,peers=east,west,phase=ExchangedSecret")
+	assert.NotContains(t, getGaugeValues(t, conditionMetric), "condition=AgentsUpgraded,mirror_peer=metrics-mirrorpeer,peers=east,west,status=True")
+}
+
+func TestRecordDRPolicyMetrics(t *testing.T) {
+	drPolicy := &ramenv1alpha1.DRPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-drpolicy"},
+		Status: ramenv1alpha1.DRPolicyStatus{
+			Conditions: []metav1.Condition{
+				{Type: DRPolicyConditionMirrorPeerReady, Status: metav1.ConditionFalse},
+				{Type: "Validated", Status: metav1.ConditionTrue},
+			},
+		},
+	}
+	const metric = "odf_multicluster_orchestrator_drpolicy_condition"
+
+	recordDRPolicyMetrics(drPolicy)
+	values := getGaugeValues(t, metric)
+	assert.Equal(t, 1.0, values["condition=MirrorPeerReady,drpolicy=metrics-drpolicy,status=False"])
+	// Conditions set by Ramen are not reported by the orchestrator
+	assert.NotContains(t, values, "condition=Validated,drpolicy=metrics-drpolicy,status=True")
+
+	deleteDRPolicyMetrics(drPolicy.Name)
+	assert.NotContains(t, getGaugeValues(t, metric), "condition=MirrorPeerReady,drpolicy=metrics-drpolicy,status=False")
+}
@@ -19,8 +19,12 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/version"
@@ -32,6 +36,7 @@ import (
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -39,10 +44,12 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -56,6 +63,8 @@ type MirrorPeerReconciler struct {
 
 	testEnvFile      string
 	CurrentNamespace string
+	// StaleThreshold is the age after which the odf-info of a provider is considered stale. Zero disables the check.
+	StaleThreshold time.Duration
 }
 
 const (
@@ -67,12 +76,13 @@ const (
 // +kubebuilder:rbac:groups=multicluster.odf.openshift.io,resources=mirrorpeers,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=multicluster.odf.openshift.io,resources=mirrorpeers/status,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups=multicluster.odf.openshift.io,resources=mirrorpeers/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups=multicluster.odf.openshift.io,resources=storageinventories,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=multicluster.odf.openshift.io,resources=storageinventories/status,verbs=get;update;patch
 
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete;deletecollection
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
-// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update,resourceNames=odf-client-info
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get
@@ -84,6 +94,7 @@ const (
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 
 // +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=get;create
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingadmissionpolicies;validatingadmissionpolicybindings,verbs=get;list;watch;create;update
 
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests;certificatesigningrequests/approval,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,verbs=approve
@@ -93,13 +104,16 @@ const (
 // +kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=managedclusteraddons/finalizers,verbs=create;update;patch;delete
 // +kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=managedclusteraddons/status,verbs=create;update;patch;delete
 // +kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=clustermanagementaddons,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=addondeploymentconfigs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=view.open-cluster-management.io,resources=managedclusterviews,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=clusterclaims,verbs=get;list;watch
 
 // +kubebuilder:rbac:groups=console.openshift.io,resources=consoleplugins,verbs=get;create;update
 
 // +kubebuilder:rbac:groups=ramendr.openshift.io,resources=drclusters,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=ramendr.openshift.io,resources=drpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ramendr.openshift.io,resources=drpolicies/status,verbs=get;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -118,17 +132,36 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			logger.Info("Could not find MirrorPeer. Ignoring since object must have been deleted")
+			deleteMirrorPeerMetrics(req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		logger.Error("Failed to get MirrorPeer", "error", err)
 		return ctrl.Result{}, err
 	}
+	// The metrics report the status the reconciliation leaves the MirrorPeer in
+	defer recordMirrorPeerMetrics(&mirrorPeer)
+
+	// The classification is recorded before the first status update, which would classify a new MirrorPeer as one
+	// predating the classification
+	if _, ok := mirrorPeer.Annotations[utils.PeerRefClassificationAnnotationKey]; !ok && mirrorPeer.GetDeletionTimestamp().IsZero() {
+		classification := utils.GetPeerRefClassification(mirrorPeer)
+		logger.Info("Recording the peerRef classification of MirrorPeer", "Classification", classification)
+		if mirrorPeer.Annotations == nil {
+			mirrorPeer.Annotations = make(map[string]string)
+		}
+		mirrorPeer.Annotations[utils.PeerRefClassificationAnnotationKey] = classification
+		if err := r.Client.Update(ctx, &mirrorPeer); err != nil {
+			logger.Error("Failed to update mirrorpeer with peerRef classification annotation", "error", err)
+			return checkK8sUpdateErrors(err, &mirrorPeer, logger)
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
 
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, r.Client, r.CurrentNamespace)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, r.Client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			logger.Info("ConfigMap 'odf-client-info' not found. Requeueing.")
+			logger.Info("No StorageInventory found. Requeueing.")
 			return ctrl.Result{Requeue: true}, nil
 		}
 		return ctrl.Result{}, err
@@ -146,21 +179,89 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logger.Error("MirrorPeer spec items are not unique", "error", err)
 		return ctrl.Result{Requeue: false}, err
 	}
+	// A MirrorPeer of a detached ManagedCluster can not be validated anymore, but it must still be deletable
+	detachedDeletion := !mirrorPeer.GetDeletionTimestamp().IsZero() &&
+		meta.IsStatusConditionTrue(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionClusterDetached)
+	// MirrorPeer.Spec.Items must still be reported by the StorageInventories. A MirrorPeer whose peer storage was
+	// uninstalled or offboarded must still be deletable.
+	if err := peerRefsInStorageInventory(mirrorPeer, clientInfoMap); err != nil && mirrorPeer.GetDeletionTimestamp().IsZero() {
+		logger.Error("Can not reconcile MirrorPeer", "error", err)
+		mirrorPeer.Status.Phase = multiclusterv1alpha1.PeerStorageNotFound
+		mirrorPeer.Status.Message = err.Error()
+		statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+		if statusErr != nil {
+			logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", statusErr)
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if mirrorPeer.Status.Phase == multiclusterv1alpha1.PeerStorageNotFound {
+		mirrorPeer.Status.Phase = ""
+		mirrorPeer.Status.Message = ""
+	}
 	for i := range mirrorPeer.Spec.Items {
 		// MirrorPeer.Spec.Items must not have empty fields
 		if err := emptySpecItems(mirrorPeer.Spec.Items[i]); err != nil {
 			logger.Error("MirrorPeer spec items have empty fields", "error", err)
 			return reconcile.Result{Requeue: false}, err
 		}
+		if detachedDeletion {
+			continue
+		}
 		// MirrorPeer.Spec.Items[*].ClusterName must be a valid ManagedCluster
 		if err := isManagedCluster(ctx, r.Client, mirrorPeer.Spec.Items[i].ClusterName); err != nil {
 			logger.Error("Invalid ManagedCluster", "ClusterName", mirrorPeer.Spec.Items[i].ClusterName, "error", err)
 			return ctrl.Result{}, err
 		}
-		// MirrorPeer.Spec.Items[*].StorageClusterRef must have a compatible version
-		if err := isVersionCompatible(mirrorPeer.Spec.Items[i], clientInfoMap.Data); err != nil {
-			logger.Error("Can not reconcile MirrorPeer", "error", err)
-			mirrorPeer.Status.Phase = multiclusterv1alpha1.IncompatibleVersion
+	}
+	// MirrorPeer.Spec.Items[*].StorageClusterRef versions are checked against the version policy. Mixed versions are
+	// expected while the sites are upgraded one after the other, so the result is reported without blocking the MirrorPeer.
+	if !detachedDeletion && mirrorPeer.GetDeletionTimestamp().IsZero() {
+		policy, policyErr := getVersionPolicy(ctx, r.Client, r.CurrentNamespace)
+		if setVersionCompatibleCondition(&mirrorPeer, clientInfoMap, policy, policyErr, version.Version) {
+			if cond := meta.FindStatusCondition(mirrorPeer.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionVersionCompatible); cond.Status != metav1.ConditionTrue {
+				logger.Warn("StorageCluster versions of MirrorPeer do not comply with the version policy", "Reason", cond.Reason, "Message", cond.Message)
+			}
+			if mirrorPeer.Status.Phase == multiclusterv1alpha1.IncompatibleVersion {
+				mirrorPeer.Status.Phase = ""
+				mirrorPeer.Status.Message = ""
+			}
+			if err := r.Client.Status().Update(ctx, &mirrorPeer); err != nil {
+				logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", err)
+				return ctrl.Result{Requeue: true}, nil
+			}
+		}
+	}
+	// MirrorPeer.Spec.Items must have been refreshed recently, stale odf-info may hold outdated endpoints and versions
+	if mirrorPeer.GetDeletionTimestamp().IsZero() {
+		now := time.Now()
+		for _, peerRef := range mirrorPeer.Spec.Items {
+			if err := isPeerRefFresh(peerRef, clientInfoMap, r.StaleThreshold, now); err != nil {
+				logger.Error("Can not reconcile MirrorPeer", "error", err)
+				mirrorPeer.Status.Phase = multiclusterv1alpha1.StalePeerStorage
+				mirrorPeer.Status.Message = err.Error()
+				statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+				if statusErr != nil {
+					logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", statusErr)
+					return ctrl.Result{Requeue: true}, nil
+				}
+				return ctrl.Result{}, err
+			}
+		}
+		if mirrorPeer.Status.Phase == multiclusterv1alpha1.StalePeerStorage {
+			mirrorPeer.Status.Phase = ""
+			mirrorPeer.Status.Message = ""
+		}
+	}
+	// MirrorPeer.Spec.Items of a sync MirrorPeer must consume the same external Ceph cluster
+	if mirrorPeer.Spec.Type == multiclusterv1alpha1.Sync && mirrorPeer.GetDeletionTimestamp().IsZero() {
+		err := validateSyncPeers(ctx, r.Client, mirrorPeer, clientInfoMap)
+		if err == nil {
+			err = validateSyncDRPolicies(ctx, r.Client, mirrorPeer)
+		}
+		if err != nil {
+			logger.Error("Can not reconcile sync MirrorPeer", "error", err)
+			mirrorPeer.Status.Phase = multiclusterv1alpha1.InvalidSyncPeers
 			mirrorPeer.Status.Message = err.Error()
 			statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
 			if statusErr != nil {
@@ -168,11 +269,28 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				return ctrl.Result{Requeue: true}, nil
 			}
 			return ctrl.Result{}, err
-		} else {
-			if mirrorPeer.Status.Phase == multiclusterv1alpha1.IncompatibleVersion {
-				mirrorPeer.Status.Phase = ""
-				mirrorPeer.Status.Message = ""
+		}
+		if mirrorPeer.Status.Phase == multiclusterv1alpha1.InvalidSyncPeers {
+			mirrorPeer.Status.Phase = ""
+			mirrorPeer.Status.Message = ""
+		}
+	}
+	// MirrorPeer.Spec.Items of a heterogeneous MirrorPeer must be served by different StorageClusters
+	if mirrorPeer.GetDeletionTimestamp().IsZero() {
+		if err := validateHeterogeneousPeers(mirrorPeer, clientInfoMap); err != nil {
+			logger.Error("Can not reconcile heterogeneous MirrorPeer", "error", err)
+			mirrorPeer.Status.Phase = multiclusterv1alpha1.InvalidHeterogeneousPeers
+			mirrorPeer.Status.Message = err.Error()
+			statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+			if statusErr != nil {
+				logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", statusErr)
+				return ctrl.Result{Requeue: true}, nil
 			}
+			return ctrl.Result{}, err
+		}
+		if mirrorPeer.Status.Phase == multiclusterv1alpha1.InvalidHeterogeneousPeers {
+			mirrorPeer.Status.Phase = ""
+			mirrorPeer.Status.Message = ""
 		}
 	}
 	logger.Info("All validations for MirrorPeer passed")
@@ -230,6 +348,13 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return reconcile.Result{}, nil
 	}
 
+	// The peerRefs of a MirrorPeer may mix StorageClients and StorageClusters, each peerRef is handled according to its type
+	peerRefTypes, err := utils.GetPeerRefTypes(clientInfoMap, mirrorPeer)
+	if err != nil {
+		logger.Error("Failed to determine the types of the MirrorPeer peerRefs", "error", err)
+		return ctrl.Result{}, err
+	}
+
 	mirrorPeerCopy := mirrorPeer.DeepCopy()
 	if mirrorPeerCopy.Labels == nil {
 		mirrorPeerCopy.Labels = make(map[string]string)
@@ -248,6 +373,41 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// The agents of StorageCluster peers only take part in the StorageClusterPeer peering when asked to
+	if isStorageClusterPeering(mirrorPeer, peerRefTypes) && mirrorPeerCopy.Annotations[utils.StorageClusterPeeringAnnotationKey] != "true" {
+		logger.Info("Adding StorageClusterPeer peering annotation to MirrorPeer")
+		if mirrorPeerCopy.Annotations == nil {
+			mirrorPeerCopy.Annotations = make(map[string]string)
+		}
+		mirrorPeerCopy.Annotations[utils.StorageClusterPeeringAnnotationKey] = "true"
+		err = r.Client.Update(ctx, mirrorPeerCopy)
+		if err != nil {
+			logger.Error("Failed to update mirrorpeer with StorageClusterPeer peering annotation", "error", err)
+			return checkK8sUpdateErrors(err, mirrorPeerCopy, logger)
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// The agents of the providers of StorageClient peers are only admitted to update the MirrorPeer once they are
+	// listed on it
+	if providerClusters := strings.Join(getProviderOnlyClusters(mirrorPeer, clientInfoMap), ","); mirrorPeerCopy.Annotations[utils.ProviderClustersAnnotationKey] != providerClusters {
+		logger.Info("Updating provider clusters annotation of MirrorPeer", "ProviderClusters", providerClusters)
+		if providerClusters == "" {
+			delete(mirrorPeerCopy.Annotations, utils.ProviderClustersAnnotationKey)
+		} else {
+			if mirrorPeerCopy.Annotations == nil {
+				mirrorPeerCopy.Annotations = make(map[string]string)
+			}
+			mirrorPeerCopy.Annotations[utils.ProviderClustersAnnotationKey] = providerClusters
+		}
+		err = r.Client.Update(ctx, mirrorPeerCopy)
+		if err != nil {
+			logger.Error("Failed to update mirrorpeer with provider clusters annotation", "error", err)
+			return checkK8sUpdateErrors(err, mirrorPeerCopy, logger)
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	if mirrorPeer.Status.Phase == "" {
 		if mirrorPeer.Spec.Type == multiclusterv1alpha1.Async {
 			mirrorPeer.Status.Phase = multiclusterv1alpha1.ExchangingSecret
@@ -262,116 +422,152 @@ func (r *MirrorPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	// Check if the MirrorPeer contains StorageClient reference
-	hasStorageClientRef, err := utils.IsStorageClientType(ctx, r.Client, mirrorPeer, false)
+	addonStarted := time.Now()
+	err = r.processManagedClusterAddon(ctx, mirrorPeer)
+	observeMirrorPeerStage(mirrorPeerStageAddon, addonStarted, err)
 	if err != nil {
-		logger.Error("Failed to determine if MirrorPeer contains StorageClient reference", "error", err)
-		return ctrl.Result{}, err
-	}
-
-	if err := r.processManagedClusterAddon(ctx, mirrorPeer); err != nil {
 		logger.Error("Failed to process managedclusteraddon", "error", err)
 		return ctrl.Result{}, err
 	}
 
 	// update s3 profile when MirrorPeer changes
 	if mirrorPeer.Spec.ManageS3 {
-		for _, peerRef := range mirrorPeer.Spec.Items {
-			var s3Secret corev1.Secret
-			var secretName string
-			var namespace string
-
-			if hasStorageClientRef {
-				s3SecretName, s3SecretNamespace, err := GetNamespacedNameForClientS3Secret(ctx, r.Client, r.CurrentNamespace, peerRef, &mirrorPeer)
-				if err != nil {
-					return ctrl.Result{}, fmt.Errorf("failed to get namespace for s3 secret %w", err)
-				}
-				secretName = s3SecretName
-				namespace = s3SecretNamespace
-			} else {
-				secretName = utils.GetSecretNameByPeerRef(peerRef, utils.S3ProfilePrefix)
-				namespace = peerRef.ClusterName
-			}
-
-			namespacedName := types.NamespacedName{
-				Name:      secretName,
-				Namespace: namespace,
-			}
-			err = r.Client.Get(ctx, namespacedName, &s3Secret)
-			if err != nil {
-				if k8serrors.IsNotFound(err) {
-					logger.Info("S3 secret is not yet synchronised. retrying till it is available. Requeing request...", "Secret Name", secretName, "Namespace/Cluster", namespace)
-					return ctrl.Result{Requeue: true}, nil
-				}
-				logger.Error("Error in fetching s3 internal secret", "Cluster", peerRef.ClusterName, "error", err)
-				return ctrl.Result{}, err
-			}
+		s3SyncStarted := time.Now()
+		result, err := r.syncS3Profiles(ctx, logger, mirrorPeer, peerRefTypes)
+		observeMirrorPeerStage(mirrorPeerStageS3Sync, s3SyncStarted, err)
+		if err != nil || !result.IsZero() {
+			return result, err
+		}
+	}
 
-			err = utils.CreateOrUpdateSecretsFromInternalSecret(ctx, r.Client, r.Scheme, r.CurrentNamespace, &s3Secret, mirrorPeer, logger)
-			if err != nil {
-				logger.Error("Error in updating S3 profile", "Cluster", peerRef.ClusterName, "error", err)
-				return ctrl.Result{}, err
-			}
+	// Peering is driven by the capabilities which the ODF versions of both peers support
+	requiredCapabilities := getMirrorPeerRequiredCapabilities(mirrorPeer, peerRefTypes)
+	missingCapabilities, err := utils.GetMissingCapabilities(ctx, r.Client, getPeerProviderClusters(mirrorPeer, clientInfoMap), requiredCapabilities)
+	if err != nil {
+		logger.Error("Failed to get capabilities of the peers", "error", err)
+		return ctrl.Result{}, err
+	}
+	if setCapabilitiesSupportedCondition(&mirrorPeer, requiredCapabilities, missingCapabilities) {
+		if err := r.Client.Status().Update(ctx, &mirrorPeer); err != nil {
+			logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", err)
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
 
-			err = r.createDRClusters(ctx, peerRef.ClusterName, s3Secret, mirrorPeer)
-			if err != nil {
-				if k8serrors.IsNotFound(err) {
-					logger.Info("Secret not synchronised yet, retrying to create DRCluster", "MirrorPeer", mirrorPeer.Name)
-					return ctrl.Result{Requeue: true}, nil
-				}
-				logger.Error("Failed to create DRClusters for MirrorPeer", "error", err)
-				return ctrl.Result{}, err
-			}
+	// Artifacts which previous agents do not understand are only created once the agents of both peers completed their
+	// upgrade. The agents publish their version on their ManagedCluster, whose changes requeue the MirrorPeer.
+	outdatedAgents, err := getOutdatedAgents(ctx, r.Client, getPeerProviderClusters(mirrorPeer, clientInfoMap))
+	if err != nil {
+		logger.Error("Failed to get the versions of the agents", "error", err)
+		return ctrl.Result{}, err
+	}
+	if setAgentsUpgradedCondition(&mirrorPeer, outdatedAgents, version.Version) {
+		if err := r.Client.Status().Update(ctx, &mirrorPeer); err != nil {
+			logger.Error("Error occurred while updating the status of mirrorpeer. Requeing request.", "Error ", err)
+			return ctrl.Result{Requeue: true}, nil
 		}
 	}
 
-	if hasStorageClientRef && mirrorPeer.Spec.Type == multiclusterv1alpha1.Async {
+	if len(missingCapabilities) > 0 {
+		logger.Warn("Peers do not support the capabilities required to peer StorageClients", "MissingCapabilities", utils.FormatMissingCapabilities(missingCapabilities))
+	} else if len(outdatedAgents) > 0 {
+		logger.Info("Waiting for the agents of the peers to complete their upgrade", "Version", version.Version, "OutdatedAgents", outdatedAgents)
+	} else if isStorageClusterPeering(mirrorPeer, peerRefTypes) {
+		storageClusterPeerStarted := time.Now()
 		result, err := createStorageClusterPeer(ctx, r.Client, logger, r.CurrentNamespace, mirrorPeer)
+		observeMirrorPeerStage(mirrorPeerStageStorageClusterPeer, storageClusterPeerStarted, err)
 		if err != nil {
 			logger.Error("Failed to create StorageClusterPeer", "error", err)
 			return result, err
 		}
 
+		clientMappingStarted := time.Now()
 		result, err = createManifestWorkForClusterPairingConfigMap(ctx, r.Client, logger, r.CurrentNamespace, mirrorPeer)
+		observeMirrorPeerStage(mirrorPeerStageClientMapping, clientMappingStarted, err)
 		if err != nil {
 			logger.Error("Failed to create ManifestWork for ClusterPairingConfigMap", "error", err)
 			return result, err
 		}
 	}
 
-	return r.updateMirrorPeerStatus(ctx, mirrorPeer, hasStorageClientRef)
+	return r.updateMirrorPeerStatus(ctx, &mirrorPeer, peerRefTypes)
+}
+
+// syncS3Profiles updates the S3 profiles and DRClusters of the MirrorPeer with the S3 secrets synced by the agents of the
+// peers. A non-zero result is returned while a secret is not synced yet.
+func (r *MirrorPeerReconciler) syncS3Profiles(ctx context.Context, logger *slog.Logger, mirrorPeer multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) (ctrl.Result, error) {
+	for i, peerRef := range mirrorPeer.Spec.Items {
+		var s3Secret corev1.Secret
+		namespacedName, err := getS3SecretNamespacedName(ctx, r.Client, r.CurrentNamespace, peerRef, peerRefTypes[i], &mirrorPeer)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get namespace for s3 secret %w", err)
+		}
+		err = r.Client.Get(ctx, namespacedName, &s3Secret)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				logger.Info("S3 secret is not yet synchronised. retrying till it is available. Requeing request...", "Secret Name", namespacedName.Name, "Namespace/Cluster", namespacedName.Namespace)
+				return ctrl.Result{Requeue: true}, nil
+			}
+			logger.Error("Error in fetching s3 internal secret", "Cluster", peerRef.ClusterName, "error", err)
+			return ctrl.Result{}, err
+		}
+
+		err = utils.CreateOrUpdateSecretsFromInternalSecret(ctx, r.Client, r.Scheme, r.CurrentNamespace, &s3Secret, mirrorPeer, logger)
+		if err != nil {
+			if errors.Is(err, utils.ErrRamenConfigUpdate) {
+				ramenConfigUpdateFailures.WithLabelValues(mirrorPeer.Name).Inc()
+			}
+			logger.Error("Error in updating S3 profile", "Cluster", peerRef.ClusterName, "error", err)
+			return ctrl.Result{}, err
+		}
+
+		err = r.createDRClusters(ctx, peerRef.ClusterName, s3Secret, mirrorPeer)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				logger.Info("Secret not synchronised yet, retrying to create DRCluster", "MirrorPeer", mirrorPeer.Name)
+				return ctrl.Result{Requeue: true}, nil
+			}
+			logger.Error("Failed to create DRClusters for MirrorPeer", "error", err)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
 }
 
 func createManifestWorkForClusterPairingConfigMap(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer multiclusterv1alpha1.MirrorPeer) (ctrl.Result, error) {
 	logger.Info("Starting to create ManifestWork for cluster pairing ConfigMap")
 
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			logger.Info("Client info ConfigMap not found; requeuing for later retry")
+			logger.Info("No StorageInventory found; requeuing for later retry")
 			return ctrl.Result{Requeue: true}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	logger.Info("Fetched client info ConfigMap successfully")
+	logger.Info("Fetched client info from StorageInventories successfully")
 	items := mirrorPeer.Spec.Items
 
-	ci1, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(items[0].ClusterName, items[0].StorageClusterRef.Name))
+	ci1, err := utils.GetClientInfoForPeerRef(clientInfoMap, items[0])
 	if err != nil {
-		logger.Error("Failed to get client info from ConfigMap for the first cluster")
+		logger.Error("Failed to get client info from StorageInventories for the first cluster")
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Fetched client info for the first cluster", "ClientInfo", ci1)
 
-	ci2, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(items[1].ClusterName, items[1].StorageClusterRef.Name))
+	ci2, err := utils.GetClientInfoForPeerRef(clientInfoMap, items[1])
 	if err != nil {
-		logger.Error("Failed to get client info from ConfigMap for the second cluster")
+		logger.Error("Failed to get client info from StorageInventories for the second cluster")
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Fetched client info for the second cluster", "ClientInfo", ci2)
+	if ci1.ClientID == "" || ci2.ClientID == "" {
+		logger.Info("Skipping client pairing as a peer of the MirrorPeer is a StorageCluster")
+		return ctrl.Result{}, nil
+	}
 	logger.Info("Updating provider ConfigMap with client pairing", "ProviderClient1", ci1.ClientID, "PairedClient1", ci2.ClientID)
 	if err := updateProviderConfigMap(logger, ctx, client, mirrorPeer, ci1, ci2); err != nil {
 		return ctrl.Result{}, err
@@ -455,7 +651,7 @@ func updateProviderConfigMap(logger *slog.Logger, ctx context.Context, client cl
 
 func createStorageClusterPeer(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer multiclusterv1alpha1.MirrorPeer) (ctrl.Result, error) {
 	logger = logger.With("MirrorPeer", mirrorPeer.Name)
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("Client info config map not found. Retrying request another time...")
@@ -468,7 +664,7 @@ func createStorageClusterPeer(ctx context.Context, client client.Client, logger
 
 	for _, item := range items {
 		logger.Info("Fetching info for client", "ClientKey", utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
-		ci, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
+		ci, err := utils.GetClientInfoForPeerRef(clientInfoMap, item)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -585,20 +781,20 @@ type ManagedClusterAddonConfig struct {
 func getConfig(ctx context.Context, c client.Client, currentNamespace string, mp multiclusterv1alpha1.MirrorPeer) ([]ManagedClusterAddonConfig, error) {
 	managedClusterAddonsConfig := make([]ManagedClusterAddonConfig, 0)
 
-	// Check if the MirrorPeer contains StorageClient reference
-	hasStorageClientRef, err := utils.IsStorageClientType(ctx, c, mp, false)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, c)
 	if err != nil {
 		return []ManagedClusterAddonConfig{}, err
 	}
 
-	if hasStorageClientRef {
-		clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, c, currentNamespace)
-		if err != nil {
-			return []ManagedClusterAddonConfig{}, err
-		}
-		for _, item := range mp.Spec.Items {
-			clientName := item.StorageClusterRef.Name
-			clientInfo, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(item.ClusterName, clientName))
+	peerRefTypes, err := utils.GetPeerRefTypes(clientInfoMap, mp)
+	if err != nil {
+		return []ManagedClusterAddonConfig{}, err
+	}
+
+	// The addon of a StorageClient runs on its provider, the addon of a StorageCluster runs next to it
+	for i, item := range mp.Spec.Items {
+		if peerRefTypes[i] == utils.PeerRefTypeStorageClient {
+			clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, item)
 			if err != nil {
 				return []ManagedClusterAddonConfig{}, err
 			}
@@ -608,9 +804,7 @@ func getConfig(ctx context.Context, c client.Client, currentNamespace string, mp
 				InstallNamespace: clientInfo.ProviderInfo.NamespacedName.Namespace,
 			}
 			managedClusterAddonsConfig = append(managedClusterAddonsConfig, config)
-		}
-	} else {
-		for _, item := range mp.Spec.Items {
+		} else {
 			managedClusterAddonsConfig = append(managedClusterAddonsConfig, ManagedClusterAddonConfig{
 				Name:             setup.TokenExchangeName,
 				Namespace:        item.ClusterName,
@@ -621,8 +815,35 @@ func getConfig(ctx context.Context, c client.Client, currentNamespace string, mp
 	return managedClusterAddonsConfig, nil
 }
 
+// getManagedClusterAddonModes returns the sorted DR modes of the MirrorPeers owning the ManagedClusterAddOn. MirrorPeers
+// being deleted no longer take part in the modes of the addon.
+func getManagedClusterAddonModes(ctx context.Context, c client.Client, addon *addonapiv1alpha1.ManagedClusterAddOn) ([]string, error) {
+	var modes []string
+	for _, ownerRef := range addon.GetOwnerReferences() {
+		if ownerRef.Kind != "MirrorPeer" || !strings.HasPrefix(ownerRef.APIVersion, multiclusterv1alpha1.GroupVersion.Group+"/") {
+			continue
+		}
+		var mirrorPeer multiclusterv1alpha1.MirrorPeer
+		if err := c.Get(ctx, types.NamespacedName{Name: ownerRef.Name}, &mirrorPeer); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get MirrorPeer %q owning ManagedClusterAddOn %q: %w", ownerRef.Name, addon.Namespace, err)
+		}
+		if mirrorPeer.UID != ownerRef.UID || !mirrorPeer.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if mode := string(mirrorPeer.Spec.Type); !slices.Contains(modes, mode) {
+			modes = append(modes, mode)
+		}
+	}
+	slices.Sort(modes)
+	return modes, nil
+}
+
 // processManagedClusterAddon creates an addon for the cluster management in all the peer refs,
-// the resources gets an owner ref of the mirrorpeer to let the garbage collector handle it if the mirrorpeer gets deleted
+// the resources gets an owner ref of the mirrorpeer to let the garbage collector handle it once all its mirrorpeers
+// get deleted
 func (r *MirrorPeerReconciler) processManagedClusterAddon(ctx context.Context, mirrorPeer multiclusterv1alpha1.MirrorPeer) error {
 	logger := r.Logger.With("MirrorPeer", mirrorPeer.Name)
 	logger.Info("Processing ManagedClusterAddons for MirrorPeer")
@@ -644,13 +865,30 @@ func (r *MirrorPeerReconciler) processManagedClusterAddon(ctx context.Context, m
 
 		logger.Info("Installing agents on the namespace", "InstallNamespace", config.InstallNamespace)
 		_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &managedClusterAddOn, func() error {
-			annotations := make(map[string]string)
-			annotations[utils.DRModeAnnotationKey] = string(mirrorPeer.Spec.Type)
-			annotations[AddonVersionAnnotationKey] = version.Version
+			// The addon of a cluster is shared by all its MirrorPeers, each of them owns it without controlling it
+			if err := controllerutil.SetOwnerReference(&mirrorPeer, &managedClusterAddOn, r.Scheme); err != nil {
+				return err
+			}
+			modes, err := getManagedClusterAddonModes(ctx, r.Client, &managedClusterAddOn)
+			if err != nil {
+				return err
+			}
 
-			managedClusterAddOn.Annotations = annotations
+			if managedClusterAddOn.Annotations == nil {
+				managedClusterAddOn.Annotations = make(map[string]string)
+			}
+			managedClusterAddOn.Annotations[utils.DRModeAnnotationKey] = strings.Join(modes, ",")
+			managedClusterAddOn.Annotations[AddonVersionAnnotationKey] = version.Version
 			managedClusterAddOn.Spec.InstallNamespace = config.InstallNamespace
-			return controllerutil.SetControllerReference(&mirrorPeer, &managedClusterAddOn, r.Scheme)
+			// Hosted agents get a namespace of their own on the hosting cluster, the namespace on the managed cluster
+			// is passed along as an annotation
+			if _, hosted := managedClusterAddOn.Annotations[addonapiv1alpha1.HostingClusterNameAnnotationKey]; hosted {
+				managedClusterAddOn.Annotations[setup.ManagedInstallNamespaceAnnotationKey] = config.InstallNamespace
+				managedClusterAddOn.Spec.InstallNamespace = setup.HostingInstallNamespace(config.InstallNamespace, config.Namespace)
+			} else {
+				delete(managedClusterAddOn.Annotations, setup.ManagedInstallNamespaceAnnotationKey)
+			}
+			return nil
 		})
 
 		if err != nil {
@@ -743,14 +981,38 @@ func (r *MirrorPeerReconciler) deleteSecrets(ctx context.Context, mirrorPeer mul
 func (r *MirrorPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Logger.Info("Setting up controller for MirrorPeer")
 
-	cmToMirrorPeerMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
-		r.Logger.Debug("Mapping ConfigMap to MirrorPeer", "ConfigMap", client.ObjectKeyFromObject(object))
+	storageInventoryToMirrorPeerMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		r.Logger.Debug("Mapping StorageInventory to MirrorPeer", "StorageInventory", object.GetName())
 		var reqs []ctrl.Request
-		_, ok := object.(*corev1.ConfigMap)
+		inventory, ok := object.(*multiclusterv1alpha1.StorageInventory)
 		if !ok {
-			r.Logger.Debug("Unable to cast object into a ConfigMap. Not requeing any requests.")
+			r.Logger.Debug("Unable to cast object into a StorageInventory. Not requeing any requests.")
+			return reqs
+		}
+		// The entries of a StorageInventory are used by the MirrorPeers of its cluster and of the clusters of its
+		// StorageClients
+		clusterNames := []string{inventory.Spec.ClusterName}
+		for _, storageClient := range inventory.Status.Clients {
+			if storageClient.ManagedClusterName != "" && !slices.Contains(clusterNames, storageClient.ManagedClusterName) {
+				clusterNames = append(clusterNames, storageClient.ManagedClusterName)
+			}
+		}
+		mirrorPeers, err := utils.ListMirrorPeersForClusters(ctx, r.Client, clusterNames)
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of MirrorPeers. Not requeing any requests.", "error", err)
 			return reqs
 		}
+		for _, mp := range mirrorPeers {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
+		}
+		r.Logger.Info("MirrorPeer reconcile requests generated based on StorageInventory change.", "RequestCount", len(reqs), "Requests", reqs)
+		return reqs
+	}
+
+	versionPolicyToMirrorPeerMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		r.Logger.Debug("Mapping version policy ConfigMap to MirrorPeer", "ConfigMap", client.ObjectKeyFromObject(object))
+		var reqs []ctrl.Request
+		// The version policy applies to every MirrorPeer
 		var mpList multiclusterv1alpha1.MirrorPeerList
 		err := r.Client.List(ctx, &mpList)
 		if err != nil {
@@ -760,27 +1022,107 @@ func (r *MirrorPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		for _, mp := range mpList.Items {
 			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
 		}
-		r.Logger.Info("MirrorPeer reconcile requests generated based on ConfigMap change.", "RequestCount", len(reqs), "Requests", reqs)
+		r.Logger.Info("MirrorPeer reconcile requests generated based on version policy change.", "RequestCount", len(reqs), "Requests", reqs)
 		return reqs
 	}
 
+	versionPolicyPredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetName() == VersionPolicyConfigMapName && object.GetNamespace() == r.CurrentNamespace
+	})
+
+	drpolicyToMirrorPeerMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		dp, ok := object.(*ramenv1alpha1.DRPolicy)
+		if !ok {
+			r.Logger.Debug("Unable to cast object into a DRPolicy. Not requeing any requests.")
+			return reqs
+		}
+		if len(dp.Spec.DRClusters) == 0 {
+			return reqs
+		}
+		mirrorPeers, err := utils.ListMirrorPeersForCluster(ctx, r.Client, dp.Spec.DRClusters[0])
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of MirrorPeers. Not requeing any requests.", "error", err)
+			return reqs
+		}
+		for _, mp := range mirrorPeers {
+			// Only sync MirrorPeers validate the DRPolicies over their clusters
+			if mp.Spec.Type == multiclusterv1alpha1.Sync && isMirrorPeerForClusters(&mp, dp.Spec.DRClusters) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
+			}
+		}
+		return reqs
+	}
+
+	managedClusterToMirrorPeerMapFunc := func(ctx context.Context, object client.Object) []ctrl.Request {
+		var reqs []ctrl.Request
+		// The capabilities of StorageClients are those of the ManagedCluster of their provider
+		clientInfoMap, err := utils.FetchClientInfo(ctx, r.Client)
+		if err != nil {
+			r.Logger.Debug("Unable to fetch client info. Mapping ManagedCluster to MirrorPeers by cluster name only.", "error", err)
+		}
+		mirrorPeers, err := utils.ListMirrorPeersForClusters(ctx, r.Client, getServedClusters(object.GetName(), clientInfoMap))
+		if err != nil {
+			r.Logger.Debug("Unable to fetch list of MirrorPeers. Not requeing any requests.", "error", err)
+			return reqs
+		}
+		for _, mp := range mirrorPeers {
+			if slices.ContainsFunc(mp.Spec.Items, func(pr multiclusterv1alpha1.PeerRef) bool { return pr.ClusterName == object.GetName() }) ||
+				slices.Contains(getPeerProviderClusters(mp, clientInfoMap), object.GetName()) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
+			}
+		}
+		return reqs
+	}
+
+	// Only changes to the DRCluster CIDRs or region or to the ODF capabilities, storage IDs or agent version of a
+	// ManagedCluster are of interest
+	drClusterInfoChangedPredicate := predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldMC, ok := e.ObjectOld.(*clusterv1.ManagedCluster)
+			if !ok {
+				return false
+			}
+			newMC, ok := e.ObjectNew.(*clusterv1.ManagedCluster)
+			if !ok {
+				return false
+			}
+			oldCIDRs, _ := utils.GetDRClusterCIDRs(oldMC)
+			newCIDRs, _ := utils.GetDRClusterCIDRs(newMC)
+			return !slices.Equal(oldCIDRs, newCIDRs) ||
+				utils.GetDRClusterRegion(oldMC) != utils.GetDRClusterRegion(newMC) ||
+				odfCapabilitiesChanged(oldMC, newMC) ||
+				storageIDsChanged(oldMC, newMC) ||
+				agentVersionChanged(oldMC, newMC)
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&multiclusterv1alpha1.MirrorPeer{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		Owns(&addonapiv1alpha1.ManagedClusterAddOn{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(managedClusterToMirrorPeerMapFunc),
+			builder.WithPredicates(drClusterInfoChangedPredicate)).
+		Watches(&ramenv1alpha1.DRPolicy{}, handler.EnqueueRequestsFromMapFunc(drpolicyToMirrorPeerMapFunc),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&addonapiv1alpha1.ManagedClusterAddOn{}, builder.MatchEveryOwner, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
 			mca, ok := object.(*addonapiv1alpha1.ManagedClusterAddOn)
 			if !ok || mca.Name != setup.TokenExchangeName {
 				return false
 			}
 			return true
 		}))).
-		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(cmToMirrorPeerMapFunc),
-			builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
-				cm, ok := object.(*corev1.ConfigMap)
-				if !ok || cm.Name != utils.ClientInfoConfigMapName || cm.Namespace != r.CurrentNamespace {
-					return false
-				}
-				return true
-			}))).
+		Watches(&multiclusterv1alpha1.StorageInventory{}, handler.EnqueueRequestsFromMapFunc(storageInventoryToMirrorPeerMapFunc),
+			builder.WithPredicates(storageInventoryEntriesChangedPredicate())).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(versionPolicyToMirrorPeerMapFunc),
+			builder.WithPredicates(versionPolicyPredicate)).
 		Complete(r)
 }
 
@@ -801,14 +1143,14 @@ func checkK8sUpdateErrors(err error, obj client.Object, logger *slog.Logger) (ct
 }
 
 func GetNamespacedNameForClientS3Secret(ctx context.Context, client client.Client, currentNamespace string, pr multiclusterv1alpha1.PeerRef, mp *multiclusterv1alpha1.MirrorPeer) (string, string, error) {
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			return "", "", fmt.Errorf("client info ConfigMap not found; requeuing for later retry %w", err)
+			return "", "", fmt.Errorf("no StorageInventory found; requeuing for later retry %w", err)
 		}
 		return "", "", err
 	}
-	ci, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(pr.ClusterName, pr.StorageClusterRef.Name))
+	ci, err := utils.GetClientInfoForPeerRef(clientInfoMap, pr)
 	if err != nil {
 		return "", "", err
 	}
@@ -821,6 +1163,25 @@ func GetNamespacedNameForClientS3Secret(ctx context.Context, client client.Clien
 	return s3SecretName, s3SecretNamespace, nil
 }
 
+// getS3SecretNamespacedName returns the S3 secret synced to the hub for the peerRef. The S3 secret of a StorageClient
+// is synced by the agent on its provider, the S3 secret of a StorageCluster by the agent next to it.
+func getS3SecretNamespacedName(ctx context.Context, client client.Client, currentNamespace string, pr multiclusterv1alpha1.PeerRef, peerRefType utils.PeerRefType, mp *multiclusterv1alpha1.MirrorPeer) (types.NamespacedName, error) {
+	if peerRefType == utils.PeerRefTypeStorageClient {
+		name, namespace, err := GetNamespacedNameForClientS3Secret(ctx, client, currentNamespace, pr, mp)
+		if err != nil {
+			return types.NamespacedName{}, err
+		}
+		return types.NamespacedName{Name: name, Namespace: namespace}, nil
+	}
+	return types.NamespacedName{Name: utils.GetSecretNameByPeerRef(pr, utils.S3ProfilePrefix), Namespace: pr.ClusterName}, nil
+}
+
+// isStorageClusterPeering returns true when the StorageClusters of the MirrorPeer are peered through
+// StorageClusterPeers, which is the case of async MirrorPeers with a StorageClient peer
+func isStorageClusterPeering(mirrorPeer multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) bool {
+	return mirrorPeer.Spec.Type == multiclusterv1alpha1.Async && utils.HasStorageClientRef(peerRefTypes)
+}
+
 func (r *MirrorPeerReconciler) createDRClusters(ctx context.Context, name string, secret corev1.Secret, mirrorpeer multiclusterv1alpha1.MirrorPeer) error {
 	logger := r.Logger
 
@@ -831,24 +1192,45 @@ func (r *MirrorPeerReconciler) createDRClusters(ctx context.Context, name string
 		return err
 	}
 
+	var managedCluster clusterv1.ManagedCluster
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, &managedCluster); err != nil {
+		logger.Error("Failed to get ManagedCluster", "error", err, "ClusterName", name)
+		return err
+	}
+	// Invalid CIDRs are not set on the DRCluster, which keeps its previous CIDRs
+	cidrs, err := utils.GetDRClusterCIDRs(&managedCluster)
+	if err != nil {
+		logger.Error("Ignoring the CIDRs of the ManagedCluster", "error", err, "ClusterName", name, "Annotation", utils.DRClusterCIDRsAnnotationKey)
+	}
+	region := utils.GetDRClusterRegion(&managedCluster)
+
 	dc := &ramenv1alpha1.DRCluster{
 		ObjectMeta: metav1.ObjectMeta{Name: name},
 	}
 
-	logger.Info("Creating and updating DR clusters", "ClusterName", name)
+	logger.Info("Creating and updating DR clusters", "ClusterName", name, "CIDRs", cidrs, "Region", region)
 	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, dc, func() error {
 		dc.Spec.S3ProfileName = st.S3ProfileName
+		// CIDRs entered by hand are kept as long as neither the agent nor the user provide any
+		if len(cidrs) > 0 {
+			dc.Spec.CIDRs = cidrs
+		}
+		// Region is immutable on DRCluster and can only be set on creation
+		if dc.CreationTimestamp.IsZero() {
+			dc.Spec.Region = ramenv1alpha1.Region(region)
+		}
 		return controllerutil.SetControllerReference(&mirrorpeer, dc, r.Scheme)
 	})
 
 	return err
 }
 
-func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirrorPeer multiclusterv1alpha1.MirrorPeer, hasStorageClientRef bool) (ctrl.Result, error) {
+func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirrorPeer *multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) (ctrl.Result, error) {
 	logger := r.Logger
+	wasReady := isMirrorPeerReady(mirrorPeer)
 	if mirrorPeer.Spec.Type == multiclusterv1alpha1.Async {
-		if hasStorageClientRef {
-			providerModePeeringDone, err := isProviderModePeeringDone(ctx, r.Client, r.Logger, r.CurrentNamespace, &mirrorPeer)
+		if utils.HasStorageClientRef(peerRefTypes) {
+			providerModePeeringDone, err := isProviderModePeeringDone(ctx, r.Client, r.Logger, r.CurrentNamespace, mirrorPeer, peerRefTypes)
 			if err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to check if provider mode peering is correctly done %w", err)
 			}
@@ -857,15 +1239,18 @@ func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirro
 				logger.Info("Peering of clusters is completed", "MirrorPeer", mirrorPeer.Name)
 				mirrorPeer.Status.Phase = multiclusterv1alpha1.ExchangedSecret
 				mirrorPeer.Status.Message = ""
-				statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+				statusErr := r.Client.Status().Update(ctx, mirrorPeer)
 				if statusErr != nil {
 					logger.Error("Error occurred while updating the status of mirrorpeer", "error", statusErr, "MirrorPeer", mirrorPeer.Name)
 					return ctrl.Result{Requeue: true}, nil
 				}
+				if !wasReady {
+					observeMirrorPeerReady(mirrorPeer, time.Now())
+				}
 				return ctrl.Result{}, nil
 			} else {
 				mirrorPeer.Status.Phase = multiclusterv1alpha1.ExchangingSecret
-				statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+				statusErr := r.Client.Status().Update(ctx, mirrorPeer)
 				if statusErr != nil {
 					logger.Error("Error occurred while updating the status of mirrorpeer", "error", statusErr, "MirrorPeer", mirrorPeer.Name)
 					return ctrl.Result{Requeue: true}, nil
@@ -875,14 +1260,14 @@ func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirro
 		}
 	} else {
 		// Sync mode status update, same flow as async but for s3 profile
-		s3ProfileSynced, err := checkS3ProfileStatus(ctx, r.Client, logger, r.CurrentNamespace, mirrorPeer, hasStorageClientRef)
+		s3ProfileSynced, err := checkS3ProfileStatus(ctx, r.Client, logger, r.CurrentNamespace, *mirrorPeer, peerRefTypes)
 		if err != nil {
 			if k8serrors.IsNotFound(err) {
 				logger.Info("S3 secrets not found; Attempting to reconcile again", "MirrorPeer", mirrorPeer.Name)
 				return ctrl.Result{Requeue: true}, nil
 			}
 			logger.Error("Error while syncing S3 Profile", "error", err, "MirrorPeer", mirrorPeer.Name)
-			statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+			statusErr := r.Client.Status().Update(ctx, mirrorPeer)
 			if statusErr != nil {
 				logger.Error("Error occurred while updating the status of mirrorpeer", "error", statusErr, "MirrorPeer", mirrorPeer.Name)
 			}
@@ -893,11 +1278,14 @@ func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirro
 			logger.Info("S3 Profile synced to hub", "MirrorPeer", mirrorPeer.Name)
 			mirrorPeer.Status.Phase = multiclusterv1alpha1.S3ProfileSynced
 			mirrorPeer.Status.Message = ""
-			statusErr := r.Client.Status().Update(ctx, &mirrorPeer)
+			statusErr := r.Client.Status().Update(ctx, mirrorPeer)
 			if statusErr != nil {
 				logger.Error("Error occurred while updating the status of mirrorpeer", "error", statusErr, "MirrorPeer", mirrorPeer.Name)
 				return ctrl.Result{Requeue: true}, nil
 			}
+			if !wasReady {
+				observeMirrorPeerReady(mirrorPeer, time.Now())
+			}
 			return ctrl.Result{}, nil
 		}
 	}
@@ -905,8 +1293,8 @@ func (r *MirrorPeerReconciler) updateMirrorPeerStatus(ctx context.Context, mirro
 	return ctrl.Result{Requeue: true}, nil
 }
 
-func isProviderModePeeringDone(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer *multiclusterv1alpha1.MirrorPeer) (bool, error) {
-	isS3SecretSynced, err := checkS3ProfileStatus(ctx, client, logger, currentNamespace, *mirrorPeer, true)
+func isProviderModePeeringDone(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer *multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) (bool, error) {
+	isS3SecretSynced, err := checkS3ProfileStatus(ctx, client, logger, currentNamespace, *mirrorPeer, peerRefTypes)
 	if err != nil {
 		logger.Error("failed to check if s3 secrets have been synced")
 		return false, err
@@ -947,16 +1335,16 @@ func isProviderModePeeringDone(ctx context.Context, client client.Client, logger
 
 func checkOnboardingTicketStatus(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer *multiclusterv1alpha1.MirrorPeer) (bool, error) {
 	logger = logger.With("MirrorPeer", mirrorPeer.Name)
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			return false, fmt.Errorf("client info config map not found")
+			return false, fmt.Errorf("no StorageInventory found")
 		}
-		return false, fmt.Errorf("failed to fetch client info config map %w", err)
+		return false, fmt.Errorf("failed to fetch client info from StorageInventories %w", err)
 	}
 	for _, item := range mirrorPeer.Spec.Items {
 		logger.Info("Fetching info for client", "ClientKey", utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
-		ci, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
+		ci, err := utils.GetClientInfoForPeerRef(clientInfoMap, item)
 		if err != nil {
 			return false, fmt.Errorf("failed to fetch client info from the config map %w", err)
 		}
@@ -970,26 +1358,19 @@ func checkOnboardingTicketStatus(ctx context.Context, client client.Client, logg
 	return true, nil
 }
 
-func checkS3ProfileStatus(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mp multiclusterv1alpha1.MirrorPeer, hasStorageClientRef bool) (bool, error) {
+func checkS3ProfileStatus(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mp multiclusterv1alpha1.MirrorPeer, peerRefTypes []utils.PeerRefType) (bool, error) {
 	logger.Info("Checking S3 profile status for each peer reference in the MirrorPeer", "MirrorPeerName", mp.Name)
 
-	for _, pr := range mp.Spec.Items {
-		var s3SecretName string
-		var s3SecretNamespace string
-		if hasStorageClientRef {
-			name, namespace, err := GetNamespacedNameForClientS3Secret(ctx, client, currentNamespace, pr, &mp)
-			if err != nil {
-				return false, err
-			}
-			s3SecretName = name
-			s3SecretNamespace = namespace
-		} else {
-			s3SecretNamespace = pr.ClusterName
-			s3SecretName = utils.GetSecretNameByPeerRef(pr, utils.S3ProfilePrefix)
+	for i, pr := range mp.Spec.Items {
+		namespacedName, err := getS3SecretNamespacedName(ctx, client, currentNamespace, pr, peerRefTypes[i], &mp)
+		if err != nil {
+			return false, err
 		}
+		s3SecretName := namespacedName.Name
+		s3SecretNamespace := namespacedName.Namespace
 		logger.Info("Attempting to fetch S3 secret", "SecretName", s3SecretName, "Namespace", s3SecretNamespace)
 
-		_, err := utils.FetchSecretWithName(ctx, client, types.NamespacedName{Name: s3SecretName, Namespace: s3SecretNamespace})
+		_, err = utils.FetchSecretWithName(ctx, client, namespacedName)
 		if err != nil {
 			logger.Error("Failed to fetch S3 secret", "error", err, "SecretName", s3SecretName, "Namespace", s3SecretNamespace)
 			return false, err
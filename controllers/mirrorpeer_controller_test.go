@@ -27,8 +27,8 @@ import (
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/addons/setup"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
-	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
@@ -72,22 +72,145 @@ func TestMirrorPeerReconcilerReconcile(t *testing.T) {
 		},
 	}
 
-	_, err := r.Reconcile(ctx, req)
-	if err != nil {
-		t.Errorf("MirrorPeerReconciler Reconcile() failed. Error: %s", err)
+	// The first reconcile records the peerRef classification of the new MirrorPeer
+	for range 2 {
+		_, err := r.Reconcile(ctx, req)
+		if err != nil {
+			t.Errorf("MirrorPeerReconciler Reconcile() failed. Error: %s", err)
+		}
 	}
 
 	var mp multiclusterv1alpha1.MirrorPeer
-	err = r.Get(ctx, req.NamespacedName, &mp)
+	err := r.Get(ctx, req.NamespacedName, &mp)
 	if err != nil {
 		t.Errorf("Failed to get MirrorPeer. Error: %s", err)
 	}
 
+	if val := mp.Annotations[utils.PeerRefClassificationAnnotationKey]; val != utils.PeerRefClassificationClientID {
+		t.Errorf("MirrorPeer.Annotations[%s] is not set correctly. Expected: %s, Actual: %s", utils.PeerRefClassificationAnnotationKey, utils.PeerRefClassificationClientID, val)
+	}
 	if val, ok := mp.Labels[utils.HubRecoveryLabel]; !ok || val != "resource" {
 		t.Errorf("MirrorPeer.Labels[%s] is not set correctly. Expected: %s, Actual: %s", utils.HubRecoveryLabel, "resource", val)
 	}
 }
 
+func TestMirrorPeerReconcilePrunedPeerStorage(t *testing.T) {
+	mirrorpeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       "cluster1",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+				},
+				{
+					ClusterName:       "cluster2",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+				},
+			},
+		},
+	}
+	r := getFakeMirrorPeerReconciler(mirrorpeer)
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mirrorpeer"}}
+	for range 2 {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("MirrorPeerReconciler Reconcile() failed. Error: %s", err)
+		}
+	}
+
+	// The StorageCluster of cluster2 is pruned from its StorageInventory once ODF is uninstalled
+	var inventory multiclusterv1alpha1.StorageInventory
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: "cluster2"}, &inventory); err != nil {
+		t.Fatalf("Failed to get StorageInventory. Error: %s", err)
+	}
+	providers := inventory.Status.Providers
+	inventory.Status.Providers = nil
+	if err := r.Client.Update(ctx, &inventory); err != nil {
+		t.Fatalf("Failed to update StorageInventory. Error: %s", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Error("MirrorPeerReconciler Reconcile() succeeded although a peer was pruned from its StorageInventory")
+	}
+	var mp multiclusterv1alpha1.MirrorPeer
+	if err := r.Client.Get(ctx, req.NamespacedName, &mp); err != nil {
+		t.Fatalf("Failed to get MirrorPeer. Error: %s", err)
+	}
+	if mp.Status.Phase != multiclusterv1alpha1.PeerStorageNotFound {
+		t.Errorf("MirrorPeer.Status.Phase is not set correctly. Expected: %s, Actual: %s", multiclusterv1alpha1.PeerStorageNotFound, mp.Status.Phase)
+	}
+
+	// The MirrorPeer recovers once the StorageCluster is reported again
+	inventory.Status.Providers = providers
+	if err := r.Client.Update(ctx, &inventory); err != nil {
+		t.Fatalf("Failed to update StorageInventory. Error: %s", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("MirrorPeerReconciler Reconcile() failed. Error: %s", err)
+	}
+	if err := r.Client.Get(ctx, req.NamespacedName, &mp); err != nil {
+		t.Fatalf("Failed to get MirrorPeer. Error: %s", err)
+	}
+	if mp.Status.Phase == multiclusterv1alpha1.PeerStorageNotFound {
+		t.Errorf("MirrorPeer.Status.Phase is still %s after the StorageCluster was reported again", mp.Status.Phase)
+	}
+}
+
+func TestMirrorPeerDeletePrunedPeerStorage(t *testing.T) {
+	mirrorpeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			ManageS3: true,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       "cluster1",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+				},
+				{
+					ClusterName:       "cluster2",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+				},
+			},
+		},
+	}
+	r := getFakeMirrorPeerReconciler(mirrorpeer)
+	ctx := context.TODO()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mirrorpeer"}}
+	for range 2 {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("MirrorPeerReconciler Reconcile() failed. Error: %s", err)
+		}
+	}
+
+	// The StorageCluster of cluster2 is offboarded while cluster2 stays attached to the hub
+	var inventory multiclusterv1alpha1.StorageInventory
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: "cluster2"}, &inventory); err != nil {
+		t.Fatalf("Failed to get StorageInventory. Error: %s", err)
+	}
+	inventory.Status.Providers = nil
+	if err := r.Client.Update(ctx, &inventory); err != nil {
+		t.Fatalf("Failed to update StorageInventory. Error: %s", err)
+	}
+
+	var mp multiclusterv1alpha1.MirrorPeer
+	if err := r.Client.Get(ctx, req.NamespacedName, &mp); err != nil {
+		t.Fatalf("Failed to get MirrorPeer. Error: %s", err)
+	}
+	if err := r.Client.Delete(ctx, &mp); err != nil {
+		t.Fatalf("Failed to delete MirrorPeer. Error: %s", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("MirrorPeerReconciler Reconcile() failed for a MirrorPeer whose peer storage is gone. Error: %s", err)
+	}
+	if err := r.Client.Get(ctx, req.NamespacedName, &mp); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the MirrorPeer to be deleted once its finalizer is removed, got error %v and finalizers %v", err, mp.Finalizers)
+	}
+}
+
 func getFakeMirrorPeerReconciler(mirrorpeer multiclusterv1alpha1.MirrorPeer) MirrorPeerReconciler {
 	// Using the same scheme as manager to ensure consistency.
 	// Using a different scheme for test might cause issues like
@@ -108,30 +231,14 @@ func getFakeMirrorPeerReconciler(mirrorpeer multiclusterv1alpha1.MirrorPeer) Mir
 		Spec: clusterv1.ManagedClusterSpec{},
 	}
 
-	var odfClientInfoConfigMap = &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "odf-client-info",
-			Namespace: utils.GetEnv("POD_NAMESPACE"),
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion: viewv1beta1.GroupVersion.String(),
-					Kind:       "ManagedClusterView",
-					Name:       "mcv-1",
-					UID:        "mcv-uid",
-				},
-			},
-		},
-		Data: map[string]string{
-			"cluster1_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\"}}",
-			"cluster2_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\"}}",
-			"cluster3_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"cluster4_test-storagecluster": "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-		},
-	}
-
 	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
-		WithObjects(&mirrorpeer, &managedcluster1, &managedcluster2, odfClientInfoConfigMap).
+		WithObjects(&mirrorpeer, &managedcluster1, &managedcluster2,
+			getFakeStorageInventory("cluster1", ""),
+			getFakeStorageInventory("cluster2", ""),
+			getFakeStorageInventory("cluster3", utils.ExternalDeploymentType),
+			getFakeStorageInventory("cluster4", utils.ExternalDeploymentType)).
 		WithStatusSubresource(&mirrorpeer).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, utils.MirrorPeerPeerRefIndexKey, utils.MirrorPeerPeerRefIndexFunc).
 		Build()
 
 	r := MirrorPeerReconciler{
@@ -190,6 +297,106 @@ func TestProcessManagedClusterAddons(t *testing.T) {
 	}
 }
 
+func TestProcessManagedClusterAddonsSharedByMirrorPeers(t *testing.T) {
+	ctx := context.TODO()
+	newMirrorPeer := func(name string, drType multiclusterv1alpha1.DRType) multiclusterv1alpha1.MirrorPeer {
+		return multiclusterv1alpha1.MirrorPeer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: multiclusterv1alpha1.MirrorPeerSpec{
+				Type: drType,
+				Items: []multiclusterv1alpha1.PeerRef{
+					{
+						ClusterName:       "cluster3",
+						StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+					},
+					{
+						ClusterName:       "cluster4",
+						StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "test-storagecluster", Namespace: "test-namespace"},
+					},
+				},
+			},
+		}
+	}
+	asyncMirrorPeer := newMirrorPeer("mirrorpeer-async", multiclusterv1alpha1.Async)
+	syncMirrorPeer := newMirrorPeer("mirrorpeer-sync", multiclusterv1alpha1.Sync)
+	r := getFakeMirrorPeerReconciler(asyncMirrorPeer)
+	if err := r.Create(ctx, &syncMirrorPeer); err != nil {
+		t.Fatal(err)
+	}
+	// Annotations set by users on the addon are kept
+	hostedAddon := addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        setup.TokenExchangeName,
+			Namespace:   "cluster3",
+			Annotations: map[string]string{addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting"},
+		},
+	}
+	if err := r.Create(ctx, &hostedAddon); err != nil {
+		t.Fatal(err)
+	}
+
+	assertAddons := func(expectedMode string, expectedOwners int) {
+		t.Helper()
+		for _, clusterName := range []string{"cluster3", "cluster4"} {
+			var managedClusterAddon addonapiv1alpha1.ManagedClusterAddOn
+			if err := r.Get(ctx, types.NamespacedName{Name: setup.TokenExchangeName, Namespace: clusterName}, &managedClusterAddon); err != nil {
+				t.Fatal(err)
+			}
+			if mode := managedClusterAddon.Annotations[utils.DRModeAnnotationKey]; mode != expectedMode {
+				t.Errorf("expected mode %q on the ManagedClusterAddOn of %s, got %q", expectedMode, clusterName, mode)
+			}
+			owners := managedClusterAddon.GetOwnerReferences()
+			if len(owners) != expectedOwners {
+				t.Errorf("expected %d owners of the ManagedClusterAddOn of %s, got %v", expectedOwners, clusterName, owners)
+			}
+			for _, owner := range owners {
+				if owner.Controller != nil && *owner.Controller {
+					t.Errorf("expected MirrorPeer %q not to control the shared ManagedClusterAddOn", owner.Name)
+				}
+			}
+			if clusterName == "cluster3" && managedClusterAddon.Annotations[addonapiv1alpha1.HostingClusterNameAnnotationKey] != "hosting" {
+				t.Error("expected the hosting cluster annotation to be kept")
+			}
+			// The hosted agent runs in a namespace of its own on the hosting cluster
+			expectedInstallNamespace := "test-namespace"
+			if clusterName == "cluster3" {
+				expectedInstallNamespace = setup.HostingInstallNamespace("test-namespace", clusterName)
+				if namespace := managedClusterAddon.Annotations[setup.ManagedInstallNamespaceAnnotationKey]; namespace != "test-namespace" {
+					t.Errorf("expected the managed install namespace of %s to be test-namespace, got %q", clusterName, namespace)
+				}
+			}
+			if managedClusterAddon.Spec.InstallNamespace != expectedInstallNamespace {
+				t.Errorf("expected install namespace %q on the ManagedClusterAddOn of %s, got %q", expectedInstallNamespace, clusterName, managedClusterAddon.Spec.InstallNamespace)
+			}
+		}
+	}
+
+	if err := r.processManagedClusterAddon(ctx, asyncMirrorPeer); err != nil {
+		t.Fatalf("Failed to process ManagedClusterAddons. Error: %s", err)
+	}
+	assertAddons("async", 1)
+
+	if err := r.processManagedClusterAddon(ctx, syncMirrorPeer); err != nil {
+		t.Fatalf("Failed to process ManagedClusterAddons. Error: %s", err)
+	}
+	assertAddons("async,sync", 2)
+
+	// Reconciling a MirrorPeer again does not take the addon over
+	if err := r.processManagedClusterAddon(ctx, asyncMirrorPeer); err != nil {
+		t.Fatalf("Failed to process ManagedClusterAddons. Error: %s", err)
+	}
+	assertAddons("async,sync", 2)
+
+	// The modes of a deleted MirrorPeer are dropped once the remaining MirrorPeer is reconciled
+	if err := r.Delete(ctx, &syncMirrorPeer); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.processManagedClusterAddon(ctx, asyncMirrorPeer); err != nil {
+		t.Fatalf("Failed to process ManagedClusterAddons. Error: %s", err)
+	}
+	assertAddons("async", 2)
+}
+
 func TestDeleteResources(t *testing.T) {
 	ctx := context.TODO()
 
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CapabilitiesClusterClaimName is the ClusterClaim published by the agent with the comma separated ODF capabilities of
+// the spoke cluster
+const CapabilitiesClusterClaimName = "capabilities.odf.openshift.io"
+
+// Capability is a feature of the ODF version running on a spoke cluster which the hub relies on
+type Capability string
+
+const (
+	// CapabilityStorageClusterPeer is the support of StorageClusterPeers for peering StorageClients of two providers
+	CapabilityStorageClusterPeer Capability = "StorageClusterPeer"
+	// CapabilityVolumeGroupReplication is the support of VolumeGroupReplicationClasses for consistency groups
+	CapabilityVolumeGroupReplication Capability = "VolumeGroupReplication"
+	// CapabilityFlattenMode is the support of the flattenMode parameter of VolumeReplicationClasses
+	CapabilityFlattenMode Capability = "FlattenMode"
+	// CapabilityRadosNamespaceMirroring is the support of mirroring the rados namespaces of StorageClients
+	CapabilityRadosNamespaceMirroring Capability = "RadosNamespaceMirroring"
+)
+
+// GetODFCapabilities returns the capabilities advertised by the ManagedCluster. The second return value is false when
+// the agent of the ManagedCluster does not advertise capabilities yet.
+func GetODFCapabilities(mc *clusterv1.ManagedCluster) ([]Capability, bool) {
+	value, ok := GetClusterClaimValue(mc, CapabilitiesClusterClaimName)
+	if !ok {
+		return nil, false
+	}
+	var capabilities []Capability
+	for _, capability := range strings.Split(value, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			capabilities = append(capabilities, Capability(capability))
+		}
+	}
+	return capabilities, true
+}
+
+// GetMissingCapabilities returns the required capabilities which are not supported by the ManagedClusters, keyed by
+// the name of the ManagedCluster. ManagedClusters whose agent does not advertise capabilities are assumed to support
+// all of them, which is how the hub behaved before capabilities were advertised.
+func GetMissingCapabilities(ctx context.Context, c client.Client, clusterNames []string, required []Capability) (map[string][]Capability, error) {
+	missing := make(map[string][]Capability)
+	if len(required) == 0 {
+		return missing, nil
+	}
+	for _, clusterName := range clusterNames {
+		var mc clusterv1.ManagedCluster
+		if err := c.Get(ctx, types.NamespacedName{Name: clusterName}, &mc); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get ManagedCluster %q: %w", clusterName, err)
+		}
+		capabilities, advertised := GetODFCapabilities(&mc)
+		if !advertised {
+			continue
+		}
+		for _, capability := range required {
+			if !slices.Contains(capabilities, capability) && !slices.Contains(missing[clusterName], capability) {
+				missing[clusterName] = append(missing[clusterName], capability)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// FormatMissingCapabilities describes the missing capabilities returned by GetMissingCapabilities
+func FormatMissingCapabilities(missing map[string][]Capability) string {
+	var descriptions []string
+	for _, clusterName := range slices.Sorted(maps.Keys(missing)) {
+		var names []string
+		for _, capability := range missing[clusterName] {
+			names = append(names, string(capability))
+		}
+		descriptions = append(descriptions, fmt.Sprintf("ManagedCluster %q does not support %s", clusterName, strings.Join(names, ", ")))
+	}
+	return strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetMissingCapabilities(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clusterv1.AddToScheme(scheme))
+
+	managedCluster := func(name string, claims ...clusterv1.ManagedClusterClaim) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     clusterv1.ManagedClusterStatus{ClusterClaims: claims},
+		}
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		managedCluster("cluster1", clusterv1.ManagedClusterClaim{Name: CapabilitiesClusterClaimName, Value: "StorageClusterPeer, VolumeGroupReplication"}),
+		managedCluster("cluster2", clusterv1.ManagedClusterClaim{Name: CapabilitiesClusterClaimName, Value: ""}),
+		managedCluster("legacy"),
+	).Build()
+
+	required := []Capability{CapabilityStorageClusterPeer, CapabilityVolumeGroupReplication}
+	missing, err := GetMissingCapabilities(context.TODO(), fakeClient, []string{"cluster1", "cluster2", "legacy", "unknown"}, required)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]Capability{"cluster2": required}, missing)
+	assert.Equal(t, `ManagedCluster "cluster2" does not support StorageClusterPeer, VolumeGroupReplication`, FormatMissingCapabilities(missing))
+
+	missing, err = GetMissingCapabilities(context.TODO(), fakeClient, []string{"cluster2"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}
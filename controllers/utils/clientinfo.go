@@ -1,12 +1,15 @@
 package utils
 
 import (
-	"encoding/json"
-	"fmt"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// ExternalDeploymentType is the DeploymentType of StorageClusters consuming an external Ceph cluster
+const ExternalDeploymentType = "external"
+
 type ProviderInfo struct {
 	Version                       string               `json:"version"`
 	DeploymentType                string               `json:"deploymentType"`
@@ -15,6 +18,7 @@ type ProviderInfo struct {
 	StorageProviderEndpoint       string               `json:"storageProviderEndpoint"`
 	CephClusterFSID               string               `json:"cephClusterFSID"`
 	StorageProviderPublicEndpoint string               `json:"storageProviderPublicEndpoint"`
+	LastRefreshTime               *metav1.Time         `json:"lastRefreshTime,omitempty"`
 }
 
 type ClientInfo struct {
@@ -25,17 +29,11 @@ type ClientInfo struct {
 	ClientID                 string       `json:"clientId"`
 }
 
-// Helper function to extract and unmarshal ClientInfo from ConfigMap
-func GetClientInfoFromConfigMap(clientInfoMap map[string]string, key string) (ClientInfo, error) {
-	clientInfoJSON, ok := clientInfoMap[key]
-	if !ok {
-		return ClientInfo{}, fmt.Errorf("client info for %s not found in ConfigMap", key)
-	}
-
-	var clientInfo ClientInfo
-	if err := json.Unmarshal([]byte(clientInfoJSON), &clientInfo); err != nil {
-		return ClientInfo{}, fmt.Errorf("failed to unmarshal client info for %s: %v", key, err)
+// IsStale returns true when the provider data has not been refreshed within the threshold. A zero threshold disables
+// the check and providers without a refresh time, reported before refresh times were recorded, are never stale.
+func (p ProviderInfo) IsStale(threshold time.Duration, now time.Time) bool {
+	if threshold <= 0 || p.LastRefreshTime == nil {
+		return false
 	}
-
-	return clientInfo, nil
+	return now.Sub(p.LastRefreshTime.Time) > threshold
 }
@@ -14,9 +14,11 @@ import (
 const (
 	ODFInfoConfigMapName                = "odf-info"
 	ConfigMapResourceType               = "ConfigMap"
-	ClientInfoConfigMapName             = "odf-client-info"
 	StorageClientMappingConfigMapName   = "storage-client-mapping"
 	StorageClusterPeerNameAnnotationKey = "ocs.openshift.io/storage-cluster-peer"
+	// ODFInfoRefreshTimeAnnotationKey is stamped on the odf-info ConfigMaps by the token exchange agent with the time
+	// of their last refresh, in RFC 3339 format
+	ODFInfoRefreshTimeAnnotationKey = "multicluster.odf.openshift.io/odf-info-refresh-time"
 )
 
 // FetchConfigMap fetches a ConfigMap with a given name from a given namespace
@@ -40,10 +42,6 @@ func GetODFInfoConfigMap(ctx context.Context, c client.Client, namespace string)
 	return FetchConfigMap(ctx, c, ODFInfoConfigMapName, namespace)
 }
 
-func FetchClientInfoConfigMap(ctx context.Context, c client.Client, currentNamespace string) (*corev1.ConfigMap, error) {
-	return FetchConfigMap(ctx, c, ClientInfoConfigMapName, currentNamespace)
-}
-
 func GetStorageClientMapping(ctx context.Context, c client.Client, currentNamespace string) (*corev1.ConfigMap, error) {
 	return FetchConfigMap(ctx, c, StorageClientMappingConfigMapName, currentNamespace)
 }
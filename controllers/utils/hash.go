@@ -59,6 +59,11 @@ func GetKey(clusterName, clientName string) string {
 	return fmt.Sprintf("%s_%s", clusterName, clientName)
 }
 
+// GetNamespacedKey returns the key of a StorageCluster which is unique even when a ManagedCluster runs several of them
+func GetNamespacedKey(clusterName, namespace, name string) string {
+	return fmt.Sprintf("%s_%s_%s", clusterName, namespace, name)
+}
+
 func CalculateMD5Hash(value any) string {
 	data, err := json.Marshal(value)
 	if err != nil {
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ManagedClusterIDIndexKey indexes ManagedClusters by their clusterID label
+	ManagedClusterIDIndexKey = "metadata.labels.clusterID"
+	// MirrorPeerClusterNameIndexKey indexes MirrorPeers by the ManagedClusters of their peerRefs
+	MirrorPeerClusterNameIndexKey = "spec.items.clusterName"
+	// MirrorPeerPeerRefIndexKey indexes MirrorPeers by the unique name of their peerRefs, see GetSecretNameByPeerRef
+	MirrorPeerPeerRefIndexKey = "spec.items.peerRef"
+	// MirrorPeerFinalizerIndexKey indexes MirrorPeers by their finalizers
+	MirrorPeerFinalizerIndexKey = "metadata.finalizers"
+	// DRPolicyClusterNameIndexKey indexes DRPolicies by their DRClusters
+	DRPolicyClusterNameIndexKey = "spec.drClusters"
+)
+
+// ManagedClusterIDIndexFunc returns the clusterID of a ManagedCluster for the ManagedClusterIDIndexKey index
+func ManagedClusterIDIndexFunc(obj client.Object) []string {
+	clusterID := obj.GetLabels()[clusterIDLabelKey]
+	if clusterID == "" {
+		return nil
+	}
+	return []string{clusterID}
+}
+
+// MirrorPeerClusterNameIndexFunc returns the ManagedClusters of a MirrorPeer for the MirrorPeerClusterNameIndexKey index
+func MirrorPeerClusterNameIndexFunc(obj client.Object) []string {
+	mirrorPeer, ok := obj.(*multiclusterv1alpha1.MirrorPeer)
+	if !ok {
+		return nil
+	}
+	var clusterNames []string
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		clusterNames = append(clusterNames, peerRef.ClusterName)
+	}
+	return clusterNames
+}
+
+// MirrorPeerPeerRefIndexFunc returns the unique names of the peerRefs of a MirrorPeer for the MirrorPeerPeerRefIndexKey
+// index. The unique name of a peerRef also names the secrets exchanged for it.
+func MirrorPeerPeerRefIndexFunc(obj client.Object) []string {
+	mirrorPeer, ok := obj.(*multiclusterv1alpha1.MirrorPeer)
+	if !ok {
+		return nil
+	}
+	var peerRefNames []string
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		peerRefNames = append(peerRefNames, GetSecretNameByPeerRef(peerRef))
+	}
+	return peerRefNames
+}
+
+// MirrorPeerFinalizerIndexFunc returns the finalizers of a MirrorPeer for the MirrorPeerFinalizerIndexKey index
+func MirrorPeerFinalizerIndexFunc(obj client.Object) []string {
+	return obj.GetFinalizers()
+}
+
+// DRPolicyClusterNameIndexFunc returns the DRClusters of a DRPolicy for the DRPolicyClusterNameIndexKey index
+func DRPolicyClusterNameIndexFunc(obj client.Object) []string {
+	drPolicy, ok := obj.(*ramenv1alpha1.DRPolicy)
+	if !ok {
+		return nil
+	}
+	return drPolicy.Spec.DRClusters
+}
+
+// AddMirrorPeerIndexers registers the MirrorPeer field indexers with the cache
+func AddMirrorPeerIndexers(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &multiclusterv1alpha1.MirrorPeer{}, MirrorPeerClusterNameIndexKey, MirrorPeerClusterNameIndexFunc); err != nil {
+		return fmt.Errorf("failed to index MirrorPeers by %q: %w", MirrorPeerClusterNameIndexKey, err)
+	}
+	if err := indexer.IndexField(ctx, &multiclusterv1alpha1.MirrorPeer{}, MirrorPeerPeerRefIndexKey, MirrorPeerPeerRefIndexFunc); err != nil {
+		return fmt.Errorf("failed to index MirrorPeers by %q: %w", MirrorPeerPeerRefIndexKey, err)
+	}
+	return nil
+}
+
+// AddManagedClusterIndexers registers the ManagedCluster field indexers with the cache
+func AddManagedClusterIndexers(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &clusterv1.ManagedCluster{}, ManagedClusterIDIndexKey, ManagedClusterIDIndexFunc); err != nil {
+		return fmt.Errorf("failed to index ManagedClusters by %q: %w", ManagedClusterIDIndexKey, err)
+	}
+	return nil
+}
+
+// AddHubIndexers registers the field indexers used by the hub controllers with the cache
+func AddHubIndexers(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := AddManagedClusterIndexers(ctx, indexer); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &multiclusterv1alpha1.MirrorPeer{}, MirrorPeerFinalizerIndexKey, MirrorPeerFinalizerIndexFunc); err != nil {
+		return fmt.Errorf("failed to index MirrorPeers by %q: %w", MirrorPeerFinalizerIndexKey, err)
+	}
+	if err := indexer.IndexField(ctx, &ramenv1alpha1.DRPolicy{}, DRPolicyClusterNameIndexKey, DRPolicyClusterNameIndexFunc); err != nil {
+		return fmt.Errorf("failed to index DRPolicies by %q: %w", DRPolicyClusterNameIndexKey, err)
+	}
+	return AddMirrorPeerIndexers(ctx, indexer)
+}
+
+// ListMirrorPeersForCluster returns the MirrorPeers with a peerRef on the ManagedCluster
+func ListMirrorPeersForCluster(ctx context.Context, c client.Client, clusterName string) ([]multiclusterv1alpha1.MirrorPeer, error) {
+	var mirrorPeerList multiclusterv1alpha1.MirrorPeerList
+	if err := c.List(ctx, &mirrorPeerList, client.MatchingFields{MirrorPeerClusterNameIndexKey: clusterName}); err != nil {
+		return nil, fmt.Errorf("failed to list MirrorPeers of ManagedCluster %q: %w", clusterName, err)
+	}
+	return mirrorPeerList.Items, nil
+}
+
+// ListMirrorPeersForClusters returns the MirrorPeers with a peerRef on any of the ManagedClusters
+func ListMirrorPeersForClusters(ctx context.Context, c client.Client, clusterNames []string) ([]multiclusterv1alpha1.MirrorPeer, error) {
+	var mirrorPeers []multiclusterv1alpha1.MirrorPeer
+	for _, clusterName := range clusterNames {
+		clusterMirrorPeers, err := ListMirrorPeersForCluster(ctx, c, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		for _, mirrorPeer := range clusterMirrorPeers {
+			if !slices.ContainsFunc(mirrorPeers, func(mp multiclusterv1alpha1.MirrorPeer) bool { return mp.Name == mirrorPeer.Name }) {
+				mirrorPeers = append(mirrorPeers, mirrorPeer)
+			}
+		}
+	}
+	return mirrorPeers, nil
+}
+
+// ListDRPoliciesForClusters returns the DRPolicies with a DRCluster on any of the ManagedClusters
+func ListDRPoliciesForClusters(ctx context.Context, c client.Client, clusterNames []string) ([]ramenv1alpha1.DRPolicy, error) {
+	var drPolicies []ramenv1alpha1.DRPolicy
+	for _, clusterName := range clusterNames {
+		var drPolicyList ramenv1alpha1.DRPolicyList
+		if err := c.List(ctx, &drPolicyList, client.MatchingFields{DRPolicyClusterNameIndexKey: clusterName}); err != nil {
+			return nil, fmt.Errorf("failed to list DRPolicies of ManagedCluster %q: %w", clusterName, err)
+		}
+		for _, drPolicy := range drPolicyList.Items {
+			if !slices.ContainsFunc(drPolicies, func(dp ramenv1alpha1.DRPolicy) bool { return dp.Name == drPolicy.Name }) {
+				drPolicies = append(drPolicies, drPolicy)
+			}
+		}
+	}
+	return drPolicies, nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIndexedLookups(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, multiclusterv1alpha1.AddToScheme(scheme))
+	assert.NoError(t, clusterv1.AddToScheme(scheme))
+	assert.NoError(t, ramenv1alpha1.AddToScheme(scheme))
+
+	newMirrorPeer := func(name string, clusterNames ...string) *multiclusterv1alpha1.MirrorPeer {
+		mp := &multiclusterv1alpha1.MirrorPeer{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, clusterName := range clusterNames {
+			mp.Spec.Items = append(mp.Spec.Items, multiclusterv1alpha1.PeerRef{
+				ClusterName:       clusterName,
+				StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"},
+			})
+		}
+		return mp
+	}
+	mp1 := newMirrorPeer("mirrorpeer-1", "cluster1", "cluster2")
+	mp2 := newMirrorPeer("mirrorpeer-2", "cluster2", "cluster3")
+	mp2.Finalizers = []string{"test.finalizer"}
+	mc1 := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Labels: map[string]string{"clusterID": "id-1"}}}
+	mc2 := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster2"}}
+	dp1 := &ramenv1alpha1.DRPolicy{ObjectMeta: metav1.ObjectMeta{Name: "drpolicy-1"}, Spec: ramenv1alpha1.DRPolicySpec{DRClusters: []string{"cluster1", "cluster2"}}}
+	dp2 := &ramenv1alpha1.DRPolicy{ObjectMeta: metav1.ObjectMeta{Name: "drpolicy-2"}, Spec: ramenv1alpha1.DRPolicySpec{DRClusters: []string{"cluster3", "cluster4"}}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mp1, mp2, mc1, mc2, dp1, dp2).
+		WithIndex(&clusterv1.ManagedCluster{}, ManagedClusterIDIndexKey, ManagedClusterIDIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, MirrorPeerClusterNameIndexKey, MirrorPeerClusterNameIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, MirrorPeerPeerRefIndexKey, MirrorPeerPeerRefIndexFunc).
+		WithIndex(&multiclusterv1alpha1.MirrorPeer{}, MirrorPeerFinalizerIndexKey, MirrorPeerFinalizerIndexFunc).
+		WithIndex(&ramenv1alpha1.DRPolicy{}, DRPolicyClusterNameIndexKey, DRPolicyClusterNameIndexFunc).
+		Build()
+
+	assert.Nil(t, ManagedClusterIDIndexFunc(mc2))
+
+	mc, err := GetManagedClusterById(context.TODO(), c, "id-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster1", mc.Name)
+	_, err = GetManagedClusterById(context.TODO(), c, "id-2")
+	assert.Error(t, err)
+
+	mirrorPeers, err := ListMirrorPeersForCluster(context.TODO(), c, "cluster2")
+	assert.NoError(t, err)
+	assert.Len(t, mirrorPeers, 2)
+	mirrorPeers, err = ListMirrorPeersForCluster(context.TODO(), c, "cluster3")
+	assert.NoError(t, err)
+	if assert.Len(t, mirrorPeers, 1) {
+		assert.Equal(t, "mirrorpeer-2", mirrorPeers[0].Name)
+	}
+
+	mirrorPeers, err = ListMirrorPeersForClusters(context.TODO(), c, []string{"cluster1", "cluster2", "cluster4"})
+	assert.NoError(t, err)
+	assert.Len(t, mirrorPeers, 2)
+
+	drPolicies, err := ListDRPoliciesForClusters(context.TODO(), c, []string{"cluster1", "cluster2"})
+	assert.NoError(t, err)
+	if assert.Len(t, drPolicies, 1) {
+		assert.Equal(t, "drpolicy-1", drPolicies[0].Name)
+	}
+
+	var mirrorPeerList multiclusterv1alpha1.MirrorPeerList
+	assert.NoError(t, c.List(context.TODO(), &mirrorPeerList, client.MatchingFields{MirrorPeerFinalizerIndexKey: "test.finalizer"}))
+	if assert.Len(t, mirrorPeerList.Items, 1) {
+		assert.Equal(t, "mirrorpeer-2", mirrorPeerList.Items[0].Name)
+	}
+
+	assert.NoError(t, c.List(context.TODO(), &mirrorPeerList, client.MatchingFields{MirrorPeerPeerRefIndexKey: GetSecretNameByPeerRef(mp1.Spec.Items[0])}))
+	if assert.Len(t, mirrorPeerList.Items, 1) {
+		assert.Equal(t, "mirrorpeer-1", mirrorPeerList.Items[0].Name)
+	}
+
+	mp, err := GetMirrorPeerForClusterSet(context.TODO(), c, []string{"cluster3", "cluster2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mirrorpeer-2", mp.Name)
+}
@@ -3,9 +3,12 @@ package utils
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net"
+	"slices"
 	"strings"
 
-	"k8s.io/apimachinery/pkg/labels"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,54 +17,122 @@ import (
 const (
 	clusterIDLabelKey                 = "clusterID"
 	OdfInfoClusterClaimNamespacedName = "odfinfo.odf.openshift.io"
+
+	// CIDRsClusterClaimName is the ClusterClaim published by the agent with the comma separated CIDRs of the spoke cluster
+	CIDRsClusterClaimName = "cidrs.odf.openshift.io"
+	// ManagedClusterRegionLabelKey is the ManagedCluster label holding the region of the cluster
+	ManagedClusterRegionLabelKey = "region"
+	// DRClusterCIDRsAnnotationKey overrides the CIDRs published by the agent when set on a ManagedCluster
+	DRClusterCIDRsAnnotationKey = "multicluster.odf.openshift.io/drcluster-cidrs"
+	// DRClusterRegionAnnotationKey overrides the region label when set on a ManagedCluster
+	DRClusterRegionAnnotationKey = "multicluster.odf.openshift.io/drcluster-region"
 )
 
 // GetManagedClusterById fetches a ManagedCluster by its cluster ID label
 func GetManagedClusterById(ctx context.Context, c client.Client, clusterId string) (*clusterv1.ManagedCluster, error) {
 	managedClusterList := &clusterv1.ManagedClusterList{}
 
-	labelSelector := labels.SelectorFromSet(labels.Set{
-		clusterIDLabelKey: clusterId,
-	})
-
-	listOptions := &client.ListOptions{
-		LabelSelector: labelSelector,
-	}
-	err := c.List(ctx, managedClusterList, listOptions)
+	err := c.List(ctx, managedClusterList, client.MatchingFields{ManagedClusterIDIndexKey: clusterId})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list managed clusters: %v", err)
 	}
 
 	if len(managedClusterList.Items) == 0 {
-		return nil, fmt.Errorf("managed cluster with ID %s not found", clusterId)
+		return nil, k8serrors.NewNotFound(clusterv1.Resource("managedclusters"), clusterId)
 	}
 
 	// Return the first matching ManagedCluster (there should only be one)
 	return &managedClusterList.Items[0], nil
 }
 
+// GetNamespacedNameForClusterInfo returns the odf-info ConfigMap of the default odfinfo.odf.openshift.io ClusterClaim.
+// Clusters which only publish additional odf-info claims return the first of them.
 func GetNamespacedNameForClusterInfo(managedCluster clusterv1.ManagedCluster) (types.NamespacedName, error) {
-	clusterClaims := managedCluster.Status.ClusterClaims
-	for _, claim := range clusterClaims {
-		if claim.Name == OdfInfoClusterClaimNamespacedName {
-			namespacedName := strings.Split(claim.Value, "/")
-			if len(namespacedName) != 2 {
-				return types.NamespacedName{}, fmt.Errorf("invalid format for namespaced name claim: expected 'namespace/name', got '%s'", claim.Value)
-			}
-			return types.NamespacedName{Namespace: namespacedName[0], Name: namespacedName[1]}, nil
+	odfInfos, err := GetNamespacedNamesForClusterInfo(managedCluster)
+	if err != nil {
+		return types.NamespacedName{}, err
+	}
+	if namespacedName, ok := odfInfos[OdfInfoClusterClaimNamespacedName]; ok {
+		return namespacedName, nil
+	}
+	claimNames := slices.Sorted(maps.Keys(odfInfos))
+	return odfInfos[claimNames[0]], nil
+}
+
+// GetNamespacedNamesForClusterInfo returns the odf-info ConfigMaps published by the ManagedCluster keyed by the name of
+// their ClusterClaim. Besides the default odfinfo.odf.openshift.io claim, clusters running more than one StorageCluster
+// publish a <name>.odfinfo.odf.openshift.io claim for every additional odf-info ConfigMap.
+func GetNamespacedNamesForClusterInfo(managedCluster clusterv1.ManagedCluster) (map[string]types.NamespacedName, error) {
+	odfInfos := make(map[string]types.NamespacedName)
+	for _, claim := range managedCluster.Status.ClusterClaims {
+		if !IsODFInfoClusterClaim(claim.Name) {
+			continue
+		}
+		namespacedName := strings.Split(claim.Value, "/")
+		if len(namespacedName) != 2 {
+			return nil, fmt.Errorf("invalid format for namespaced name claim %q: expected 'namespace/name', got '%s'", claim.Name, claim.Value)
 		}
+		odfInfos[claim.Name] = types.NamespacedName{Namespace: namespacedName[0], Name: namespacedName[1]}
+	}
+
+	if len(odfInfos) == 0 {
+		return nil, fmt.Errorf("cannot find ClusterClaim %q in ManagedCluster status", OdfInfoClusterClaimNamespacedName)
 	}
+	return odfInfos, nil
+}
 
-	return types.NamespacedName{}, fmt.Errorf("cannot find ClusterClaim %q in ManagedCluster status", OdfInfoClusterClaimNamespacedName)
+// IsODFInfoClusterClaim returns true for the default and the additional odf-info ClusterClaims
+func IsODFInfoClusterClaim(claimName string) bool {
+	return claimName == OdfInfoClusterClaimNamespacedName || strings.HasSuffix(claimName, "."+OdfInfoClusterClaimNamespacedName)
 }
 
 func HasRequiredODFKey(mc *clusterv1.ManagedCluster) bool {
 	claims := mc.Status.ClusterClaims
 	for _, claim := range claims {
-		if claim.Name == OdfInfoClusterClaimNamespacedName {
+		if IsODFInfoClusterClaim(claim.Name) {
 			return true
 		}
 	}
 	return false
 
 }
+
+// GetClusterClaimValue returns the value of the named ClusterClaim from the ManagedCluster status
+func GetClusterClaimValue(mc *clusterv1.ManagedCluster, name string) (string, bool) {
+	for _, claim := range mc.Status.ClusterClaims {
+		if claim.Name == name {
+			return claim.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetDRClusterCIDRs returns the CIDRs to be set on the DRCluster of the ManagedCluster. The
+// DRClusterCIDRsAnnotationKey annotation takes precedence over the CIDRs published by the agent. An error is returned
+// instead of the CIDRs when one of them is invalid.
+func GetDRClusterCIDRs(mc *clusterv1.ManagedCluster) ([]string, error) {
+	value, ok := mc.Annotations[DRClusterCIDRsAnnotationKey]
+	if !ok {
+		value, _ = GetClusterClaimValue(mc, CIDRsClusterClaimName)
+	}
+	var cidrs []string
+	for _, cidr := range strings.Split(value, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("invalid CIDR of the DRCluster of ManagedCluster %q: %w", mc.Name, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// GetDRClusterRegion returns the region to be set on the DRCluster of the ManagedCluster. The
+// DRClusterRegionAnnotationKey annotation takes precedence over the region label.
+func GetDRClusterRegion(mc *clusterv1.ManagedCluster) string {
+	if region, ok := mc.Annotations[DRClusterRegionAnnotationKey]; ok {
+		return region
+	}
+	return mc.Labels[ManagedClusterRegionLabelKey]
+}
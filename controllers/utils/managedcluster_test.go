@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
@@ -64,6 +65,23 @@ func Test_GetNamespacedNameForClusterInfo(t *testing.T) {
 			want:    types.NamespacedName{},
 			wantErr: true,
 		},
+		{
+			name: "Only additional Namespaced Name Claim",
+			args: args{
+				managedCluster: clusterv1.ManagedCluster{
+					Status: clusterv1.ManagedClusterStatus{
+						ClusterClaims: []clusterv1.ManagedClusterClaim{
+							{
+								Name:  "external." + OdfInfoClusterClaimNamespacedName,
+								Value: "namespace/external",
+							},
+						},
+					},
+				},
+			},
+			want:    types.NamespacedName{Namespace: "namespace", Name: "external"},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -78,3 +96,104 @@ func Test_GetNamespacedNameForClusterInfo(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetDRClusterCIDRsAndRegion(t *testing.T) {
+	tests := []struct {
+		name       string
+		mc         clusterv1.ManagedCluster
+		wantCIDRs  []string
+		wantRegion string
+		wantErr    bool
+	}{
+		{
+			name:       "No CIDRs or region",
+			mc:         clusterv1.ManagedCluster{},
+			wantCIDRs:  nil,
+			wantRegion: "",
+		},
+		{
+			name: "CIDRs from ClusterClaim and region from label",
+			mc: clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{ManagedClusterRegionLabelKey: "east"},
+				},
+				Status: clusterv1.ManagedClusterStatus{
+					ClusterClaims: []clusterv1.ManagedClusterClaim{
+						{Name: CIDRsClusterClaimName, Value: "10.0.0.1/32,10.0.0.2/32"},
+					},
+				},
+			},
+			wantCIDRs:  []string{"10.0.0.1/32", "10.0.0.2/32"},
+			wantRegion: "east",
+		},
+		{
+			name: "Annotations override ClusterClaim and label",
+			mc: clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{ManagedClusterRegionLabelKey: "east"},
+					Annotations: map[string]string{
+						DRClusterCIDRsAnnotationKey:  "192.168.0.0/24, 192.168.1.0/24",
+						DRClusterRegionAnnotationKey: "west",
+					},
+				},
+				Status: clusterv1.ManagedClusterStatus{
+					ClusterClaims: []clusterv1.ManagedClusterClaim{
+						{Name: CIDRsClusterClaimName, Value: "10.0.0.1/32"},
+					},
+				},
+			},
+			wantCIDRs:  []string{"192.168.0.0/24", "192.168.1.0/24"},
+			wantRegion: "west",
+		},
+		{
+			name: "Invalid CIDR",
+			mc: clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{DRClusterCIDRsAnnotationKey: "192.168.0.0/24,192.168.1.0"},
+				},
+			},
+			wantCIDRs: nil,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetDRClusterCIDRs(&tt.mc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetDRClusterCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantCIDRs) {
+				t.Errorf("GetDRClusterCIDRs() = %v, want %v", got, tt.wantCIDRs)
+			}
+			if got := GetDRClusterRegion(&tt.mc); got != tt.wantRegion {
+				t.Errorf("GetDRClusterRegion() = %v, want %v", got, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func Test_GetNamespacedNamesForClusterInfo(t *testing.T) {
+	managedCluster := clusterv1.ManagedCluster{
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: OdfInfoClusterClaimNamespacedName, Value: "openshift-storage/odf-info"},
+				{Name: "external." + OdfInfoClusterClaimNamespacedName, Value: "openshift-storage-extended/odf-info"},
+				{Name: "id.k8s.io", Value: "cluster-id"},
+			},
+		},
+	}
+	want := map[string]types.NamespacedName{
+		OdfInfoClusterClaimNamespacedName:               {Namespace: "openshift-storage", Name: "odf-info"},
+		"external." + OdfInfoClusterClaimNamespacedName: {Namespace: "openshift-storage-extended", Name: "odf-info"},
+	}
+	got, err := GetNamespacedNamesForClusterInfo(managedCluster)
+	if err != nil {
+		t.Fatalf("GetNamespacedNamesForClusterInfo() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNamespacedNamesForClusterInfo() = %v, want %v", got, want)
+	}
+	if !HasRequiredODFKey(&clusterv1.ManagedCluster{Status: clusterv1.ManagedClusterStatus{ClusterClaims: managedCluster.Status.ClusterClaims[1:]}}) {
+		t.Errorf("HasRequiredODFKey() = false for an additional odf-info ClusterClaim")
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,14 +14,32 @@ import (
 const MCVLabelKey = "multicluster.odf.openshift.io/cluster"
 const MCVNameTemplate = "odf-multicluster-mcv-%s"
 
+// MCVCreatedByValue is the CreatedByLabelKey value of the ManagedClusterViews created by the orchestrator
+const MCVCreatedByValue = "odf-multicluster-managedcluster-controller"
+
 func GetManagedClusterViewName(clusterName string) string {
 	return fmt.Sprintf(MCVNameTemplate, clusterName)
 }
 
-func CreateOrUpdateManagedClusterView(ctx context.Context, client ctrlClient.Client, resourceToFindName string, resourceToFindNamespace string, resourceToFindType string, clusterName string, ownerRef *metav1.OwnerReference) (*viewv1beta1.ManagedClusterView, controllerutil.OperationResult, error) {
+// GetODFInfoManagedClusterViewName returns the name of the ManagedClusterView of an odf-info ClusterClaim. The default
+// claim keeps the name used before ManagedClusters could publish several odf-info ConfigMaps.
+func GetODFInfoManagedClusterViewName(clusterName, claimName string) string {
+	if claimName == OdfInfoClusterClaimNamespacedName {
+		return GetManagedClusterViewName(clusterName)
+	}
+	return fmt.Sprintf("%s.%s", GetManagedClusterViewName(clusterName), strings.TrimSuffix(claimName, "."+OdfInfoClusterClaimNamespacedName))
+}
+
+// GetStorageInventoryName returns the name of the StorageInventory populated from the ManagedClusterView. The
+// StorageInventory of the default odf-info ClusterClaim is named after the ManagedCluster.
+func GetStorageInventoryName(managedClusterView viewv1beta1.ManagedClusterView) string {
+	return strings.TrimPrefix(managedClusterView.Name, GetManagedClusterViewName(""))
+}
+
+func CreateOrUpdateManagedClusterView(ctx context.Context, client ctrlClient.Client, mcvName string, resourceToFindName string, resourceToFindNamespace string, resourceToFindType string, clusterName string, ownerRef *metav1.OwnerReference) (*viewv1beta1.ManagedClusterView, controllerutil.OperationResult, error) {
 	mcv := &viewv1beta1.ManagedClusterView{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      GetManagedClusterViewName(clusterName),
+			Name:      mcvName,
 			Namespace: clusterName,
 		},
 	}
@@ -38,7 +57,7 @@ func CreateOrUpdateManagedClusterView(ctx context.Context, client ctrlClient.Cli
 			mcv.Labels = make(map[string]string)
 		}
 
-		mcv.Labels[CreatedByLabelKey] = "odf-multicluster-managedcluster-controller"
+		mcv.Labels[CreatedByLabelKey] = MCVCreatedByValue
 
 		if ownerRef != nil {
 			mcv.OwnerReferences = []metav1.OwnerReference{*ownerRef}
@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	ocsv1alpha1 "github.com/red-hat-storage/ocs-operator/api/v4/v1alpha1"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
@@ -27,15 +28,37 @@ const (
 	PeerRefTypeUnknown PeerRefType = "Unknown"
 )
 
+const (
+	// PeerRefClassificationAnnotationKey records on a MirrorPeer how the types of its peerRefs are determined, so that
+	// MirrorPeers created before StorageClients and StorageClusters could be mixed keep the types they were set up with
+	PeerRefClassificationAnnotationKey = "multicluster.odf.openshift.io/peer-ref-classification"
+	// PeerRefClassificationClientID classifies each peerRef on its own, peerRefs reported with a client ID are
+	// StorageClients
+	PeerRefClassificationClientID = "ClientID"
+	// PeerRefClassificationDeploymentType classifies all peerRefs as StorageClients when none of them consumes an
+	// external Ceph cluster, and as StorageClusters otherwise
+	PeerRefClassificationDeploymentType = "DeploymentType"
+)
+
+// StorageClusterPeeringAnnotationKey is set by the hub on MirrorPeers whose StorageClusters are peered through
+// StorageClusterPeers. It tells the agents of StorageCluster peers to generate onboarding tokens as well.
+const StorageClusterPeeringAnnotationKey = "multicluster.odf.openshift.io/storagecluster-peering"
+
+// ProviderClustersAnnotationKey is set by the hub on MirrorPeers with StorageClient peers to the comma separated
+// ManagedClusters of their providers which are not peers themselves. The agents of these clusters manage the
+// MirrorPeer for their StorageClients, the admission policy of the agents admits their finalizer updates.
+const ProviderClustersAnnotationKey = "multicluster.odf.openshift.io/provider-clusters"
+
 // DoesAnotherMirrorPeerPointToPeerRef checks if another mirrorpeer is pointing to the provided peer ref
 func DoesAnotherMirrorPeerPointToPeerRef(ctx context.Context, rc client.Client, peerRef *multiclusterv1alpha1.PeerRef) (bool, error) {
-	mirrorPeers, err := FetchAllMirrorPeers(ctx, rc)
+	var mirrorPeerList multiclusterv1alpha1.MirrorPeerList
+	err := rc.List(ctx, &mirrorPeerList, client.MatchingFields{MirrorPeerPeerRefIndexKey: GetSecretNameByPeerRef(*peerRef)})
 	if err != nil {
 		return false, err
 	}
 	count := 0
-	for i := range mirrorPeers {
-		if ContainsPeerRef(mirrorPeers[i].Spec.Items, peerRef) {
+	for i := range mirrorPeerList.Items {
+		if ContainsPeerRef(mirrorPeerList.Items[i].Spec.Items, peerRef) {
 			count++
 		}
 	}
@@ -92,7 +115,9 @@ func GetClusterID(ctx context.Context, client client.Client, clusterName string)
 	return managedCluster.GetLabels()["clusterID"], nil
 }
 
-func getPeerRefType(ctx context.Context, c client.Client, peerRef multiclusterv1alpha1.PeerRef, isManagedCluster bool) (PeerRefType, error) {
+// GetPeerRefType returns whether the peerRef is a StorageClient or a StorageCluster. On a ManagedCluster the peerRef is
+// looked up in the odf-info ConfigMap, on the hub in the StorageInventories.
+func GetPeerRefType(ctx context.Context, c client.Client, peerRef multiclusterv1alpha1.PeerRef, isManagedCluster bool) (PeerRefType, error) {
 	if isManagedCluster {
 		operatorNamespace := GetEnv("POD_NAMESPACE")
 		cm, err := GetODFInfoConfigMap(ctx, c, operatorNamespace)
@@ -116,49 +141,90 @@ func getPeerRefType(ctx context.Context, c client.Client, peerRef multiclusterv1
 		}
 		return PeerRefTypeStorageCluster, nil
 	} else {
-		operatorNamespace := GetEnv("POD_NAMESPACE")
-		cm, err := FetchClientInfoConfigMap(ctx, c, operatorNamespace)
+		clientInfoMap, err := FetchClientInfo(ctx, c)
 		if err != nil {
 			return PeerRefTypeUnknown, err
 		}
-		cInfo, err := GetClientInfoFromConfigMap(cm.Data, GetKey(peerRef.ClusterName, peerRef.StorageClusterRef.Name))
-		if err != nil {
-			return PeerRefTypeUnknown, err
+		return GetPeerRefTypeFromClientInfo(clientInfoMap, peerRef)
+	}
+}
+
+// GetPeerRefTypeFromClientInfo returns whether the peerRef is a StorageClient or a StorageCluster according to the
+// client info of the StorageInventories
+func GetPeerRefTypeFromClientInfo(clientInfoMap map[string]ClientInfo, peerRef multiclusterv1alpha1.PeerRef) (PeerRefType, error) {
+	cInfo, err := GetClientInfoForPeerRef(clientInfoMap, peerRef)
+	if err != nil {
+		return PeerRefTypeUnknown, err
+	}
+	// StorageClusters are reported without a client, like on the ManagedCluster where they are missing from the clients
+	// of the odf-info ConfigMap
+	if cInfo.ClientID != "" {
+		return PeerRefTypeStorageClient, nil
+	}
+	return PeerRefTypeStorageCluster, nil
+}
+
+// GetPeerRefClassification returns how the types of the peerRefs of the MirrorPeer are determined. MirrorPeers
+// without the classification annotation which have already been reconciled predate it and keep the classification by
+// deployment type.
+func GetPeerRefClassification(mirrorPeer multiclusterv1alpha1.MirrorPeer) string {
+	if classification, ok := mirrorPeer.Annotations[PeerRefClassificationAnnotationKey]; ok {
+		return classification
+	}
+	if mirrorPeer.Status.Phase != "" {
+		return PeerRefClassificationDeploymentType
+	}
+	return PeerRefClassificationClientID
+}
+
+// GetPeerRefTypes returns the type of every peerRef of the MirrorPeer, in the order of MirrorPeer.Spec.Items. The
+// peerRefs of a MirrorPeer may be of different types, unless it is classified by deployment type.
+func GetPeerRefTypes(clientInfoMap map[string]ClientInfo, mirrorPeer multiclusterv1alpha1.MirrorPeer) ([]PeerRefType, error) {
+	peerRefTypes := make([]PeerRefType, 0, len(mirrorPeer.Spec.Items))
+	if GetPeerRefClassification(mirrorPeer) == PeerRefClassificationDeploymentType {
+		peerRefType := PeerRefTypeStorageClient
+		for _, peerRef := range mirrorPeer.Spec.Items {
+			cInfo, err := GetClientInfoForPeerRef(clientInfoMap, peerRef)
+			if err != nil {
+				return nil, err
+			}
+			if cInfo.ProviderInfo.DeploymentType == ExternalDeploymentType {
+				peerRefType = PeerRefTypeStorageCluster
+			}
 		}
-		if cInfo.ProviderInfo.DeploymentType != "external" {
-			return PeerRefTypeStorageClient, nil
+		for range mirrorPeer.Spec.Items {
+			peerRefTypes = append(peerRefTypes, peerRefType)
 		}
-		return PeerRefTypeStorageCluster, nil
+		return peerRefTypes, nil
 	}
-}
 
-// IsStorageClientType checks if peerRefs on MirrorPeer is of type StorageClient or StorageCluster
-func IsStorageClientType(ctx context.Context, c client.Client, mirrorPeer multiclusterv1alpha1.MirrorPeer, isManagedCluster bool) (bool, error) {
-	for _, v := range mirrorPeer.Spec.Items {
-		peerRefType, err := getPeerRefType(ctx, c, v, isManagedCluster)
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		peerRefType, err := GetPeerRefTypeFromClientInfo(clientInfoMap, peerRef)
 		if err != nil {
-			return false, err
-		}
-		if peerRefType != PeerRefTypeStorageClient {
-			return false, nil
+			return nil, err
 		}
+		peerRefTypes = append(peerRefTypes, peerRefType)
 	}
-	return true, nil
+	return peerRefTypes, nil
+}
+
+// HasStorageClientRef returns true when any peerRef of the MirrorPeer is a StorageClient
+func HasStorageClientRef(peerRefTypes []PeerRefType) bool {
+	return slices.Contains(peerRefTypes, PeerRefTypeStorageClient)
 }
 
 func GetMirrorPeerForClusterSet(ctx context.Context, client client.Client, clusterSet []string) (*multiclusterv1alpha1.MirrorPeer, error) {
 
-	var mpList multiclusterv1alpha1.MirrorPeerList
-	err := client.List(ctx, &mpList)
+	mirrorPeers, err := ListMirrorPeersForCluster(ctx, client, clusterSet[0])
 	if err != nil {
 		return nil, err
 	}
 
-	if len(mpList.Items) == 0 {
+	if len(mirrorPeers) == 0 {
 		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: multiclusterv1alpha1.GroupVersion.Group, Resource: "MirrorPeer"}, "MirrorPeerList")
 	}
 
-	for _, mp := range mpList.Items {
+	for _, mp := range mirrorPeers {
 		if (mp.Spec.Items[0].ClusterName == clusterSet[0] && mp.Spec.Items[1].ClusterName == clusterSet[1]) ||
 			(mp.Spec.Items[1].ClusterName == clusterSet[0] && mp.Spec.Items[0].ClusterName == clusterSet[1]) {
 			return &mp, nil
@@ -106,3 +106,95 @@ storageCluster:
 	err = os.Unsetenv("POD_NAMESPACE")
 	assert.NoError(t, err)
 }
+
+func TestGetPeerRefTypes(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{
+				{ClusterName: "cluster1", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster"}},
+				{ClusterName: "cluster2", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "storage-client"}},
+			},
+		},
+	}
+	clientInfoMap := map[string]ClientInfo{
+		GetKey("cluster1", "ocs-storagecluster"): {ProviderInfo: ProviderInfo{DeploymentType: "internal"}},
+		GetKey("cluster2", "storage-client"):     {ClientID: "client-id", ProviderInfo: ProviderInfo{DeploymentType: "internal"}},
+	}
+
+	peerRefTypes, err := GetPeerRefTypes(clientInfoMap, mirrorPeer)
+	assert.NoError(t, err)
+	assert.Equal(t, []PeerRefType{PeerRefTypeStorageCluster, PeerRefTypeStorageClient}, peerRefTypes)
+	assert.True(t, HasStorageClientRef(peerRefTypes))
+	assert.False(t, HasStorageClientRef(peerRefTypes[:1]))
+
+	delete(clientInfoMap, GetKey("cluster2", "storage-client"))
+	_, err = GetPeerRefTypes(clientInfoMap, mirrorPeer)
+	assert.Error(t, err)
+}
+
+func TestGetPeerRefTypesByDeploymentType(t *testing.T) {
+	peerRefs := []multiclusterv1alpha1.PeerRef{
+		{ClusterName: "cluster1", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster"}},
+		{ClusterName: "cluster2", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "storage-client"}},
+	}
+	// Client info reported by the StorageInventories of StorageClusters, without client ID, and of StorageClients
+	internalClientInfoMap := map[string]ClientInfo{
+		GetKey("cluster1", "ocs-storagecluster"): {ProviderInfo: ProviderInfo{DeploymentType: "internal"}},
+		GetKey("cluster2", "storage-client"):     {ClientID: "client-id", ProviderInfo: ProviderInfo{DeploymentType: "internal"}},
+	}
+	externalClientInfoMap := map[string]ClientInfo{
+		GetKey("cluster1", "ocs-storagecluster"): {ProviderInfo: ProviderInfo{DeploymentType: ExternalDeploymentType}},
+		GetKey("cluster2", "storage-client"):     {ClientID: "client-id", ProviderInfo: ProviderInfo{DeploymentType: "internal"}},
+	}
+
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		phase          multiclusterv1alpha1.PhaseType
+		clientInfoMap  map[string]ClientInfo
+		classification string
+		want           []PeerRefType
+	}{
+		{
+			name:           "new MirrorPeer is classified by client ID",
+			clientInfoMap:  internalClientInfoMap,
+			classification: PeerRefClassificationClientID,
+			want:           []PeerRefType{PeerRefTypeStorageCluster, PeerRefTypeStorageClient},
+		},
+		{
+			name:           "MirrorPeer reconciled before the classification keeps the classification by deployment type",
+			phase:          multiclusterv1alpha1.ExchangedSecret,
+			clientInfoMap:  internalClientInfoMap,
+			classification: PeerRefClassificationDeploymentType,
+			want:           []PeerRefType{PeerRefTypeStorageClient, PeerRefTypeStorageClient},
+		},
+		{
+			name:           "MirrorPeer classified by deployment type with an external peer",
+			annotations:    map[string]string{PeerRefClassificationAnnotationKey: PeerRefClassificationDeploymentType},
+			clientInfoMap:  externalClientInfoMap,
+			classification: PeerRefClassificationDeploymentType,
+			want:           []PeerRefType{PeerRefTypeStorageCluster, PeerRefTypeStorageCluster},
+		},
+		{
+			name:           "recorded classification by client ID",
+			annotations:    map[string]string{PeerRefClassificationAnnotationKey: PeerRefClassificationClientID},
+			phase:          multiclusterv1alpha1.ExchangedSecret,
+			clientInfoMap:  externalClientInfoMap,
+			classification: PeerRefClassificationClientID,
+			want:           []PeerRefType{PeerRefTypeStorageCluster, PeerRefTypeStorageClient},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       multiclusterv1alpha1.MirrorPeerSpec{Items: peerRefs},
+				Status:     multiclusterv1alpha1.MirrorPeerStatus{Phase: tt.phase},
+			}
+			assert.Equal(t, tt.classification, GetPeerRefClassification(mirrorPeer))
+			peerRefTypes, err := GetPeerRefTypes(tt.clientInfoMap, mirrorPeer)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, peerRefTypes)
+		})
+	}
+}
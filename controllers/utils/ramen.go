@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -17,6 +18,9 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// ErrRamenConfigUpdate is wrapped by the errors of the updates of the S3 profiles in the Ramen hub operator config
+var ErrRamenConfigUpdate = errors.New("failed to update Ramen hub operator config")
+
 func createOrUpdateRamenS3Secret(ctx context.Context, rc client.Client, scheme *runtime.Scheme, name string, data map[string][]byte, ramenHubNamespace string, mirrorPeer multiclusterv1alpha1.MirrorPeer, logger *slog.Logger) error {
 
 	secret := corev1.Secret{
@@ -193,7 +197,7 @@ func CreateOrUpdateSecretsFromInternalSecret(ctx context.Context, rc client.Clie
 		}
 		if err := updateRamenHubOperatorConfig(ctx, rc, secret, data, mirrorPeer, currentNamespace, logger); err != nil {
 			logger.Error("Failed to update Ramen Hub Operator config", "error", err, "SecretName", secret.Name, "Namespace", currentNamespace)
-			return err
+			return fmt.Errorf("%w: %w", ErrRamenConfigUpdate, err)
 		}
 	}
 
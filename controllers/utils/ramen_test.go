@@ -299,3 +299,13 @@ func TestCreateOrUpdateSecretsFromInternalSecret(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateOrUpdateSecretsFromInternalSecretWithoutRamenConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	assert.NoError(t, multiclusterv1alpha1.AddToScheme(scheme))
+	fakeClient := getFakeClient(t, scheme)
+
+	err := CreateOrUpdateSecretsFromInternalSecret(context.TODO(), fakeClient, scheme, "namespace-without-ramen", fakeS3InternalSecret(t, TestSourceManagedClusterEast), fakeMirrorPeers(true), GetLogger(GetZapLogger(true)))
+	assert.ErrorIs(t, err, ErrRamenConfigUpdate)
+}
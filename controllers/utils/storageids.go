@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// StorageIDsClusterClaimName is the ClusterClaim published by the agent with the storage IDs of the default
+// StorageClasses of the StorageClusters of the spoke cluster. The value holds comma separated
+// <namespace>/<name>/<type>=<storageID> entries.
+const StorageIDsClusterClaimName = "storageids.odf.openshift.io"
+
+// FormatStorageIDs returns the sorted ClusterClaim value of the storage IDs of the StorageClusters
+func FormatStorageIDs(storageIDs map[types.NamespacedName]map[CephType]string) string {
+	var entries []string
+	for storageCluster, ids := range storageIDs {
+		for cephType, id := range ids {
+			entries = append(entries, fmt.Sprintf("%s/%s=%s", storageCluster.String(), cephType, id))
+		}
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ",")
+}
+
+// GetStorageIDs returns the storage IDs published by the agent of the ManagedCluster for the StorageCluster. The
+// second return value is false when the agent of the ManagedCluster does not publish storage IDs yet.
+func GetStorageIDs(mc *clusterv1.ManagedCluster, storageCluster types.NamespacedName) (map[CephType]string, bool) {
+	value, ok := GetClusterClaimValue(mc, StorageIDsClusterClaimName)
+	if !ok {
+		return nil, false
+	}
+	prefix := storageCluster.String() + "/"
+	storageIDs := make(map[CephType]string)
+	for _, entry := range strings.Split(value, ",") {
+		key, id, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			continue
+		}
+		if cephType, ok := strings.CutPrefix(key, prefix); ok {
+			storageIDs[CephType(cephType)] = id
+		}
+	}
+	return storageIDs, true
+}
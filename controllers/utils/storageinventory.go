@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FetchClientInfo returns the client info of all StorageInventories keyed by GetKey(clusterName, name).
+// A NotFound error is returned when no StorageInventory has been created yet.
+func FetchClientInfo(ctx context.Context, c client.Client) (map[string]ClientInfo, error) {
+	var inventoryList multiclusterv1alpha1.StorageInventoryList
+	if err := c.List(ctx, &inventoryList); err != nil {
+		return nil, fmt.Errorf("failed to list StorageInventories: %w", err)
+	}
+	if len(inventoryList.Items) == 0 {
+		return nil, k8serrors.NewNotFound(multiclusterv1alpha1.GroupVersion.WithResource("storageinventories").GroupResource(), "")
+	}
+	return GetClientInfoFromStorageInventories(inventoryList.Items), nil
+}
+
+// GetClientInfoFromStorageInventories flattens the StorageInventories into client info keyed by GetKey(clusterName, name).
+// StorageClients are keyed by the ManagedCluster they run on, StorageClusters without any StorageClient are keyed by
+// the ManagedCluster they run on. As a ManagedCluster may run several StorageClusters, StorageClusters are also keyed
+// by GetNamespacedKey and the plain key is only kept when it identifies a single StorageCluster of the ManagedCluster.
+func GetClientInfoFromStorageInventories(inventories []multiclusterv1alpha1.StorageInventory) map[string]ClientInfo {
+	clientInfoMap := make(map[string]ClientInfo)
+	providerKeyCount := make(map[string]int)
+	for _, inventory := range inventories {
+		for _, provider := range inventory.Status.Providers {
+			providerKeyCount[GetKey(inventory.Spec.ClusterName, provider.Name)]++
+		}
+	}
+
+	var providerInfos []ClientInfo
+	for _, inventory := range inventories {
+		for _, provider := range inventory.Status.Providers {
+			providerInfo := ProviderInfo{
+				Version:                       provider.Version,
+				DeploymentType:                provider.DeploymentType,
+				ProviderManagedClusterName:    inventory.Spec.ClusterName,
+				NamespacedName:                types.NamespacedName{Namespace: provider.Namespace, Name: provider.Name},
+				StorageProviderEndpoint:       provider.StorageProviderEndpoint,
+				CephClusterFSID:               provider.CephClusterFSID,
+				StorageProviderPublicEndpoint: provider.StorageProviderPublicEndpoint,
+				LastRefreshTime:               provider.LastRefreshTime,
+			}
+
+			hasClients := false
+			for _, storageClient := range inventory.Status.Clients {
+				if storageClient.ProviderName != provider.Name {
+					continue
+				}
+				hasClients = true
+				clientInfoMap[GetKey(storageClient.ManagedClusterName, storageClient.Name)] = ClientInfo{
+					ClusterID:                storageClient.ClusterID,
+					Name:                     storageClient.Name,
+					ProviderInfo:             providerInfo,
+					ClientManagedClusterName: storageClient.ManagedClusterName,
+					ClientID:                 storageClient.ClientID,
+				}
+			}
+			if !hasClients {
+				providerInfos = append(providerInfos, ClientInfo{ProviderInfo: providerInfo})
+			}
+		}
+	}
+
+	// StorageClients take precedence over StorageClusters with the same plain key
+	for _, providerInfo := range providerInfos {
+		clusterName := providerInfo.ProviderInfo.ProviderManagedClusterName
+		namespacedName := providerInfo.ProviderInfo.NamespacedName
+		clientInfoMap[GetNamespacedKey(clusterName, namespacedName.Namespace, namespacedName.Name)] = providerInfo
+		key := GetKey(clusterName, namespacedName.Name)
+		if _, ok := clientInfoMap[key]; !ok && providerKeyCount[key] == 1 {
+			clientInfoMap[key] = providerInfo
+		}
+	}
+	return clientInfoMap
+}
+
+// GetClientInfoForPeerRef returns the client info of the StorageCluster or StorageClient referenced by the peerRef.
+// Namespaced StorageCluster references are looked up by their namespaced key first.
+func GetClientInfoForPeerRef(clientInfoMap map[string]ClientInfo, peerRef multiclusterv1alpha1.PeerRef) (ClientInfo, error) {
+	if peerRef.StorageClusterRef.Namespace != "" {
+		if clientInfo, ok := clientInfoMap[GetNamespacedKey(peerRef.ClusterName, peerRef.StorageClusterRef.Namespace, peerRef.StorageClusterRef.Name)]; ok {
+			return clientInfo, nil
+		}
+	}
+	return GetClientInfo(clientInfoMap, GetKey(peerRef.ClusterName, peerRef.StorageClusterRef.Name))
+}
+
+// GetClientInfo returns the client info stored under the given key
+func GetClientInfo(clientInfoMap map[string]ClientInfo, key string) (ClientInfo, error) {
+	clientInfo, ok := clientInfoMap[key]
+	if !ok {
+		return ClientInfo{}, fmt.Errorf("client info for %s not found in StorageInventories", key)
+	}
+	return clientInfo, nil
+}
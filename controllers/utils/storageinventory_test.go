@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetClientInfoFromStorageInventories(t *testing.T) {
+	inventories := []multiclusterv1alpha1.StorageInventory{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+			Spec:       multiclusterv1alpha1.StorageInventorySpec{ClusterName: "cluster1"},
+			Status: multiclusterv1alpha1.StorageInventoryStatus{
+				Providers: []multiclusterv1alpha1.StorageProvider{
+					{Name: "ocs-storagecluster", Namespace: "openshift-storage", DeploymentType: "internal"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster1.external"},
+			Spec:       multiclusterv1alpha1.StorageInventorySpec{ClusterName: "cluster1"},
+			Status: multiclusterv1alpha1.StorageInventoryStatus{
+				Providers: []multiclusterv1alpha1.StorageProvider{
+					{Name: "ocs-storagecluster", Namespace: "openshift-storage-extended", DeploymentType: ExternalDeploymentType},
+					{Name: "ocs-external-storagecluster", Namespace: "openshift-storage-extended", DeploymentType: ExternalDeploymentType},
+				},
+			},
+		},
+	}
+
+	clientInfoMap := GetClientInfoFromStorageInventories(inventories)
+
+	t.Run("StorageClusters sharing a name are only keyed by namespace", func(t *testing.T) {
+		assert.NotContains(t, clientInfoMap, GetKey("cluster1", "ocs-storagecluster"))
+		assert.Equal(t, "internal", clientInfoMap[GetNamespacedKey("cluster1", "openshift-storage", "ocs-storagecluster")].ProviderInfo.DeploymentType)
+		assert.Equal(t, ExternalDeploymentType, clientInfoMap[GetNamespacedKey("cluster1", "openshift-storage-extended", "ocs-storagecluster")].ProviderInfo.DeploymentType)
+	})
+
+	t.Run("Unique StorageClusters keep their plain key", func(t *testing.T) {
+		assert.Contains(t, clientInfoMap, GetKey("cluster1", "ocs-external-storagecluster"))
+		assert.Contains(t, clientInfoMap, GetNamespacedKey("cluster1", "openshift-storage-extended", "ocs-external-storagecluster"))
+	})
+
+	t.Run("PeerRefs resolve by namespace first", func(t *testing.T) {
+		clientInfo, err := GetClientInfoForPeerRef(clientInfoMap, multiclusterv1alpha1.PeerRef{
+			ClusterName:       "cluster1",
+			StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage-extended"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, ExternalDeploymentType, clientInfo.ProviderInfo.DeploymentType)
+
+		_, err = GetClientInfoForPeerRef(clientInfoMap, multiclusterv1alpha1.PeerRef{
+			ClusterName:       "cluster1",
+			StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster"},
+		})
+		assert.Error(t, err)
+
+		_, err = GetClientInfoForPeerRef(clientInfoMap, multiclusterv1alpha1.PeerRef{
+			ClusterName:       "cluster1",
+			StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-external-storagecluster"},
+		})
+		assert.NoError(t, err)
+	})
+}
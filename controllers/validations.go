@@ -20,11 +20,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"reflect"
+	"slices"
+	"sort"
+	"time"
 
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
 	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
-	"github.com/red-hat-storage/odf-multicluster-orchestrator/version"
 	"k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -64,20 +68,184 @@ func isManagedCluster(ctx context.Context, client client.Client, clusterName str
 		}
 		return fmt.Errorf("validation: unable to get ManagedCluster %q: error: %v", clusterName, err)
 	}
+	if !mcluster.GetDeletionTimestamp().IsZero() {
+		return fmt.Errorf("validation: ManagedCluster %q is being detached from the hub", clusterName)
+	}
+	return nil
+}
+
+// peerRefsInStorageInventory checks that every StorageCluster or StorageClient of the MirrorPeer is still reported by the
+// StorageInventories. Entries are pruned when a StorageClient is offboarded or a StorageCluster is uninstalled.
+func peerRefsInStorageInventory(mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo) error {
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		if _, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef); err != nil {
+			return fmt.Errorf("validation: %q on ManagedCluster %q is no longer reported by the StorageInventories", peerRef.StorageClusterRef.Name, peerRef.ClusterName)
+		}
+	}
 	return nil
 }
 
-func isVersionCompatible(peerRef multiclusterv1alpha1.PeerRef, clientInfoMap map[string]string) error {
-	clientInfo, err := utils.GetClientInfoFromConfigMap(clientInfoMap, utils.GetKey(peerRef.ClusterName, peerRef.StorageClusterRef.Name))
+// isPeerRefFresh checks that the odf-info of the StorageCluster or StorageClient has been refreshed within the threshold
+func isPeerRefFresh(peerRef multiclusterv1alpha1.PeerRef, clientInfoMap map[string]utils.ClientInfo, threshold time.Duration, now time.Time) error {
+	clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef)
 	if err != nil {
 		return fmt.Errorf("validation: unable to get client info: error: %v", err)
 	}
-	eq, err := utils.CompareSemverMajorMinorVersions(clientInfo.ProviderInfo.Version, version.Version, utils.Eq)
+	if clientInfo.ProviderInfo.IsStale(threshold, now) {
+		return fmt.Errorf("validation: odf-info of %q on ManagedCluster %q was last refreshed at %s, which is longer ago than %s",
+			peerRef.StorageClusterRef.Name, peerRef.ClusterName, clientInfo.ProviderInfo.LastRefreshTime.Format(time.RFC3339), threshold)
+	}
+	return nil
+}
+
+// validateSyncPeers checks that both sides of a sync (Metro-DR) MirrorPeer consume the same external Ceph cluster.
+// Both StorageClusters must be deployed in external mode, report the same Ceph FSID and publish the same storage IDs
+// for their default StorageClasses. The storage IDs also depend on the RADOS namespace and the subvolume group of
+// the StorageClasses, so they are compared as published by the agents of the ManagedClusters.
+func validateSyncPeers(ctx context.Context, c client.Client, mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo) error {
+	var fsid string
+	var storageIDs map[utils.CephType]string
+	for i, peerRef := range mirrorPeer.Spec.Items {
+		clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef)
+		if err != nil {
+			return fmt.Errorf("validation: unable to get client info: error: %v", err)
+		}
+		if clientInfo.ProviderInfo.DeploymentType != utils.ExternalDeploymentType {
+			return fmt.Errorf("validation: StorageCluster %q on ManagedCluster %q is deployed in %q mode. Sync MirrorPeers require both StorageClusters to be deployed in %q mode",
+				peerRef.StorageClusterRef.Name, peerRef.ClusterName, clientInfo.ProviderInfo.DeploymentType, utils.ExternalDeploymentType)
+		}
+		if clientInfo.ProviderInfo.CephClusterFSID == "" {
+			return fmt.Errorf("validation: Ceph FSID of StorageCluster %q on ManagedCluster %q is not yet known", peerRef.StorageClusterRef.Name, peerRef.ClusterName)
+		}
+		var managedCluster clusterv1.ManagedCluster
+		if err := c.Get(ctx, types.NamespacedName{Name: peerRef.ClusterName}, &managedCluster); err != nil {
+			return fmt.Errorf("validation: unable to get ManagedCluster %q: error: %v", peerRef.ClusterName, err)
+		}
+		peerStorageIDs, _ := utils.GetStorageIDs(&managedCluster, types.NamespacedName{Namespace: peerRef.StorageClusterRef.Namespace, Name: peerRef.StorageClusterRef.Name})
+		if len(peerStorageIDs) == 0 {
+			return fmt.Errorf("validation: storage IDs of StorageCluster %q on ManagedCluster %q are not yet known", peerRef.StorageClusterRef.Name, peerRef.ClusterName)
+		}
+		if i == 0 {
+			fsid = clientInfo.ProviderInfo.CephClusterFSID
+			storageIDs = peerStorageIDs
+			continue
+		}
+		if clientInfo.ProviderInfo.CephClusterFSID != fsid {
+			return fmt.Errorf("validation: ManagedClusters %q and %q are connected to different Ceph clusters (FSID %q and %q). Sync MirrorPeers require both StorageClusters to consume the same external Ceph cluster",
+				mirrorPeer.Spec.Items[0].ClusterName, peerRef.ClusterName, fsid, clientInfo.ProviderInfo.CephClusterFSID)
+		}
+		if !maps.Equal(peerStorageIDs, storageIDs) {
+			return fmt.Errorf("validation: ManagedClusters %q and %q have different storage IDs (%v and %v). Sync MirrorPeers require the default StorageClasses of both StorageClusters to use the same RADOS namespace and subvolume group",
+				mirrorPeer.Spec.Items[0].ClusterName, peerRef.ClusterName, storageIDs, peerStorageIDs)
+		}
+	}
+	return nil
+}
+
+// storageIDsChanged returns true when the storage IDs published by the agent of the ManagedCluster changed
+func storageIDsChanged(oldMC, newMC *clusterv1.ManagedCluster) bool {
+	oldValue, _ := utils.GetClusterClaimValue(oldMC, utils.StorageIDsClusterClaimName)
+	newValue, _ := utils.GetClusterClaimValue(newMC, utils.StorageIDsClusterClaimName)
+	return oldValue != newValue
+}
+
+// validateHeterogeneousPeers checks that a StorageClient peered with a StorageCluster does not consume the
+// StorageCluster it is peered with, as both peers would then store their data in the same Ceph cluster, and that the
+// StorageCluster runs a provider which the StorageClusterPeer of the StorageClient provider can connect to.
+func validateHeterogeneousPeers(mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo) error {
+	peerRefTypes, err := utils.GetPeerRefTypes(clientInfoMap, mirrorPeer)
 	if err != nil {
-		return fmt.Errorf("validation: unable to parse versions: error: %v", err)
+		return fmt.Errorf("validation: unable to get client info: error: %v", err)
+	}
+	if !slices.Contains(peerRefTypes, utils.PeerRefTypeStorageClient) || !slices.Contains(peerRefTypes, utils.PeerRefTypeStorageCluster) {
+		return nil
+	}
+	for i, peerRef := range mirrorPeer.Spec.Items {
+		clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef)
+		if err != nil {
+			return fmt.Errorf("validation: unable to get client info: error: %v", err)
+		}
+		if peerRefTypes[i] == utils.PeerRefTypeStorageCluster {
+			// StorageClusters are peered with the provider of the StorageClient through a StorageClusterPeer
+			if clientInfo.ProviderInfo.DeploymentType == utils.ExternalDeploymentType {
+				return fmt.Errorf("validation: StorageCluster %q on ManagedCluster %q is deployed in %q mode and can not be peered with a StorageClient",
+					peerRef.StorageClusterRef.Name, peerRef.ClusterName, utils.ExternalDeploymentType)
+			}
+			continue
+		}
+		for j, otherPeerRef := range mirrorPeer.Spec.Items {
+			if peerRefTypes[j] == utils.PeerRefTypeStorageCluster && otherPeerRef.ClusterName == clientInfo.ProviderInfo.ProviderManagedClusterName {
+				return fmt.Errorf("validation: StorageClient %q on ManagedCluster %q consumes StorageCluster %q of its peer ManagedCluster %q. The peers of a MirrorPeer must store their data in different Ceph clusters",
+					peerRef.StorageClusterRef.Name, peerRef.ClusterName, clientInfo.ProviderInfo.NamespacedName.Name, otherPeerRef.ClusterName)
+			}
+		}
 	}
-	if !eq {
-		return fmt.Errorf("validation: StorageCluster version %q on ManagedCluster %q is incompatible with Multicluster Orchestrator version %q", clientInfo.ProviderInfo.Version, peerRef.ClusterName, version.Version)
+	return nil
+}
+
+// validateSyncDRPolicies checks that DRPolicies over the clusters of a sync MirrorPeer do not set a scheduling interval,
+// as Ramen treats DRPolicies with a scheduling interval as async.
+func validateSyncDRPolicies(ctx context.Context, client client.Client, mirrorPeer multiclusterv1alpha1.MirrorPeer) error {
+	var drpolicyList ramenv1alpha1.DRPolicyList
+	if err := client.List(ctx, &drpolicyList); err != nil {
+		return fmt.Errorf("validation: unable to list DRPolicies: error: %v", err)
+	}
+	for _, dp := range drpolicyList.Items {
+		if !isMirrorPeerForClusters(&mirrorPeer, dp.Spec.DRClusters) {
+			continue
+		}
+		if dp.Spec.SchedulingInterval != "" {
+			return fmt.Errorf("validation: DRPolicy %q sets schedulingInterval %q over the clusters of sync MirrorPeer %q. Remove the schedulingInterval from the DRPolicy or recreate it without one",
+				dp.Name, dp.Spec.SchedulingInterval, mirrorPeer.Name)
+		}
+	}
+	return nil
+}
+
+// replicationParameterValidators holds the VolumeReplicationClass parameters which can be set on a DRPolicy
+// along with the validation for their values. Any other parameter is rejected.
+var replicationParameterValidators = map[string]func(string) error{
+	MirroringModeKey: func(value string) error {
+		if value != DefaultMirroringMode && value != JournalMirroringMode {
+			return fmt.Errorf("expected %q or %q", DefaultMirroringMode, JournalMirroringMode)
+		}
+		return nil
+	},
+	SchedulingStartTimeKey: func(value string) error {
+		for _, layout := range []string{time.RFC3339, "15:04:05Z07:00", time.TimeOnly} {
+			if _, err := time.Parse(layout, value); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a time in the %q or %q format", time.TimeOnly, time.RFC3339)
+	},
+	ReplicationSecretNameKey:      notEmpty,
+	ReplicationSecretNamespaceKey: notEmpty,
+}
+
+func notEmpty(value string) error {
+	if value == "" {
+		return fmt.Errorf("value must not be empty")
+	}
+	return nil
+}
+
+// validateReplicationParameters checks that only supported VolumeReplicationClass parameters with valid values are requested
+func validateReplicationParameters(parameters map[string]string) error {
+	keys := make([]string, 0, len(parameters))
+	for k := range parameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		validate, ok := replicationParameterValidators[k]
+		if !ok {
+			return fmt.Errorf("validation: unsupported VolumeReplicationClass parameter %q", k)
+		}
+		if err := validate(parameters[k]); err != nil {
+			return fmt.Errorf("validation: invalid value %q for VolumeReplicationClass parameter %q: %v", parameters[k], k, err)
+		}
 	}
 	return nil
 }
@@ -87,24 +255,23 @@ func isVersionCompatible(peerRef multiclusterv1alpha1.PeerRef, clientInfoMap map
 func checkStorageClusterPeerStatus(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer *multiclusterv1alpha1.MirrorPeer) (bool, error) {
 	logger.Info("Checking if StorageClusterPeer ManifestWorks have been created and reached Applied status")
 
-	// Fetch the client info ConfigMap
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	// Fetch the client info from the StorageInventories
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			logger.Info("Client info ConfigMap not found; requeuing for later retry")
+			logger.Info("No StorageInventory found; requeuing for later retry")
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to fetch client info ConfigMap: %w", err)
+		return false, fmt.Errorf("failed to fetch client info from StorageInventories: %w", err)
 	}
 
 	// Collect client information for each cluster in the MirrorPeer
 	items := mirrorPeer.Spec.Items
 	clientInfos := make([]utils.ClientInfo, 0, len(items))
 	for _, item := range items {
-		clientKey := utils.GetKey(item.ClusterName, item.StorageClusterRef.Name)
-		ci, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, clientKey)
+		ci, err := utils.GetClientInfoForPeerRef(clientInfoMap, item)
 		if err != nil {
-			logger.Error("Failed to get client info from ConfigMap", "ClientKey", clientKey)
+			logger.Error("Failed to get client info from StorageInventories", "ClientKey", utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
 			return false, err
 		}
 		clientInfos = append(clientInfos, ci)
@@ -154,26 +321,29 @@ func checkStorageClusterPeerStatus(ctx context.Context, client client.Client, lo
 func checkClientPairingConfigMapStatus(ctx context.Context, client client.Client, logger *slog.Logger, currentNamespace string, mirrorPeer *multiclusterv1alpha1.MirrorPeer) (bool, error) {
 	logger.Info("Checking if client pairing ConfigMap ManifestWorks have been created and reached Applied status")
 
-	// Fetch the client info ConfigMap
-	clientInfoMap, err := utils.FetchClientInfoConfigMap(ctx, client, currentNamespace)
+	// Fetch the client info from the StorageInventories
+	clientInfoMap, err := utils.FetchClientInfo(ctx, client)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			logger.Info("Client info ConfigMap not found; requeuing for later retry")
+			logger.Info("No StorageInventory found; requeuing for later retry")
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to fetch client info ConfigMap: %w", err)
+		return false, fmt.Errorf("failed to fetch client info from StorageInventories: %w", err)
 	}
 
 	// Collect client information for each cluster in the MirrorPeer
 	items := mirrorPeer.Spec.Items
 	clientInfos := make([]utils.ClientInfo, 0, len(items))
 	for _, item := range items {
-		clientKey := utils.GetKey(item.ClusterName, item.StorageClusterRef.Name)
-		ci, err := utils.GetClientInfoFromConfigMap(clientInfoMap.Data, clientKey)
+		ci, err := utils.GetClientInfoForPeerRef(clientInfoMap, item)
 		if err != nil {
-			logger.Error("Failed to get client info from ConfigMap", "ClientKey", clientKey)
+			logger.Error("Failed to get client info from StorageInventories", "ClientKey", utils.GetKey(item.ClusterName, item.StorageClusterRef.Name))
 			return false, err
 		}
+		if ci.ClientID == "" {
+			logger.Info("No client pairing ConfigMap is created as a peer of the MirrorPeer is a StorageCluster")
+			return true, nil
+		}
 		clientInfos = append(clientInfos, ci)
 	}
 
@@ -22,8 +22,11 @@ package controllers
 import (
 	"context"
 	"testing"
+	"time"
 
+	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
 	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -219,3 +222,430 @@ func TestIsManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReplicationParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "No parameters",
+			parameters: map[string]string{},
+			wantErr:    false,
+		},
+		{
+			name: "Supported parameters",
+			parameters: map[string]string{
+				MirroringModeKey:              JournalMirroringMode,
+				SchedulingStartTimeKey:        "14:00:00",
+				ReplicationSecretNameKey:      "rook-csi-rbd-provisioner",
+				ReplicationSecretNamespaceKey: "openshift-storage",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unsupported parameter",
+			parameters: map[string]string{
+				"pool": "replicapool",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Scheduling interval can not be overridden",
+			parameters: map[string]string{
+				SchedulingIntervalKey: "1m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid mirroring mode",
+			parameters: map[string]string{
+				MirroringModeKey: "async",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid scheduling start time",
+			parameters: map[string]string{
+				SchedulingStartTimeKey: "tomorrow",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReplicationParameters(tt.parameters); (err != nil) != tt.wantErr {
+				t.Errorf("validateReplicationParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPeerRefsInStorageInventory(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       "cluster1",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"},
+				},
+				{
+					ClusterName:       "cluster2",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		clientInfoMap map[string]utils.ClientInfo
+		wantErr       bool
+	}{
+		{
+			name: "Both StorageClusters reported",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-storagecluster": {},
+				"cluster2_ocs-storagecluster": {},
+			},
+			wantErr: false,
+		},
+		{
+			name: "StorageCluster pruned from the StorageInventory",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-storagecluster": {},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := peerRefsInStorageInventory(mirrorPeer, tt.clientInfoMap); (err != nil) != tt.wantErr {
+				t.Errorf("peerRefsInStorageInventory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsPeerRefFresh(t *testing.T) {
+	now := time.Now()
+	peerRef := multiclusterv1alpha1.PeerRef{
+		ClusterName:       "cluster1",
+		StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"},
+	}
+	clientInfoMap := func(lastRefreshTime *metav1.Time) map[string]utils.ClientInfo {
+		return map[string]utils.ClientInfo{
+			"cluster1_ocs-storagecluster": {ProviderInfo: utils.ProviderInfo{LastRefreshTime: lastRefreshTime}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		clientInfoMap map[string]utils.ClientInfo
+		threshold     time.Duration
+		wantErr       bool
+	}{
+		{
+			name:          "Refreshed within the threshold",
+			clientInfoMap: clientInfoMap(&metav1.Time{Time: now.Add(-time.Minute)}),
+			threshold:     time.Hour,
+			wantErr:       false,
+		},
+		{
+			name:          "Not refreshed within the threshold",
+			clientInfoMap: clientInfoMap(&metav1.Time{Time: now.Add(-2 * time.Hour)}),
+			threshold:     time.Hour,
+			wantErr:       true,
+		},
+		{
+			name:          "Staleness check disabled",
+			clientInfoMap: clientInfoMap(&metav1.Time{Time: now.Add(-2 * time.Hour)}),
+			threshold:     0,
+			wantErr:       false,
+		},
+		{
+			name:          "Refresh time not recorded",
+			clientInfoMap: clientInfoMap(nil),
+			threshold:     time.Hour,
+			wantErr:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := isPeerRefFresh(peerRef, tt.clientInfoMap, tt.threshold, now); (err != nil) != tt.wantErr {
+				t.Errorf("isPeerRefFresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSyncPeers(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Sync,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       "cluster1",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-external-storagecluster", Namespace: "openshift-storage"},
+				},
+				{
+					ClusterName:       "cluster2",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-external-storagecluster", Namespace: "openshift-storage"},
+				},
+			},
+		},
+	}
+	clientInfo := func(deploymentType, fsid string) utils.ClientInfo {
+		return utils.ClientInfo{ProviderInfo: utils.ProviderInfo{DeploymentType: deploymentType, CephClusterFSID: fsid}}
+	}
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	managedCluster := func(name, storageIDs string) *clusterv1.ManagedCluster {
+		mc := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if storageIDs != "" {
+			mc.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{{Name: utils.StorageIDsClusterClaimName, Value: storageIDs}}
+		}
+		return mc
+	}
+	storageIDs := "openshift-storage/ocs-external-storagecluster/cephfs=cephfs-id,openshift-storage/ocs-external-storagecluster/rbd=rbd-id"
+	sameCluster := map[string]utils.ClientInfo{
+		"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+		"cluster2_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+	}
+
+	tests := []struct {
+		name            string
+		clientInfoMap   map[string]utils.ClientInfo
+		managedClusters []client.Object
+		wantErr         bool
+	}{
+		{
+			name: "External StorageClusters sharing a Ceph cluster",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+				"cluster2_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+			},
+			wantErr: false,
+		},
+		{
+			name:          "External StorageClusters with different RADOS namespaces",
+			clientInfoMap: sameCluster,
+			managedClusters: []client.Object{
+				managedCluster("cluster1", storageIDs),
+				managedCluster("cluster2", "openshift-storage/ocs-external-storagecluster/cephfs=cephfs-id,openshift-storage/ocs-external-storagecluster/rbd=other-rbd-id"),
+			},
+			wantErr: true,
+		},
+		{
+			name:          "External StorageClusters with a different set of default StorageClasses",
+			clientInfoMap: sameCluster,
+			managedClusters: []client.Object{
+				managedCluster("cluster1", storageIDs),
+				managedCluster("cluster2", "openshift-storage/ocs-external-storagecluster/rbd=rbd-id"),
+			},
+			wantErr: true,
+		},
+		{
+			name:          "Storage IDs not yet published",
+			clientInfoMap: sameCluster,
+			managedClusters: []client.Object{
+				managedCluster("cluster1", storageIDs),
+				managedCluster("cluster2", ""),
+			},
+			wantErr: true,
+		},
+		{
+			name:          "Storage IDs published for another StorageCluster",
+			clientInfoMap: sameCluster,
+			managedClusters: []client.Object{
+				managedCluster("cluster1", storageIDs),
+				managedCluster("cluster2", "openshift-storage/other-storagecluster/rbd=rbd-id"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "External StorageClusters with different Ceph clusters",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+				"cluster2_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-2"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Internal StorageCluster",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+				"cluster2_ocs-external-storagecluster": clientInfo("internal", "fsid-1"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Ceph FSID not yet reported",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, ""),
+				"cluster2_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing client info",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"cluster1_ocs-external-storagecluster": clientInfo(utils.ExternalDeploymentType, "fsid-1"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managedClusters := tt.managedClusters
+			if managedClusters == nil {
+				managedClusters = []client.Object{managedCluster("cluster1", storageIDs), managedCluster("cluster2", storageIDs)}
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(managedClusters...).Build()
+			if err := validateSyncPeers(context.TODO(), fakeClient, mirrorPeer, tt.clientInfoMap); (err != nil) != tt.wantErr {
+				t.Errorf("validateSyncPeers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHeterogeneousPeers(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Async,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{
+					ClusterName:       "converged",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"},
+				},
+				{
+					ClusterName:       "client",
+					StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "storage-client", Namespace: "openshift-storage-client"},
+				},
+			},
+		},
+	}
+	storageCluster := func(deploymentType string) utils.ClientInfo {
+		return utils.ClientInfo{ProviderInfo: utils.ProviderInfo{DeploymentType: deploymentType, ProviderManagedClusterName: "converged"}}
+	}
+	storageClient := func(clientID, provider string) utils.ClientInfo {
+		return utils.ClientInfo{ClientID: clientID, ProviderInfo: utils.ProviderInfo{DeploymentType: "internal", ProviderManagedClusterName: provider}}
+	}
+
+	tests := []struct {
+		name          string
+		clientInfoMap map[string]utils.ClientInfo
+		wantErr       bool
+	}{
+		{
+			name: "StorageClient of a remote provider",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"converged_ocs-storagecluster": storageCluster("internal"),
+				"client_storage-client":        storageClient("client-id", "provider"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "StorageClient of the StorageCluster peer",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"converged_ocs-storagecluster": storageCluster("internal"),
+				"client_storage-client":        storageClient("client-id", "converged"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "External StorageCluster",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"converged_ocs-storagecluster": storageCluster(utils.ExternalDeploymentType),
+				"client_storage-client":        storageClient("client-id", "provider"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Homogeneous StorageClusters",
+			clientInfoMap: map[string]utils.ClientInfo{
+				"converged_ocs-storagecluster": storageCluster(utils.ExternalDeploymentType),
+				"client_storage-client":        storageCluster(utils.ExternalDeploymentType),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHeterogeneousPeers(mirrorPeer, tt.clientInfoMap); (err != nil) != tt.wantErr {
+				t.Errorf("validateHeterogeneousPeers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSyncDRPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := ramenv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mirrorpeer",
+		},
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Sync,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{ClusterName: "cluster1"},
+				{ClusterName: "cluster2"},
+			},
+		},
+	}
+	drpolicy := func(name, interval string, clusters ...string) *ramenv1alpha1.DRPolicy {
+		return &ramenv1alpha1.DRPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: ramenv1alpha1.DRPolicySpec{
+				SchedulingInterval: interval,
+				DRClusters:         clusters,
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		drpolicies []client.Object
+		wantErr    bool
+	}{
+		{
+			name:       "DRPolicy without scheduling interval",
+			drpolicies: []client.Object{drpolicy("sync", "", "cluster1", "cluster2")},
+			wantErr:    false,
+		},
+		{
+			name:       "DRPolicy with scheduling interval over other clusters",
+			drpolicies: []client.Object{drpolicy("async", "5m", "cluster1", "cluster3")},
+			wantErr:    false,
+		},
+		{
+			name:       "DRPolicy with scheduling interval over the sync clusters",
+			drpolicies: []client.Object{drpolicy("async", "5m", "cluster2", "cluster1")},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.drpolicies...).Build()
+			if err := validateSyncDRPolicies(context.TODO(), fakeClient, mirrorPeer); (err != nil) != tt.wantErr {
+				t.Errorf("validateSyncDRPolicies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
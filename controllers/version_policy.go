@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// VersionPolicyConfigMapName is the ConfigMap in the orchestrator namespace which holds the version skew policy
+	VersionPolicyConfigMapName = "odf-multicluster-version-policy"
+
+	// versionPolicyMaxMinorSkewKey holds the number of minor versions a StorageCluster may differ from the orchestrator
+	versionPolicyMaxMinorSkewKey = "maxMinorSkew"
+	// versionPolicyAllowedVersionsKey holds a comma separated list of major.minor or major.minor.patch versions which
+	// are compatible regardless of the skew
+	versionPolicyAllowedVersionsKey = "allowedVersions"
+	// versionPolicyMinimumVersionKeyPrefix prefixes the keys holding the minimum version required by a feature
+	versionPolicyMinimumVersionKeyPrefix = "minimumVersion."
+
+	// StorageClientFeature is the feature of MirrorPeers peering StorageClients
+	StorageClientFeature = "storageClient"
+)
+
+// VersionPolicy decides which StorageCluster versions are compatible with the version of the orchestrator
+type VersionPolicy struct {
+	// MaxMinorSkew is the number of minor versions a StorageCluster may be behind or ahead of the orchestrator
+	MaxMinorSkew uint64
+	// AllowedVersions are compatible regardless of MaxMinorSkew
+	AllowedVersions []string
+	// FeatureMinimumVersions is the minimum StorageCluster version of a MirrorPeer using the feature. Features are the
+	// MirrorPeer types and StorageClientFeature.
+	FeatureMinimumVersions map[string]string
+}
+
+// getVersionPolicy returns the version skew policy configured in the orchestrator namespace. Without the
+// VersionPolicyConfigMapName ConfigMap, StorageClusters must run the same minor version as the orchestrator.
+func getVersionPolicy(ctx context.Context, c client.Client, namespace string) (VersionPolicy, error) {
+	var cm corev1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{Name: VersionPolicyConfigMapName, Namespace: namespace}, &cm)
+	if k8serrors.IsNotFound(err) {
+		return VersionPolicy{}, nil
+	}
+	if err != nil {
+		return VersionPolicy{}, fmt.Errorf("failed to get ConfigMap %q: %w", VersionPolicyConfigMapName, err)
+	}
+	return parseVersionPolicy(cm.Data)
+}
+
+func parseVersionPolicy(data map[string]string) (VersionPolicy, error) {
+	policy := VersionPolicy{FeatureMinimumVersions: make(map[string]string)}
+	for key, value := range data {
+		value = strings.TrimSpace(value)
+		switch {
+		case key == versionPolicyMaxMinorSkewKey:
+			skew, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return VersionPolicy{}, fmt.Errorf("validation: %q of the version policy must be a non-negative integer, got %q", key, value)
+			}
+			policy.MaxMinorSkew = skew
+		case key == versionPolicyAllowedVersionsKey:
+			for _, allowed := range strings.Split(value, ",") {
+				allowed = strings.TrimSpace(allowed)
+				if allowed == "" {
+					continue
+				}
+				if _, err := semver.ParseTolerant(allowed); err != nil {
+					return VersionPolicy{}, fmt.Errorf("validation: invalid version %q in %q of the version policy: %w", allowed, key, err)
+				}
+				policy.AllowedVersions = append(policy.AllowedVersions, allowed)
+			}
+		case strings.HasPrefix(key, versionPolicyMinimumVersionKeyPrefix):
+			if _, err := semver.ParseTolerant(value); err != nil {
+				return VersionPolicy{}, fmt.Errorf("validation: invalid version %q in %q of the version policy: %w", value, key, err)
+			}
+			policy.FeatureMinimumVersions[strings.TrimPrefix(key, versionPolicyMinimumVersionKeyPrefix)] = value
+		default:
+			return VersionPolicy{}, fmt.Errorf("validation: unsupported key %q in the version policy", key)
+		}
+	}
+	return policy, nil
+}
+
+// isAllowListed returns true when the version matches an allow-listed major.minor or major.minor.patch version
+func (p VersionPolicy) isAllowListed(v semver.Version) bool {
+	return slices.ContainsFunc(p.AllowedVersions, func(allowed string) bool {
+		allowedVersion, err := semver.ParseTolerant(allowed)
+		if err != nil || allowedVersion.Major != v.Major || allowedVersion.Minor != v.Minor {
+			return false
+		}
+		// major.minor entries allow every patch version
+		return strings.Count(allowed, ".") < 2 || allowedVersion.Patch == v.Patch
+	})
+}
+
+// check returns the reason why the StorageCluster version is compatible or not with the orchestrator version for
+// MirrorPeers using the features
+func (p VersionPolicy) check(storageVersion, orchestratorVersion string, features []string) (string, bool, error) {
+	v, err := semver.Parse(storageVersion)
+	if err != nil {
+		return multiclusterv1alpha1.MirrorPeerReasonVersionUnknown, false, fmt.Errorf("unable to parse StorageCluster version %q: %w", storageVersion, err)
+	}
+	orchestrator, err := semver.Parse(orchestratorVersion)
+	if err != nil {
+		return multiclusterv1alpha1.MirrorPeerReasonVersionUnknown, false, fmt.Errorf("unable to parse Multicluster Orchestrator version %q: %w", orchestratorVersion, err)
+	}
+
+	// Pre-releases and builds of a version provide the features of the version
+	release := semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	for _, feature := range features {
+		minimum, ok := p.FeatureMinimumVersions[feature]
+		if !ok {
+			continue
+		}
+		minimumVersion, err := semver.ParseTolerant(minimum)
+		if err != nil || release.LT(minimumVersion) {
+			return multiclusterv1alpha1.MirrorPeerReasonFeatureVersionUnsupported, false, fmt.Errorf("feature %q requires StorageCluster version %s or later, got %q", feature, minimum, storageVersion)
+		}
+	}
+
+	if v.Major == orchestrator.Major && v.Minor == orchestrator.Minor {
+		return multiclusterv1alpha1.MirrorPeerReasonVersionsMatch, true, nil
+	}
+	if p.isAllowListed(v) {
+		return multiclusterv1alpha1.MirrorPeerReasonVersionAllowListed, true, nil
+	}
+	if v.Major == orchestrator.Major && max(v.Minor, orchestrator.Minor)-min(v.Minor, orchestrator.Minor) <= p.MaxMinorSkew {
+		return multiclusterv1alpha1.MirrorPeerReasonVersionSkewAllowed, true, nil
+	}
+	return multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded, false, fmt.Errorf("StorageCluster version %q is more than %d minor versions apart from Multicluster Orchestrator version %q", storageVersion, p.MaxMinorSkew, orchestratorVersion)
+}
+
+// getMirrorPeerFeatures returns the features of the MirrorPeer which may require a minimum StorageCluster version
+func getMirrorPeerFeatures(mirrorPeer multiclusterv1alpha1.MirrorPeer, clientInfo utils.ClientInfo) []string {
+	features := []string{string(mirrorPeer.Spec.Type)}
+	if clientInfo.ClientID != "" {
+		features = append(features, StorageClientFeature)
+	}
+	return features
+}
+
+// setVersionCompatibleCondition reports on the MirrorPeer whether the versions of its StorageClusters comply with the
+// version policy. It returns true when the condition changed.
+func setVersionCompatibleCondition(mirrorPeer *multiclusterv1alpha1.MirrorPeer, clientInfoMap map[string]utils.ClientInfo, policy VersionPolicy, policyErr error, orchestratorVersion string) bool {
+	condition := metav1.Condition{
+		Type:               multiclusterv1alpha1.MirrorPeerConditionVersionCompatible,
+		Status:             metav1.ConditionTrue,
+		Reason:             multiclusterv1alpha1.MirrorPeerReasonVersionsMatch,
+		ObservedGeneration: mirrorPeer.Generation,
+	}
+	if policyErr != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = multiclusterv1alpha1.MirrorPeerReasonVersionPolicyInvalid
+		condition.Message = fmt.Sprintf("ConfigMap %q is invalid: %v", VersionPolicyConfigMapName, policyErr)
+		return meta.SetStatusCondition(&mirrorPeer.Status.Conditions, condition)
+	}
+
+	var versions []string
+	for _, peerRef := range mirrorPeer.Spec.Items {
+		clientInfo, err := utils.GetClientInfoForPeerRef(clientInfoMap, peerRef)
+		if err != nil {
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = multiclusterv1alpha1.MirrorPeerReasonVersionUnknown
+			condition.Message = err.Error()
+			break
+		}
+		reason, compatible, err := policy.check(clientInfo.ProviderInfo.Version, orchestratorVersion, getMirrorPeerFeatures(*mirrorPeer, clientInfo))
+		if !compatible {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reason
+			condition.Message = fmt.Sprintf("%q on ManagedCluster %q: %v", peerRef.StorageClusterRef.Name, peerRef.ClusterName, err)
+			break
+		}
+		// A skew or an allow-listed version is reported over matching versions
+		if reason != multiclusterv1alpha1.MirrorPeerReasonVersionsMatch {
+			condition.Reason = reason
+		}
+		versions = append(versions, fmt.Sprintf("%s/%s=%s", peerRef.ClusterName, peerRef.StorageClusterRef.Name, clientInfo.ProviderInfo.Version))
+	}
+	if condition.Status == metav1.ConditionTrue {
+		condition.Message = fmt.Sprintf("StorageCluster versions %s are compatible with Multicluster Orchestrator version %s", strings.Join(versions, ", "), orchestratorVersion)
+	}
+	return meta.SetStatusCondition(&mirrorPeer.Status.Conditions, condition)
+}
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	multiclusterv1alpha1 "github.com/red-hat-storage/odf-multicluster-orchestrator/api/v1alpha1"
+	"github.com/red-hat-storage/odf-multicluster-orchestrator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseVersionPolicy(t *testing.T) {
+	policy, err := parseVersionPolicy(map[string]string{
+		"maxMinorSkew":         "1",
+		"allowedVersions":      "4.16, 4.15.3",
+		"minimumVersion.sync":  "4.18",
+		"minimumVersion.async": "4.17.2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, VersionPolicy{
+		MaxMinorSkew:           1,
+		AllowedVersions:        []string{"4.16", "4.15.3"},
+		FeatureMinimumVersions: map[string]string{"sync": "4.18", "async": "4.17.2"},
+	}, policy)
+
+	for name, data := range map[string]map[string]string{
+		"Negative skew":           {"maxMinorSkew": "-1"},
+		"Invalid allowed version": {"allowedVersions": "4.x"},
+		"Invalid minimum version": {"minimumVersion.sync": "latest"},
+		"Unsupported key":         {"maxMajorSkew": "1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseVersionPolicy(data)
+			assert.ErrorContains(t, err, "validation: ")
+		})
+	}
+}
+
+func TestVersionPolicyCheck(t *testing.T) {
+	policy := VersionPolicy{
+		MaxMinorSkew:           1,
+		AllowedVersions:        []string{"4.16", "4.15.3"},
+		FeatureMinimumVersions: map[string]string{"sync": "4.19.1"},
+	}
+
+	tests := []struct {
+		name           string
+		policy         VersionPolicy
+		storageVersion string
+		features       []string
+		wantReason     string
+		wantCompatible bool
+	}{
+		{"Same minor version", VersionPolicy{}, "4.19.3", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionsMatch, true},
+		{"Minor skew without policy", VersionPolicy{}, "4.18.0", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded, false},
+		{"N-1 minor skew", policy, "4.18.0", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewAllowed, true},
+		{"N+1 minor skew", policy, "4.20.0", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewAllowed, true},
+		{"N-2 minor skew", policy, "4.17.0", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded, false},
+		{"Allow-listed minor version", policy, "4.16.5", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionAllowListed, true},
+		{"Allow-listed patch version", policy, "4.15.3", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionAllowListed, true},
+		{"Patch version not allow-listed", policy, "4.15.4", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded, false},
+		{"Different major version", policy, "5.19.0", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded, false},
+		{"Feature minimum version met", policy, "4.19.1-rc.1", []string{"sync"}, multiclusterv1alpha1.MirrorPeerReasonVersionsMatch, true},
+		{"Feature minimum version not met", policy, "4.19.0", []string{"sync"}, multiclusterv1alpha1.MirrorPeerReasonFeatureVersionUnsupported, false},
+		{"Unparsable version", policy, "unknown", []string{"async"}, multiclusterv1alpha1.MirrorPeerReasonVersionUnknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, compatible, err := tt.policy.check(tt.storageVersion, "4.19.0", tt.features)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.wantCompatible, compatible)
+			assert.Equal(t, !tt.wantCompatible, err != nil)
+		})
+	}
+}
+
+func TestSetVersionCompatibleCondition(t *testing.T) {
+	mirrorPeer := multiclusterv1alpha1.MirrorPeer{
+		Spec: multiclusterv1alpha1.MirrorPeerSpec{
+			Type: multiclusterv1alpha1.Async,
+			Items: []multiclusterv1alpha1.PeerRef{
+				{ClusterName: "cluster1", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"}},
+				{ClusterName: "cluster2", StorageClusterRef: multiclusterv1alpha1.StorageClusterRef{Name: "ocs-storagecluster", Namespace: "openshift-storage"}},
+			},
+		},
+	}
+	clientInfoMap := func(version1, version2 string) map[string]utils.ClientInfo {
+		return map[string]utils.ClientInfo{
+			"cluster1_ocs-storagecluster": {ProviderInfo: utils.ProviderInfo{Version: version1}},
+			"cluster2_ocs-storagecluster": {ProviderInfo: utils.ProviderInfo{Version: version2}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		clientInfoMap map[string]utils.ClientInfo
+		policyErr     error
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{"Matching versions", clientInfoMap("4.19.0", "4.19.2"), nil, metav1.ConditionTrue, multiclusterv1alpha1.MirrorPeerReasonVersionsMatch},
+		{"Site upgraded first", clientInfoMap("4.20.0", "4.19.2"), nil, metav1.ConditionTrue, multiclusterv1alpha1.MirrorPeerReasonVersionSkewAllowed},
+		{"Skew exceeded", clientInfoMap("4.19.0", "4.17.0"), nil, metav1.ConditionFalse, multiclusterv1alpha1.MirrorPeerReasonVersionSkewExceeded},
+		{"Invalid policy", clientInfoMap("4.19.0", "4.19.0"), errors.New("validation: unsupported key"), metav1.ConditionUnknown, multiclusterv1alpha1.MirrorPeerReasonVersionPolicyInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := mirrorPeer.DeepCopy()
+			changed := setVersionCompatibleCondition(mp, tt.clientInfoMap, VersionPolicy{MaxMinorSkew: 1}, tt.policyErr, "4.19.0")
+			assert.True(t, changed)
+			cond := meta.FindStatusCondition(mp.Status.Conditions, multiclusterv1alpha1.MirrorPeerConditionVersionCompatible)
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, tt.wantStatus, cond.Status)
+				assert.Equal(t, tt.wantReason, cond.Reason)
+			}
+			assert.False(t, setVersionCompatibleCondition(mp, tt.clientInfoMap, VersionPolicy{MaxMinorSkew: 1}, tt.policyErr, "4.19.0"))
+		})
+	}
+}
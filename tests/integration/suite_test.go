@@ -26,7 +26,6 @@ import (
 	"testing"
 
 	ramenv1alpha1 "github.com/ramendr/ramen/api/v1alpha1"
-	viewv1beta1 "github.com/stolostron/multicloud-operators-foundation/pkg/apis/view/v1beta1"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -108,6 +107,7 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(mgr).NotTo(BeNil())
+	Expect(utils.AddHubIndexers(ctx, mgr.GetFieldIndexer())).To(Succeed())
 
 	fakeLogger := utils.GetLogger(utils.GetZapLogger(true))
 	err = (&controllers.MirrorPeerReconciler{
@@ -131,30 +131,35 @@ var _ = BeforeSuite(func() {
 	err = k8sClient.Create(context.TODO(), nsOpenshiftOperators, &client.CreateOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	odfClientInfoConfigMap := &v1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "odf-client-info",
-			Namespace: "openshift-operators",
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion: viewv1beta1.GroupVersion.String(),
-					Kind:       "ManagedClusterView",
-					Name:       "mcv-1",
-					UID:        "mcv-uid",
-				},
+	storageClusters := map[string]string{
+		"mc-1":                   "test-storagecluster1",
+		"mc-2":                   "test-storagecluster2",
+		"test-provider-cluster1": "test-storagecluster-1",
+		"test-provider-cluster2": "test-storagecluster-2",
+		"cluster1":               "test-storagecluster",
+		"cluster2":               "test-storagecluster",
+	}
+	for clusterName, storageClusterName := range storageClusters {
+		inventory := &multiclusterv1alpha1.StorageInventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterName,
 			},
-		},
-		Data: map[string]string{
-			"mc-1_test-storagecluster1":                    "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"mc-2_test-storagecluster2":                    "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"test-provider-cluster1_test-storagecluster-1": "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"test-provider-cluster2_test-storagecluster-2": "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"cluster1_test-storagecluster":                 "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-			"cluster2_test-storagecluster":                 "{\"providerInfo\":{\"version\":\"4.19.0\", \"deploymentType\": \"external\"}}",
-		},
+			Spec: multiclusterv1alpha1.StorageInventorySpec{
+				ClusterName: clusterName,
+			},
+		}
+		err = k8sClient.Create(context.TODO(), inventory, &client.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		inventory.Status.Providers = []multiclusterv1alpha1.StorageProvider{
+			{
+				Name:           storageClusterName,
+				Version:        "4.19.0",
+				DeploymentType: "external",
+			},
+		}
+		err = k8sClient.Status().Update(context.TODO(), inventory)
+		Expect(err).NotTo(HaveOccurred())
 	}
-	err = k8sClient.Create(context.TODO(), odfClientInfoConfigMap, &client.CreateOptions{})
-	Expect(err).NotTo(HaveOccurred())
 
 }, 60)
 